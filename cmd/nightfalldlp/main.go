@@ -5,12 +5,21 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer/azure"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer/bitbucket"
 	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer/circleci"
 	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer/github"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer/gitlab"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer/local"
 	"github.com/nightfallai/nightfall_code_scanner/internal/clients/flag"
 	"github.com/nightfallai/nightfall_code_scanner/internal/clients/nightfall"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/reporter"
+	"github.com/nightfallai/nightfall_code_scanner/internal/nightfallconfig"
 )
 
 const (
@@ -18,7 +27,17 @@ const (
 	githubActionsEnvVar     = "GITHUB_ACTIONS"
 	githubTokenEnvVar       = "GITHUB_TOKEN"
 	githubApiBaseUrlEnvVar  = "BASE_URL"
+	githubAppIDEnvVar       = "GITHUB_APP_ID"
+	githubAppInstallEnvVar  = "GITHUB_APP_INSTALLATION_ID"
+	githubAppKeyEnvVar      = "GITHUB_APP_PRIVATE_KEY"
 	circleCiEnvVar          = "CIRCLECI"
+	gitlabCiEnvVar          = "GITLAB_CI"
+	gitlabTokenEnvVar       = "GITLAB_TOKEN"
+	gitlabApiUrlEnvVar      = "CI_API_V4_URL"
+	bitbucketBuildEnvVar    = "BITBUCKET_BUILD_NUMBER"
+	bitbucketTokenEnvVar    = "BITBUCKET_ACCESS_TOKEN"
+	azurePipelinesEnvVar    = "TF_BUILD"
+	azureTokenEnvVar        = "SYSTEM_ACCESSTOKEN"
 )
 
 // main starts the service process.
@@ -31,12 +50,32 @@ func main() {
 
 func run() error {
 	ctx := context.Background()
-	_, done := flag.Parse(os.Args[1:])
+	flagValues, done, err := flag.Parse(os.Args[1:])
+	if err != nil {
+		return err
+	}
 	if done {
 		return nil
 	}
+	if flagValues.InstallHook != "" {
+		hookPath, err := local.InstallHook(flagValues.RepoPath, flagValues.InstallHook)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Installed %s hook at %s\n", flagValues.InstallHook, hookPath)
+		return nil
+	}
+	if flagValues.BaselineCreate {
+		return createBaseline(ctx, flagValues)
+	}
 
-	diffReviewClient, err := CreateDiffReviewerClient()
+	// validate the requested reports before scanning
+	reporters, err := createReporters(flagValues)
+	if err != nil {
+		return err
+	}
+
+	diffReviewClient, err := CreateDiffReviewerClient(flagValues)
 	if err != nil {
 		return err
 	}
@@ -55,12 +94,91 @@ func run() error {
 		return err
 	}
 
+	// the findings of a partial scan are still reported, along with the files that could not be scanned
+	var scanFailure *diffreviewer.ScanFailure
 	comments, err := nightfallClient.ReviewDiff(ctx, diffReviewClient.GetLogger(), fileDiffs)
+	if err != nil && !errors.As(err, &scanFailure) {
+		return err
+	}
+
+	for _, r := range reporters {
+		err = r.Report(comments, nightfallConfig.AnnotationLevel)
+		if err != nil {
+			return err
+		}
+	}
+
+	return diffReviewClient.WriteComments(comments, nightfallConfig.AnnotationLevel, scanFailure)
+}
+
+// createBaseline scans every file of the repository, or the --base range if given,
+// and records the findings in the baseline file so they are not reported again
+func createBaseline(ctx context.Context, flagValues *flag.Values) error {
+	base := flagValues.Base
+	if base == "" {
+		base = local.EmptyTree
+	}
+	localService := local.NewLocalService(flagValues.RepoPath, base, flagValues.Head)
+	logger := localService.GetLogger()
+	nightfallConfig, err := localService.LoadConfig(nightfallConfigFileName)
 	if err != nil {
 		return err
 	}
+	// every current finding is recorded, including those of the existing baseline
+	nightfallConfig.Baseline = nil
+	nightfallClient, err := nightfall.NewClient(*nightfallConfig)
+	if err != nil {
+		return err
+	}
+
+	fileDiffs, err := localService.GetDiff()
+	if err != nil {
+		return err
+	}
+	comments, err := nightfallClient.ReviewDiff(ctx, logger, fileDiffs)
+	if err != nil {
+		return err
+	}
+
+	baseline := nightfallconfig.NewBaseline()
+	for _, c := range comments {
+		if c.Finding != nil {
+			baseline.Add(nightfallconfig.NewBaselineFingerprint(c.FilePath, c.DetectorName, c.Finding.Finding))
+		}
+	}
+	baselinePath := filepath.Join(flagValues.RepoPath, nightfallconfig.BaselineFileName)
+	if err := baseline.Write(baselinePath); err != nil {
+		return err
+	}
+	logger.Info(fmt.Sprintf("Recorded %d findings in %s", baseline.Len(), baselinePath))
+	return nil
+}
 
-	return diffReviewClient.WriteComments(comments, nightfallConfig.AnnotationLevel)
+// githubAppCredentials reads the GitHub App credentials from the environment,
+// nil if no app ID is set so that the static github token is used
+func githubAppCredentials() (*github.AppCredentials, error) {
+	appIDValue, ok := os.LookupEnv(githubAppIDEnvVar)
+	if !ok || appIDValue == "" {
+		return nil, nil
+	}
+	appID, err := strconv.ParseInt(appIDValue, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s environment variable %q: %w", githubAppIDEnvVar, appIDValue, err)
+	}
+	installationIDValue := os.Getenv(githubAppInstallEnvVar)
+	installationID, err := strconv.ParseInt(installationIDValue, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s environment variable %q: %w", githubAppInstallEnvVar, installationIDValue, err)
+	}
+	privateKey, ok := os.LookupEnv(githubAppKeyEnvVar)
+	if !ok || privateKey == "" {
+		return nil, fmt.Errorf("could not find required %s environment variable", githubAppKeyEnvVar)
+	}
+	return &github.AppCredentials{
+		AppID:          appID,
+		InstallationID: installationID,
+		PrivateKey:     []byte(privateKey),
+	}, nil
 }
 
 // usingGithubAction determine if nightfalldlp is being run by
@@ -82,18 +200,80 @@ func usingCircleCi() bool {
 	return val == "true"
 }
 
+// usingGitlabCi determine if nightfalldlp is being triggered by GitLab CI
+func usingGitlabCi() bool {
+	val, ok := os.LookupEnv(gitlabCiEnvVar)
+	if !ok {
+		return false
+	}
+	return val == "true"
+}
+
+// usingBitbucketPipelines determine if nightfalldlp is being triggered by Bitbucket Pipelines
+func usingBitbucketPipelines() bool {
+	val, ok := os.LookupEnv(bitbucketBuildEnvVar)
+	return ok && val != ""
+}
+
+// usingAzurePipelines determine if nightfalldlp is being triggered by Azure Pipelines
+func usingAzurePipelines() bool {
+	val, ok := os.LookupEnv(azurePipelinesEnvVar)
+	if !ok {
+		return false
+	}
+	return strings.EqualFold(val, "true")
+}
+
+// createReporters returns a reporter for each report file requested by flag
+func createReporters(flagValues *flag.Values) ([]reporter.Reporter, error) {
+	var reporters []reporter.Reporter
+	if flagValues.Sarif != "" {
+		reporters = append(reporters, reporter.NewSarifReporter(flagValues.Sarif))
+	}
+	for _, output := range flagValues.Outputs {
+		r, err := reporter.ParseOutput(output)
+		if err != nil {
+			return nil, err
+		}
+		reporters = append(reporters, r)
+	}
+	return reporters, nil
+}
+
 // CreateDiffReviewerClient determines the current environment that is running nightfalldlp
 // and returns the corresponding DiffReviewer client
-func CreateDiffReviewerClient() (diffreviewer.DiffReviewer, error) {
+func CreateDiffReviewerClient(flagValues *flag.Values) (diffreviewer.DiffReviewer, error) {
 	baseUrl, _ := os.LookupEnv(githubApiBaseUrlEnvVar)
 	switch {
+	case flagValues.Path != "":
+		return local.NewPathService(flagValues.Path), nil
+	case flagValues.History:
+		return local.NewHistoryService(flagValues.RepoPath, flagValues.Base, flagValues.Head), nil
+	case flagValues.Hook != "":
+		return local.NewHookService(flagValues.RepoPath, flagValues.Hook)
+	case flagValues.Local():
+		return local.NewLocalService(flagValues.RepoPath, flagValues.Base, flagValues.Head), nil
 	case usingGithubAction():
+		appCredentials, err := githubAppCredentials()
+		if err != nil {
+			return nil, err
+		}
+		if appCredentials != nil {
+			return github.NewAppAuthenticatedGithubService(*appCredentials, baseUrl)
+		}
 		githubToken, ok := os.LookupEnv(githubTokenEnvVar)
 		if !ok {
 			return nil, fmt.Errorf("could not find required %s environment variable", githubTokenEnvVar)
 		}
 		return github.NewAuthenticatedGithubService(githubToken, baseUrl), nil
 	case usingCircleCi():
+		appCredentials, err := githubAppCredentials()
+		if err != nil {
+			return nil, err
+		}
+		if appCredentials != nil {
+			return circleci.NewCircleCiServiceWithGithubAppComments(*appCredentials, baseUrl)
+		}
 		githubToken, ok := os.LookupEnv(githubTokenEnvVar)
 		if !ok || githubToken == "" {
 			circleService := circleci.NewCircleCiService()
@@ -101,7 +281,33 @@ func CreateDiffReviewerClient() (diffreviewer.DiffReviewer, error) {
 			return circleService, nil
 		}
 		return circleci.NewCircleCiServiceWithGithubComments(githubToken, baseUrl), nil
+	case usingGitlabCi():
+		gitlabToken, ok := os.LookupEnv(gitlabTokenEnvVar)
+		if !ok || gitlabToken == "" {
+			gitlabService := gitlab.NewGitlabService()
+			gitlabService.GetLogger().Info("GitLab Token not found - findings will only be posted to the GitLab job log")
+			return gitlabService, nil
+		}
+		gitlabApiUrl, _ := os.LookupEnv(gitlabApiUrlEnvVar)
+		return gitlab.NewGitlabServiceWithMergeRequestComments(gitlabToken, gitlabApiUrl), nil
+	case usingBitbucketPipelines():
+		bitbucketToken, ok := os.LookupEnv(bitbucketTokenEnvVar)
+		if !ok || bitbucketToken == "" {
+			bitbucketService := bitbucket.NewBitbucketService()
+			bitbucketService.GetLogger().Info("Bitbucket access token not found - findings will only be posted to the Pipelines step log")
+			return bitbucketService, nil
+		}
+		return bitbucket.NewBitbucketServiceWithCodeInsights(bitbucketToken), nil
+	case usingAzurePipelines():
+		azureToken, ok := os.LookupEnv(azureTokenEnvVar)
+		if !ok || azureToken == "" {
+			azureService := azure.NewAzureService()
+			azureService.GetLogger().Info("System.AccessToken not found - findings will only be posted to the pipeline log")
+			return azureService, nil
+		}
+		collectionURI, _ := os.LookupEnv(azure.CollectionURIEnvVar)
+		return azure.NewAzureServiceWithPullRequestThreads(azureToken, collectionURI), nil
 	default:
-		return nil, errors.New("current environment unknown")
+		return nil, errors.New("current environment unknown, use --base to scan a local git range")
 	}
 }
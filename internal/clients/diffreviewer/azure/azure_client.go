@@ -0,0 +1,163 @@
+package azure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	threadsAPIVersion  = "6.0"
+	statusesAPIVersion = "6.0-preview.1"
+	authorizationKey   = "Authorization"
+	bearerTokenPattern = "Bearer %s"
+)
+
+// Pull request thread and status enums
+// https://learn.microsoft.com/en-us/rest/api/azure/devops/git/pull-request-threads
+const (
+	CommentTypeText      = 1
+	ThreadStatusActive   = 1
+	StatusStateSucceeded = "succeeded"
+	StatusStateFailed    = "failed"
+	StatusContextName    = "nightfalldlp"
+	StatusContextGenre   = "security"
+)
+
+// Client is a minimal client for the Azure DevOps Git REST API
+type Client struct {
+	CollectionURL string
+	Token         string
+	HTTPClient    *http.Client
+}
+
+// CommentPosition is a line and character offset within a file
+type CommentPosition struct {
+	Line   int `json:"line"`
+	Offset int `json:"offset"`
+}
+
+// ThreadContext anchors a thread to a range of lines in the right (new) side of the diff
+type ThreadContext struct {
+	FilePath       string           `json:"filePath"`
+	RightFileStart *CommentPosition `json:"rightFileStart,omitempty"`
+	RightFileEnd   *CommentPosition `json:"rightFileEnd,omitempty"`
+}
+
+// Comment is a single comment within a pull request thread
+type Comment struct {
+	ParentCommentID int    `json:"parentCommentId"`
+	Content         string `json:"content"`
+	CommentType     int    `json:"commentType"`
+}
+
+// Thread is a pull request comment thread
+type Thread struct {
+	ID            int            `json:"id,omitempty"`
+	Comments      []*Comment     `json:"comments"`
+	Status        int            `json:"status"`
+	ThreadContext *ThreadContext `json:"threadContext,omitempty"`
+}
+
+// StatusContext identifies the service that posted a pull request status
+type StatusContext struct {
+	Name  string `json:"name"`
+	Genre string `json:"genre"`
+}
+
+// Status is a pull request status
+type Status struct {
+	State       string         `json:"state"`
+	Description string         `json:"description"`
+	Context     *StatusContext `json:"context"`
+	TargetURL   string         `json:"targetUrl,omitempty"`
+}
+
+type threadList struct {
+	Value []*Thread `json:"value"`
+	Count int       `json:"count"`
+}
+
+// NewAuthenticatedClient generates a client authenticated with the pipeline's System.AccessToken
+func NewAuthenticatedClient(token string, collectionURL string) *Client {
+	return &Client{
+		CollectionURL: strings.TrimSuffix(collectionURL, "/"),
+		Token:         token,
+		HTTPClient:    http.DefaultClient,
+	}
+}
+
+// ListThreads lists all threads on a pull request
+func (c *Client) ListThreads(ctx context.Context, project, repoID string, prID int) ([]*Thread, error) {
+	var threads threadList
+	err := c.do(ctx, http.MethodGet, c.pullRequestPath(project, repoID, prID, "threads", threadsAPIVersion), nil, &threads)
+	if err != nil {
+		return nil, err
+	}
+	return threads.Value, nil
+}
+
+// CreateThread creates a new thread on a pull request
+func (c *Client) CreateThread(ctx context.Context, project, repoID string, prID int, thread *Thread) (*Thread, error) {
+	var created Thread
+	err := c.do(ctx, http.MethodPost, c.pullRequestPath(project, repoID, prID, "threads", threadsAPIVersion), thread, &created)
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// CreateStatus posts a status to a pull request
+func (c *Client) CreateStatus(ctx context.Context, project, repoID string, prID int, status *Status) error {
+	return c.do(ctx, http.MethodPost, c.pullRequestPath(project, repoID, prID, "statuses", statusesAPIVersion), status, nil)
+}
+
+func (c *Client) pullRequestPath(project, repoID string, prID int, resource, apiVersion string) string {
+	return fmt.Sprintf(
+		"/%s/_apis/git/repositories/%s/pullRequests/%d/%s?api-version=%s",
+		url.PathEscape(project),
+		url.PathEscape(repoID),
+		prID,
+		resource,
+		apiVersion,
+	)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, v interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, c.CollectionURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set(authorizationKey, fmt.Sprintf(bearerTokenPattern, c.Token))
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("azure devops api %s %s returned %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if v != nil {
+		return json.NewDecoder(resp.Body).Decode(v)
+	}
+	return nil
+}
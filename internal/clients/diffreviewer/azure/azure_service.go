@@ -0,0 +1,318 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer/diffutils"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/gitdiff"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/logger"
+	azurelogger "github.com/nightfallai/nightfall_code_scanner/internal/clients/logger/azure_logger"
+	"github.com/nightfallai/nightfall_code_scanner/internal/interfaces/gitdiffintf"
+	"github.com/nightfallai/nightfall_code_scanner/internal/nightfallconfig"
+)
+
+// Predefined Azure Pipelines variables https://learn.microsoft.com/en-us/azure/devops/pipelines/build/variables
+const (
+	WorkspacePathEnvVar           = "BUILD_SOURCESDIRECTORY"
+	NightfallAPIKeyEnvVar         = "NIGHTFALL_API_KEY"
+	CommitShaEnvVar               = "BUILD_SOURCEVERSION"
+	BuildIDEnvVar                 = "BUILD_BUILDID"
+	CollectionURIEnvVar           = "SYSTEM_COLLECTIONURI"
+	TeamProjectEnvVar             = "SYSTEM_TEAMPROJECT"
+	RepositoryIDEnvVar            = "BUILD_REPOSITORY_ID"
+	PullRequestIDEnvVar           = "SYSTEM_PULLREQUEST_PULLREQUESTID"
+	PullRequestTargetBranchEnvVar = "SYSTEM_PULLREQUEST_TARGETBRANCH"
+
+	branchRefPrefix = "refs/heads/"
+	buildResultsURL = "%s/%s/_build/results?buildId=%s"
+	summaryString   = "Nightfall DLP has found %d potentially sensitive items"
+)
+
+var errSensitiveItemsFound = errors.New("potentially sensitive items found")
+
+// Service contains the Azure DevOps client that makes Azure DevOps api calls
+type Service struct {
+	Client    *Client
+	Logger    logger.Logger
+	GitDiff   gitdiffintf.GitDiff
+	PrDetails prDetails
+}
+
+type prDetails struct {
+	CommitSha    string
+	Project      string
+	RepositoryID string
+	BuildURL     string
+	PrID         *int
+}
+
+// NewAzureService creates a new Azure Pipelines service
+func NewAzureService() diffreviewer.DiffReviewer {
+	return &Service{
+		Logger: azurelogger.NewDefaultAzureLogger(),
+	}
+}
+
+// NewAzureServiceWithPullRequestThreads creates a new Azure Pipelines service with an authenticated Azure DevOps client
+func NewAzureServiceWithPullRequestThreads(token, collectionURL string) diffreviewer.DiffReviewer {
+	return &Service{
+		Client: NewAuthenticatedClient(token, collectionURL),
+		Logger: azurelogger.NewDefaultAzureLogger(),
+	}
+}
+
+// GetLogger gets the Azure service logger
+func (s *Service) GetLogger() logger.Logger {
+	return s.Logger
+}
+
+// LoadConfig gets all config values from files or environment and creates a config
+func (s *Service) LoadConfig(nightfallConfigFileName string) (*nightfallconfig.Config, error) {
+	s.Logger.Info("Loading configuration")
+	workspacePath, ok := os.LookupEnv(WorkspacePathEnvVar)
+	if !ok || workspacePath == "" {
+		s.Logger.Error(fmt.Sprintf("Environment variable %s cannot be found", WorkspacePathEnvVar))
+		return nil, errors.New("missing env var for workspace path")
+	}
+	prDetails, err := s.getPrDetails()
+	if err != nil {
+		return nil, err
+	}
+	s.PrDetails = *prDetails
+	// Azure Pipelines does not expose the previous commit of a push, so
+	// branch builds fall back to the diff of the most recent commit
+	targetBranch, _ := os.LookupEnv(PullRequestTargetBranchEnvVar)
+	s.GitDiff = &gitdiff.GitDiff{
+		WorkDir:    workspacePath,
+		BaseBranch: strings.TrimPrefix(targetBranch, branchRefPrefix),
+		Head:       s.PrDetails.CommitSha,
+	}
+	nightfallConfig, err := nightfallconfig.GetNightfallConfigFile(workspacePath, nightfallConfigFileName, s.Logger)
+	if err != nil {
+		s.Logger.Error("Error getting Nightfall config file. " +
+			"Ensure you have a Nightfall config file located in the root of your repository at .nightfalldlp/config.json " +
+			"with either a Condition Set UUID or at least one Condition enabled")
+		return nil, err
+	}
+	baseline, err := nightfallconfig.GetBaseline(workspacePath, nightfallconfig.BaselineFileName, s.Logger)
+	if err != nil {
+		s.Logger.Error(fmt.Sprintf("Error getting Nightfall baseline file. Recreate %s with the baseline create command", nightfallconfig.BaselineFileName))
+		return nil, err
+	}
+	nightfallAPIKey := os.Getenv(NightfallAPIKeyEnvVar)
+	if nightfallAPIKey == "" && nightfallConfig.UsesAPIEngine() {
+		s.Logger.Warning(fmt.Sprintf("Nightfall API key not found, falling back to the local detector engine. Ensure you have %s mapped from a secret pipeline variable to scan with the Nightfall API", NightfallAPIKeyEnvVar))
+	}
+	return &nightfallconfig.Config{
+		NightfallAPIKey:             nightfallAPIKey,
+		NightfallDetectionRuleUUIDs: nightfallConfig.DetectionRuleUUIDs,
+		NightfallDetectionRules:     nightfallConfig.DetectionRules,
+		NightfallMaxNumberRoutines:  nightfallConfig.MaxNumberRoutines,
+		TokenExclusionList:          nightfallConfig.TokenExclusionList,
+		FileInclusionList:           nightfallConfig.FileInclusionList,
+		FileExclusionList:           nightfallConfig.FileExclusionList,
+		DefaultRedactionConfig:      nightfallConfig.DefaultRedactionConfig,
+		AnnotationLevel:             nightfallConfig.AnnotationLevel,
+		Engine:                      nightfallConfig.Engine,
+		EntropyDetection:            nightfallConfig.EntropyDetection,
+		Baseline:                    baseline,
+		FailurePolicy:               nightfallConfig.FailurePolicy,
+		CacheDirectory:              nightfallConfig.CacheDirectory,
+		Engines:                     nightfallConfig.Engines,
+		CommandDetectors:            nightfallConfig.CommandDetectors,
+	}, nil
+}
+
+func (s *Service) getPrDetails() (*prDetails, error) {
+	commitSha, ok := os.LookupEnv(CommitShaEnvVar)
+	if !ok || commitSha == "" {
+		s.Logger.Error(fmt.Sprintf("Environment variable %s cannot be found", CommitShaEnvVar))
+		return nil, errors.New("missing env var for commit sha")
+	}
+	project, ok := os.LookupEnv(TeamProjectEnvVar)
+	if !ok || project == "" {
+		s.Logger.Error(fmt.Sprintf("Environment variable %s cannot be found", TeamProjectEnvVar))
+		return nil, errors.New("missing env var for team project")
+	}
+	repositoryID, ok := os.LookupEnv(RepositoryIDEnvVar)
+	if !ok || repositoryID == "" {
+		s.Logger.Error(fmt.Sprintf("Environment variable %s cannot be found", RepositoryIDEnvVar))
+		return nil, errors.New("missing env var for repository id")
+	}
+	var buildURL string
+	collectionURI, _ := os.LookupEnv(CollectionURIEnvVar)
+	buildID, _ := os.LookupEnv(BuildIDEnvVar)
+	if collectionURI != "" && buildID != "" {
+		buildURL = fmt.Sprintf(buildResultsURL, strings.TrimSuffix(collectionURI, "/"), url.PathEscape(project), buildID)
+	}
+	var prID *int
+	prIDStr, ok := os.LookupEnv(PullRequestIDEnvVar)
+	if ok && prIDStr != "" {
+		id, err := strconv.Atoi(prIDStr)
+		if err != nil {
+			s.Logger.Error(fmt.Sprintf("Environment variable %s has an invalid format: %s", PullRequestIDEnvVar, prIDStr))
+			return nil, errors.New("invalid format of pull request id env var")
+		}
+		prID = &id
+	}
+	return &prDetails{
+		CommitSha:    commitSha,
+		Project:      project,
+		RepositoryID: repositoryID,
+		BuildURL:     buildURL,
+		PrID:         prID,
+	}, nil
+}
+
+// GetDiff retrieves the file diff from the requested pull request
+func (s *Service) GetDiff() ([]*diffreviewer.FileDiff, error) {
+	s.Logger.Info("Getting diff from Azure Repos")
+	content, err := s.GitDiff.GetDiff()
+	if err != nil {
+		s.Logger.Error(fmt.Sprintf("Error getting the raw diff from Azure Repos: %v", err))
+		return nil, err
+	}
+
+	fileDiffs, err := diffutils.ParseMultiFile(strings.NewReader(content))
+	if err != nil {
+		s.Logger.Error("Error parsing the raw diff from Azure Repos")
+		return nil, err
+	}
+	fileDiffs = diffutils.FilterFileDiffs(fileDiffs)
+	return fileDiffs, nil
+}
+
+// WriteComments posts the findings as pull request threads and sets the pull request status
+func (s *Service) WriteComments(comments []*diffreviewer.Comment, level string, failure *diffreviewer.ScanFailure) error {
+	if len(comments) == 0 {
+		s.Logger.Info("no sensitive items found")
+	}
+	s.logCommentsToAzure(comments, level)
+	returnErr := diffreviewer.LogScanFailure(s.Logger, failure)
+	if returnErr == nil && len(comments) > 0 && level == nightfallconfig.AnnotationLevelFailure {
+		returnErr = errSensitiveItemsFound
+	}
+	if s.Client == nil || s.PrDetails.PrID == nil {
+		return returnErr
+	}
+
+	ctx := context.Background()
+	if len(comments) > 0 {
+		existingThreads, err := s.Client.ListThreads(ctx, s.PrDetails.Project, s.PrDetails.RepositoryID, *s.PrDetails.PrID)
+		if err != nil {
+			s.Logger.Error(fmt.Sprintf("Error listing existing pull request threads: %s", err.Error()))
+		}
+		threads := createThreads(comments, level)
+		filteredThreads := filterExistingThreads(threads, existingThreads)
+		for _, t := range filteredThreads {
+			_, err := s.Client.CreateThread(ctx, s.PrDetails.Project, s.PrDetails.RepositoryID, *s.PrDetails.PrID, t)
+			if err != nil {
+				s.Logger.Error(fmt.Sprintf("Error writing thread to pull request: %s", err.Error()))
+			}
+		}
+	}
+
+	status := &Status{
+		State:       StatusStateSucceeded,
+		Description: fmt.Sprintf(summaryString, len(comments)),
+		Context: &StatusContext{
+			Name:  StatusContextName,
+			Genre: StatusContextGenre,
+		},
+		TargetURL: s.PrDetails.BuildURL,
+	}
+	if failure != nil {
+		status.Description += ". " + failure.Summary()
+	}
+	if returnErr != nil {
+		status.State = StatusStateFailed
+	}
+	err := s.Client.CreateStatus(ctx, s.PrDetails.Project, s.PrDetails.RepositoryID, *s.PrDetails.PrID, status)
+	if err != nil {
+		s.Logger.Error(fmt.Sprintf("Error setting pull request status: %s", err.Error()))
+	}
+	// returning error to fail the pipeline task
+	return returnErr
+}
+
+func (s *Service) logCommentsToAzure(comments []*diffreviewer.Comment, level string) {
+	for _, comment := range comments {
+		logString := fmt.Sprintf(
+			"%s at %s on line %d",
+			comment.Body,
+			comment.FilePath,
+			comment.LineNumber,
+		)
+		switch level {
+		case nightfallconfig.AnnotationLevelFailure:
+			s.Logger.Error(logString)
+		case nightfallconfig.AnnotationLevelWarning:
+			s.Logger.Warning(logString)
+		case nightfallconfig.AnnotationLevelNotice:
+			s.Logger.Info(logString)
+		default:
+			s.Logger.Error(logString)
+		}
+	}
+}
+
+func createThreads(comments []*diffreviewer.Comment, level string) []*Thread {
+	threads := make([]*Thread, len(comments))
+	for i, comment := range comments {
+		threads[i] = &Thread{
+			Comments: []*Comment{{
+				ParentCommentID: 0,
+				Content:         fmt.Sprintf("%s: %s", level, comment.Body),
+				CommentType:     CommentTypeText,
+			}},
+			Status: ThreadStatusActive,
+			ThreadContext: &ThreadContext{
+				// file paths in thread contexts are rooted at the repository
+				FilePath:       "/" + strings.TrimPrefix(comment.FilePath, "/"),
+				RightFileStart: &CommentPosition{Line: comment.LineNumber, Offset: 1},
+				RightFileEnd:   &CommentPosition{Line: comment.LineNumber, Offset: 1},
+			},
+		}
+	}
+	return threads
+}
+
+type prThread struct {
+	Content string
+	Path    string
+	Line    int
+}
+
+func filterExistingThreads(threads []*Thread, existingThreads []*Thread) []*Thread {
+	existingThreadsMap := make(map[prThread]bool, len(existingThreads))
+	for _, et := range existingThreads {
+		if et.ThreadContext == nil || et.ThreadContext.RightFileStart == nil || len(et.Comments) == 0 {
+			continue
+		}
+		thread := prThread{
+			Content: et.Comments[0].Content,
+			Path:    et.ThreadContext.FilePath,
+			Line:    et.ThreadContext.RightFileStart.Line,
+		}
+		existingThreadsMap[thread] = true
+	}
+	filteredThreads := make([]*Thread, 0, len(threads))
+	for _, t := range threads {
+		thread := prThread{
+			Content: t.Comments[0].Content,
+			Path:    t.ThreadContext.FilePath,
+			Line:    t.ThreadContext.RightFileStart.Line,
+		}
+		if _, ok := existingThreadsMap[thread]; !ok {
+			filteredThreads = append(filteredThreads, t)
+		}
+	}
+	return filteredThreads
+}
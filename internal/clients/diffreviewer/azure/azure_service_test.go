@@ -0,0 +1,335 @@
+package azure
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	nf "github.com/nightfallai/nightfall-go-sdk"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/gitdiff"
+	azurelogger "github.com/nightfallai/nightfall_code_scanner/internal/clients/logger/azure_logger"
+	"github.com/nightfallai/nightfall_code_scanner/internal/mocks/clients/gitdiff_mock"
+	"github.com/nightfallai/nightfall_code_scanner/internal/nightfallconfig"
+	"github.com/stretchr/testify/suite"
+)
+
+const expectedDiffResponseStr = `diff --git a/blah.txt b/blah.txt
+new file mode 100644
+index 0000000..e9ea42a
+--- /dev/null
++++ b/blah.txt
+@@ -0,0 +1 @@
++this is a text file`
+
+var expectedFileDiffs = []*diffreviewer.FileDiff{{
+	PathOld: "/dev/null",
+	PathNew: "blah.txt",
+	Hunks: []*diffreviewer.Hunk{{
+		StartLineOld:  0,
+		LineLengthOld: 0,
+		StartLineNew:  1,
+		LineLengthNew: 1,
+		Lines: []*diffreviewer.Line{{
+			Type:     diffreviewer.LineAdded,
+			Content:  "this is a text file",
+			LnumDiff: 1,
+			LnumOld:  0,
+			LnumNew:  1,
+		}},
+	}},
+	Extended: []string{"diff --git a/blah.txt b/blah.txt", "new file mode 100644", "index 0000000..e9ea42a"},
+}}
+
+const commitSha = "7b46da6e4d3259b1a1c470ee468e2cb3d9733802"
+const testProject = "nightfall"
+const testRepositoryID = "d4f6b6a2-3c1e-4d5b-9c43-0a3e4f1b2c5d"
+const testCollectionURI = "https://dev.azure.com/nightfallai/"
+const testBuildID = "42"
+const testPrID = 7
+const testConfigFileName = "nightfall_test_config.json"
+const excludedCreditCardRegex = "4242-4242-4242-[0-9]{4}"
+const excludedApiToken = "xG0Ct4Wsu3OTcJnE1dFLAQfRgL6b8tIv"
+const excludedIPRegex = "^127\\."
+
+var envVars = []string{
+	WorkspacePathEnvVar,
+	CommitShaEnvVar,
+	BuildIDEnvVar,
+	CollectionURIEnvVar,
+	TeamProjectEnvVar,
+	RepositoryIDEnvVar,
+	PullRequestIDEnvVar,
+	PullRequestTargetBranchEnvVar,
+	NightfallAPIKeyEnvVar,
+}
+
+var azLogger = azurelogger.NewDefaultAzureLogger()
+
+type azureTestSuite struct {
+	suite.Suite
+}
+
+func (a *azureTestSuite) AfterTest(_, _ string) {
+	for _, e := range envVars {
+		err := os.Unsetenv(e)
+		a.NoErrorf(err, "Error unsetting var %s", e)
+	}
+}
+
+func (a *azureTestSuite) setEnv() string {
+	workspace, err := os.Getwd()
+	a.NoError(err, "Error getting workspace")
+	workspacePath := path.Join(workspace, "../../../../test/data")
+	_ = os.Setenv(WorkspacePathEnvVar, workspacePath)
+	_ = os.Setenv(CommitShaEnvVar, commitSha)
+	_ = os.Setenv(BuildIDEnvVar, testBuildID)
+	_ = os.Setenv(CollectionURIEnvVar, testCollectionURI)
+	_ = os.Setenv(TeamProjectEnvVar, testProject)
+	_ = os.Setenv(RepositoryIDEnvVar, testRepositoryID)
+	_ = os.Setenv(PullRequestIDEnvVar, fmt.Sprint(testPrID))
+	_ = os.Setenv(PullRequestTargetBranchEnvVar, "refs/heads/main")
+	return workspacePath
+}
+
+func (a *azureTestSuite) TestLoadConfig() {
+	workspacePath := a.setEnv()
+	apiKey := "api-key"
+	_ = os.Setenv(NightfallAPIKeyEnvVar, apiKey)
+	s := &Service{Logger: azLogger}
+
+	expectedNightfallConfig := &nightfallconfig.Config{
+		NightfallAPIKey: apiKey,
+		NightfallDetectionRules: []nf.DetectionRule{
+			{
+				Name: "my detection rule",
+				Detectors: []nf.Detector{
+					{
+						MinNumFindings:    1,
+						MinConfidence:     nf.ConfidencePossible,
+						DetectorType:      nf.DetectorTypeNightfallDetector,
+						DisplayName:       "cc",
+						NightfallDetector: "CREDIT_CARD_NUMBER",
+					},
+					{
+						MinNumFindings:    1,
+						MinConfidence:     nf.ConfidencePossible,
+						DetectorType:      nf.DetectorTypeNightfallDetector,
+						DisplayName:       "phone",
+						NightfallDetector: "PHONE_NUMBER",
+					},
+					{
+						MinNumFindings:    1,
+						MinConfidence:     nf.ConfidenceLikely,
+						DetectorType:      nf.DetectorTypeNightfallDetector,
+						DisplayName:       "ip",
+						NightfallDetector: "IP_ADDRESS",
+					},
+				},
+				LogicalOp: nf.LogicalOpAny,
+			},
+		},
+		NightfallMaxNumberRoutines: 20,
+		TokenExclusionList:         []string{excludedCreditCardRegex, excludedApiToken, excludedIPRegex},
+		FileInclusionList:          []string{"*"},
+		FileExclusionList:          []string{".nightfalldlp/config.json", ".nightfalldlp/baseline.json"},
+		DefaultRedactionConfig: &nf.RedactionConfig{
+			SubstitutionConfig: &nf.SubstitutionConfig{SubstitutionPhrase: "REDACTED"},
+		},
+		AnnotationLevel: "warning",
+		Engine:          "api",
+		FailurePolicy:   "fail-closed",
+	}
+	prID := testPrID
+	expectedPrDetails := prDetails{
+		CommitSha:    commitSha,
+		Project:      testProject,
+		RepositoryID: testRepositoryID,
+		BuildURL:     "https://dev.azure.com/nightfallai/nightfall/_build/results?buildId=42",
+		PrID:         &prID,
+	}
+	expectedGitDiff := &gitdiff.GitDiff{
+		WorkDir:    workspacePath,
+		BaseBranch: "main",
+		Head:       commitSha,
+	}
+
+	nightfallConfig, err := s.LoadConfig(testConfigFileName)
+	a.NoError(err, "Unexpected error in LoadConfig")
+	a.Equal(expectedNightfallConfig, nightfallConfig, "Incorrect nightfall config")
+	a.Equal(expectedPrDetails, s.PrDetails, "Incorrect pull request details")
+	a.Equal(expectedGitDiff, s.GitDiff, "Incorrect git diff")
+}
+
+func (a *azureTestSuite) TestLoadConfigMissingRepositoryID() {
+	a.setEnv()
+	_ = os.Unsetenv(RepositoryIDEnvVar)
+	s := &Service{Logger: azLogger}
+
+	_, err := s.LoadConfig(testConfigFileName)
+	a.EqualError(err, "missing env var for repository id", "incorrect error from missing repository id test")
+}
+
+func (a *azureTestSuite) TestLoadConfigInvalidPullRequestID() {
+	a.setEnv()
+	_ = os.Setenv(PullRequestIDEnvVar, "abc")
+	s := &Service{Logger: azLogger}
+
+	_, err := s.LoadConfig(testConfigFileName)
+	a.EqualError(err, "invalid format of pull request id env var", "incorrect error from invalid pull request id test")
+}
+
+func (a *azureTestSuite) TestGetDiff() {
+	ctrl := gomock.NewController(a.T())
+	defer ctrl.Finish()
+	mockGitDiff := gitdiff_mock.NewGitDiff(ctrl)
+	s := &Service{
+		Logger:  azLogger,
+		GitDiff: mockGitDiff,
+	}
+
+	mockGitDiff.EXPECT().GetDiff().Return(expectedDiffResponseStr, nil)
+
+	fileDiffs, err := s.GetDiff()
+	a.NoError(err, "unexpected error in GetDiff")
+	a.Equal(expectedFileDiffs, fileDiffs, "invalid fileDiff return value")
+}
+
+func (a *azureTestSuite) TestWriteComments() {
+	tests := []struct {
+		numComments     int
+		existingThreads []*Thread
+		level           string
+		wantState       string
+		wantThreads     int
+		wantErr         error
+		desc            string
+	}{
+		{
+			numComments: 3,
+			level:       nightfallconfig.AnnotationLevelFailure,
+			wantState:   StatusStateFailed,
+			wantThreads: 3,
+			wantErr:     errSensitiveItemsFound,
+			desc:        "failure test",
+		},
+		{
+			numComments: 3,
+			existingThreads: []*Thread{{
+				ID: 1,
+				Comments: []*Comment{{
+					Content:     "warning: testComment",
+					CommentType: CommentTypeText,
+				}},
+				Status: ThreadStatusActive,
+				ThreadContext: &ThreadContext{
+					FilePath:       "/comments.txt",
+					RightFileStart: &CommentPosition{Line: 1, Offset: 1},
+					RightFileEnd:   &CommentPosition{Line: 1, Offset: 1},
+				},
+			}},
+			level:       nightfallconfig.AnnotationLevelWarning,
+			wantState:   StatusStateSucceeded,
+			wantThreads: 2,
+			desc:        "warning with existing thread test",
+		},
+		{
+			numComments: 0,
+			level:       nightfallconfig.AnnotationLevelFailure,
+			wantState:   StatusStateSucceeded,
+			wantThreads: 0,
+			desc:        "no comments test",
+		},
+	}
+
+	prPath := fmt.Sprintf("/%s/_apis/git/repositories/%s/pullRequests/%d", testProject, testRepositoryID, testPrID)
+	for _, tt := range tests {
+		var status Status
+		threads := make([]*Thread, 0)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			a.Equal("Bearer token", r.Header.Get(authorizationKey), "missing authorization header")
+			switch {
+			case r.Method == http.MethodGet && r.URL.Path == prPath+"/threads":
+				_ = json.NewEncoder(w).Encode(threadList{Value: tt.existingThreads, Count: len(tt.existingThreads)})
+			case r.Method == http.MethodPost && r.URL.Path == prPath+"/threads":
+				var thread Thread
+				a.NoError(json.NewDecoder(r.Body).Decode(&thread), "invalid thread body")
+				threads = append(threads, &thread)
+				_ = json.NewEncoder(w).Encode(thread)
+			case r.Method == http.MethodPost && r.URL.Path == prPath+"/statuses":
+				a.NoError(json.NewDecoder(r.Body).Decode(&status), "invalid status body")
+			default:
+				a.Failf("unexpected request", "%s %s", r.Method, r.URL.Path)
+			}
+		}))
+		prID := testPrID
+		s := &Service{
+			Client: NewAuthenticatedClient("token", server.URL),
+			Logger: azLogger,
+			PrDetails: prDetails{
+				CommitSha:    commitSha,
+				Project:      testProject,
+				RepositoryID: testRepositoryID,
+				PrID:         &prID,
+			},
+		}
+		comments := makeTestComments(tt.numComments)
+
+		err := s.WriteComments(comments, tt.level, nil)
+		server.Close()
+		if tt.wantErr != nil {
+			a.EqualError(err, tt.wantErr.Error(), fmt.Sprintf("invalid error for %s", tt.desc))
+		} else {
+			a.NoError(err, fmt.Sprintf("unexpected error for %s", tt.desc))
+		}
+		a.Equal(tt.wantState, status.State, fmt.Sprintf("invalid status state for %s", tt.desc))
+		a.Equal(fmt.Sprintf(summaryString, tt.numComments), status.Description, fmt.Sprintf("invalid status description for %s", tt.desc))
+		a.Equal(&StatusContext{Name: StatusContextName, Genre: StatusContextGenre}, status.Context, fmt.Sprintf("invalid status context for %s", tt.desc))
+		a.Len(threads, tt.wantThreads, fmt.Sprintf("invalid number of threads for %s", tt.desc))
+		for _, t := range threads {
+			a.Equal(fmt.Sprintf("%s: testComment", tt.level), t.Comments[0].Content, fmt.Sprintf("invalid thread content for %s", tt.desc))
+			a.Equal("/comments.txt", t.ThreadContext.FilePath, fmt.Sprintf("invalid thread path for %s", tt.desc))
+		}
+	}
+}
+
+func (a *azureTestSuite) TestWriteCommentsWithoutPullRequest() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.Failf("unexpected request", "%s %s", r.Method, r.URL.Path)
+	}))
+	defer server.Close()
+	s := &Service{
+		Client: NewAuthenticatedClient("token", server.URL),
+		Logger: azLogger,
+		PrDetails: prDetails{
+			CommitSha:    commitSha,
+			Project:      testProject,
+			RepositoryID: testRepositoryID,
+		},
+	}
+
+	err := s.WriteComments(makeTestComments(2), nightfallconfig.AnnotationLevelFailure, nil)
+	a.EqualError(err, errSensitiveItemsFound.Error(), "invalid error for branch build")
+}
+
+func makeTestComments(size int) []*diffreviewer.Comment {
+	comments := make([]*diffreviewer.Comment, size)
+	for i := 0; i < size; i++ {
+		comments[i] = &diffreviewer.Comment{
+			Title:      "title",
+			Body:       "testComment",
+			FilePath:   "comments.txt",
+			LineNumber: i + 1,
+		}
+	}
+	return comments
+}
+
+func TestAzureClient(t *testing.T) {
+	suite.Run(t, new(azureTestSuite))
+}
@@ -0,0 +1,136 @@
+package bitbucket
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// Bitbucket Pipelines only runs on Bitbucket Cloud, Bitbucket Server and Data Center are not supported
+	defaultAPIURL      = "https://api.bitbucket.org/2.0"
+	authorizationKey   = "Authorization"
+	bearerTokenPattern = "Bearer %s"
+)
+
+// Code Insights enums https://developer.atlassian.com/cloud/bitbucket/rest/api-group-reports/
+const (
+	ReportTypeSecurity           = "SECURITY"
+	ReportResultPassed           = "PASSED"
+	ReportResultFailed           = "FAILED"
+	AnnotationTypeVulnerability  = "VULNERABILITY"
+	AnnotationSeverityHigh       = "HIGH"
+	AnnotationSeverityMedium     = "MEDIUM"
+	AnnotationSeverityLow        = "LOW"
+	MaxAnnotationsPerRequest     = 100
+	MaxAnnotationsPerReport      = 1000
+	reportDataTypeNumber         = "NUMBER"
+	reportDataTypeBoolean        = "BOOLEAN"
+	reportDataTitleFindings      = "Findings"
+	reportDataTitleSafeToMerge   = "Safe to merge?"
+	reportDataTitleAnnotationCap = "Findings not annotated"
+)
+
+// Client is a minimal client for the Bitbucket Cloud Code Insights API
+type Client struct {
+	BaseURL    string
+	Token      string
+	HTTPClient *http.Client
+}
+
+// ReportData is a key figure displayed alongside a report
+type ReportData struct {
+	Title string      `json:"title"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// Report is a Code Insights report attached to a commit
+type Report struct {
+	Title      string        `json:"title"`
+	Details    string        `json:"details"`
+	ReportType string        `json:"report_type"`
+	Reporter   string        `json:"reporter"`
+	Result     string        `json:"result"`
+	LogoURL    string        `json:"logo_url,omitempty"`
+	Data       []*ReportData `json:"data,omitempty"`
+}
+
+// Annotation is a single Code Insights annotation anchored to a file line
+type Annotation struct {
+	ExternalID     string `json:"external_id"`
+	AnnotationType string `json:"annotation_type"`
+	Summary        string `json:"summary"`
+	Details        string `json:"details,omitempty"`
+	Path           string `json:"path"`
+	Line           int    `json:"line"`
+	Severity       string `json:"severity"`
+}
+
+// NewAuthenticatedClient generates a client authenticated with a repository or workspace access token
+func NewAuthenticatedClient(token string, baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = defaultAPIURL
+	}
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		Token:      token,
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// CreateOrUpdateReport creates the report, replacing any existing report with the same id
+func (c *Client) CreateOrUpdateReport(ctx context.Context, workspace, repoSlug, commit, reportID string, report *Report) error {
+	return c.do(ctx, http.MethodPut, c.reportPath(workspace, repoSlug, commit, reportID), report)
+}
+
+// CreateAnnotations adds up to MaxAnnotationsPerRequest annotations to an existing report
+func (c *Client) CreateAnnotations(ctx context.Context, workspace, repoSlug, commit, reportID string, annotations []*Annotation) error {
+	return c.do(ctx, http.MethodPost, c.reportPath(workspace, repoSlug, commit, reportID)+"/annotations", annotations)
+}
+
+func (c *Client) reportPath(workspace, repoSlug, commit, reportID string) string {
+	return fmt.Sprintf(
+		"/repositories/%s/%s/commit/%s/reports/%s",
+		url.PathEscape(workspace),
+		url.PathEscape(repoSlug),
+		commit,
+		url.PathEscape(reportID),
+	)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, c.BaseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if c.Token != "" {
+		req.Header.Set(authorizationKey, fmt.Sprintf(bearerTokenPattern, c.Token))
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("bitbucket api %s %s returned %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,309 @@
+package bitbucket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer/diffutils"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/gitdiff"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/logger"
+	bitbucketlogger "github.com/nightfallai/nightfall_code_scanner/internal/clients/logger/bitbucket_logger"
+	"github.com/nightfallai/nightfall_code_scanner/internal/interfaces/gitdiffintf"
+	"github.com/nightfallai/nightfall_code_scanner/internal/nightfallconfig"
+)
+
+// Default Bitbucket Pipelines variables https://support.atlassian.com/bitbucket-cloud/docs/variables-and-secrets/
+const (
+	WorkspacePathEnvVar                = "BITBUCKET_CLONE_DIR"
+	NightfallAPIKeyEnvVar              = "NIGHTFALL_API_KEY"
+	CommitShaEnvVar                    = "BITBUCKET_COMMIT"
+	RepoWorkspaceEnvVar                = "BITBUCKET_WORKSPACE"
+	RepoSlugEnvVar                     = "BITBUCKET_REPO_SLUG"
+	PullRequestDestinationBranchEnvVar = "BITBUCKET_PR_DESTINATION_BRANCH"
+
+	ReportID = "nightfalldlp"
+
+	reportTitle   = "Nightfall DLP"
+	reporterName  = "Nightfall"
+	logoURL       = "https://cdn.nightfall.ai/nightfall-dark-logo-tm.png"
+	summaryString = "Nightfall DLP has found %d potentially sensitive items"
+)
+
+var errSensitiveItemsFound = errors.New("potentially sensitive items found")
+
+// Service contains the Bitbucket client that makes Code Insights api calls
+type Service struct {
+	Client    *Client
+	Logger    logger.Logger
+	GitDiff   gitdiffintf.GitDiff
+	PrDetails prDetails
+}
+
+type prDetails struct {
+	CommitSha string
+	Workspace string
+	RepoSlug  string
+}
+
+// NewBitbucketService creates a new Bitbucket Pipelines service that only writes the findings to the step log
+func NewBitbucketService() diffreviewer.DiffReviewer {
+	return &Service{
+		Logger: bitbucketlogger.NewDefaultBitbucketLogger(),
+	}
+}
+
+// NewBitbucketServiceWithCodeInsights creates a new Bitbucket Pipelines service publishing Code Insights
+// reports to Bitbucket Cloud with a repository or workspace access token
+func NewBitbucketServiceWithCodeInsights(token string) diffreviewer.DiffReviewer {
+	return &Service{
+		Client: NewAuthenticatedClient(token, defaultAPIURL),
+		Logger: bitbucketlogger.NewDefaultBitbucketLogger(),
+	}
+}
+
+// GetLogger gets the Bitbucket service logger
+func (s *Service) GetLogger() logger.Logger {
+	return s.Logger
+}
+
+// LoadConfig gets all config values from files or environment and creates a config
+func (s *Service) LoadConfig(nightfallConfigFileName string) (*nightfallconfig.Config, error) {
+	s.Logger.Info("Loading configuration")
+	workspacePath, ok := os.LookupEnv(WorkspacePathEnvVar)
+	if !ok || workspacePath == "" {
+		s.Logger.Error(fmt.Sprintf("Environment variable %s cannot be found", WorkspacePathEnvVar))
+		return nil, errors.New("missing env var for workspace path")
+	}
+	prDetails, err := s.getPrDetails()
+	if err != nil {
+		return nil, err
+	}
+	s.PrDetails = *prDetails
+	// Bitbucket does not expose the previous commit of a push, so branch
+	// builds fall back to the diff of the most recent commit
+	destinationBranch, _ := os.LookupEnv(PullRequestDestinationBranchEnvVar)
+	s.GitDiff = &gitdiff.GitDiff{
+		WorkDir:    workspacePath,
+		BaseBranch: destinationBranch,
+		Head:       s.PrDetails.CommitSha,
+	}
+	nightfallConfig, err := nightfallconfig.GetNightfallConfigFile(workspacePath, nightfallConfigFileName, s.Logger)
+	if err != nil {
+		s.Logger.Error("Error getting Nightfall config file. " +
+			"Ensure you have a Nightfall config file located in the root of your repository at .nightfalldlp/config.json " +
+			"with either a Condition Set UUID or at least one Condition enabled")
+		return nil, err
+	}
+	baseline, err := nightfallconfig.GetBaseline(workspacePath, nightfallconfig.BaselineFileName, s.Logger)
+	if err != nil {
+		s.Logger.Error(fmt.Sprintf("Error getting Nightfall baseline file. Recreate %s with the baseline create command", nightfallconfig.BaselineFileName))
+		return nil, err
+	}
+	nightfallAPIKey := os.Getenv(NightfallAPIKeyEnvVar)
+	if nightfallAPIKey == "" && nightfallConfig.UsesAPIEngine() {
+		s.Logger.Warning(fmt.Sprintf("Nightfall API key not found, falling back to the local detector engine. Ensure you have %s set in the repository variables to scan with the Nightfall API", NightfallAPIKeyEnvVar))
+	}
+	return &nightfallconfig.Config{
+		NightfallAPIKey:             nightfallAPIKey,
+		NightfallDetectionRuleUUIDs: nightfallConfig.DetectionRuleUUIDs,
+		NightfallDetectionRules:     nightfallConfig.DetectionRules,
+		NightfallMaxNumberRoutines:  nightfallConfig.MaxNumberRoutines,
+		TokenExclusionList:          nightfallConfig.TokenExclusionList,
+		FileInclusionList:           nightfallConfig.FileInclusionList,
+		FileExclusionList:           nightfallConfig.FileExclusionList,
+		DefaultRedactionConfig:      nightfallConfig.DefaultRedactionConfig,
+		AnnotationLevel:             nightfallConfig.AnnotationLevel,
+		Engine:                      nightfallConfig.Engine,
+		EntropyDetection:            nightfallConfig.EntropyDetection,
+		Baseline:                    baseline,
+		FailurePolicy:               nightfallConfig.FailurePolicy,
+		CacheDirectory:              nightfallConfig.CacheDirectory,
+		Engines:                     nightfallConfig.Engines,
+		CommandDetectors:            nightfallConfig.CommandDetectors,
+	}, nil
+}
+
+func (s *Service) getPrDetails() (*prDetails, error) {
+	commitSha, ok := os.LookupEnv(CommitShaEnvVar)
+	if !ok || commitSha == "" {
+		s.Logger.Error(fmt.Sprintf("Environment variable %s cannot be found", CommitShaEnvVar))
+		return nil, errors.New("missing env var for commit sha")
+	}
+	workspace, ok := os.LookupEnv(RepoWorkspaceEnvVar)
+	if !ok || workspace == "" {
+		s.Logger.Error(fmt.Sprintf("Environment variable %s cannot be found", RepoWorkspaceEnvVar))
+		return nil, errors.New("missing env var for repo workspace")
+	}
+	repoSlug, ok := os.LookupEnv(RepoSlugEnvVar)
+	if !ok || repoSlug == "" {
+		s.Logger.Error(fmt.Sprintf("Environment variable %s cannot be found", RepoSlugEnvVar))
+		return nil, errors.New("missing env var for repository slug")
+	}
+	return &prDetails{
+		CommitSha: commitSha,
+		Workspace: workspace,
+		RepoSlug:  repoSlug,
+	}, nil
+}
+
+// GetDiff retrieves the file diff from the requested pull request or commit
+func (s *Service) GetDiff() ([]*diffreviewer.FileDiff, error) {
+	s.Logger.Info("Getting diff from Bitbucket")
+	content, err := s.GitDiff.GetDiff()
+	if err != nil {
+		s.Logger.Error(fmt.Sprintf("Error getting the raw diff from Bitbucket: %v", err))
+		return nil, err
+	}
+
+	fileDiffs, err := diffutils.ParseMultiFile(strings.NewReader(content))
+	if err != nil {
+		s.Logger.Error("Error parsing the raw diff from Bitbucket")
+		return nil, err
+	}
+	fileDiffs = diffutils.FilterFileDiffs(fileDiffs)
+	return fileDiffs, nil
+}
+
+// WriteComments posts the findings as a Code Insights report with one annotation per finding
+func (s *Service) WriteComments(comments []*diffreviewer.Comment, level string, failure *diffreviewer.ScanFailure) error {
+	if len(comments) == 0 {
+		s.Logger.Info("no sensitive items found")
+	}
+	s.logCommentsToBitbucket(comments, level)
+	returnErr := diffreviewer.LogScanFailure(s.Logger, failure)
+	if returnErr == nil && len(comments) > 0 && level == nightfallconfig.AnnotationLevelFailure {
+		returnErr = errSensitiveItemsFound
+	}
+	if s.Client == nil {
+		return returnErr
+	}
+
+	ctx := context.Background()
+	report := createReport(comments, level, failure)
+	err := s.Client.CreateOrUpdateReport(ctx, s.PrDetails.Workspace, s.PrDetails.RepoSlug, s.PrDetails.CommitSha, ReportID, report)
+	if err != nil {
+		s.Logger.Error(fmt.Sprintf("Error creating Code Insights report: %s", err.Error()))
+		return err
+	}
+	annotations := createAnnotations(comments, level)
+	if len(annotations) > MaxAnnotationsPerReport {
+		s.Logger.Warning(fmt.Sprintf(
+			"Only the first %d of %d findings will be annotated in the Code Insights report",
+			MaxAnnotationsPerReport,
+			len(annotations),
+		))
+		annotations = annotations[:MaxAnnotationsPerReport]
+	}
+	for start := 0; start < len(annotations); start += MaxAnnotationsPerRequest {
+		end := min(start+MaxAnnotationsPerRequest, len(annotations))
+		err := s.Client.CreateAnnotations(ctx, s.PrDetails.Workspace, s.PrDetails.RepoSlug, s.PrDetails.CommitSha, ReportID, annotations[start:end])
+		if err != nil {
+			s.Logger.Error(fmt.Sprintf("Unable to write %d annotations to Bitbucket: %s", end-start, err.Error()))
+		}
+	}
+	// returning error to fail the pipeline step
+	return returnErr
+}
+
+func (s *Service) logCommentsToBitbucket(comments []*diffreviewer.Comment, level string) {
+	for _, comment := range comments {
+		logString := fmt.Sprintf(
+			"%s at %s on line %d",
+			comment.Body,
+			comment.FilePath,
+			comment.LineNumber,
+		)
+		switch level {
+		case nightfallconfig.AnnotationLevelFailure:
+			s.Logger.Error(logString)
+		case nightfallconfig.AnnotationLevelWarning:
+			s.Logger.Warning(logString)
+		case nightfallconfig.AnnotationLevelNotice:
+			s.Logger.Info(logString)
+		default:
+			s.Logger.Error(logString)
+		}
+	}
+}
+
+func createReport(comments []*diffreviewer.Comment, level string, failure *diffreviewer.ScanFailure) *Report {
+	result := ReportResultPassed
+	if (len(comments) > 0 && level == nightfallconfig.AnnotationLevelFailure) || failure.FailsRun() {
+		result = ReportResultFailed
+	}
+	data := []*ReportData{
+		{
+			Title: reportDataTitleFindings,
+			Type:  reportDataTypeNumber,
+			Value: len(comments),
+		},
+		{
+			Title: reportDataTitleSafeToMerge,
+			Type:  reportDataTypeBoolean,
+			Value: result == ReportResultPassed,
+		},
+	}
+	if len(comments) > MaxAnnotationsPerReport {
+		data = append(data, &ReportData{
+			Title: reportDataTitleAnnotationCap,
+			Type:  reportDataTypeNumber,
+			Value: len(comments) - MaxAnnotationsPerReport,
+		})
+	}
+	details := fmt.Sprintf(summaryString, len(comments))
+	if failure != nil {
+		details += ". " + failure.Summary()
+	}
+	return &Report{
+		Title:      reportTitle,
+		Details:    details,
+		ReportType: ReportTypeSecurity,
+		Reporter:   reporterName,
+		Result:     result,
+		LogoURL:    logoURL,
+		Data:       data,
+	}
+}
+
+func createAnnotations(comments []*diffreviewer.Comment, level string) []*Annotation {
+	severity := getAnnotationSeverity(level)
+	annotations := make([]*Annotation, len(comments))
+	for i, comment := range comments {
+		summary := comment.Title
+		if summary == "" {
+			summary = comment.Body
+		}
+		annotations[i] = &Annotation{
+			ExternalID:     fmt.Sprintf("%s-%d", ReportID, i+1),
+			AnnotationType: AnnotationTypeVulnerability,
+			Summary:        summary,
+			Details:        comment.Body,
+			Path:           comment.FilePath,
+			Line:           comment.LineNumber,
+			Severity:       severity,
+		}
+	}
+	return annotations
+}
+
+func getAnnotationSeverity(level string) string {
+	switch level {
+	case nightfallconfig.AnnotationLevelWarning:
+		return AnnotationSeverityMedium
+	case nightfallconfig.AnnotationLevelNotice:
+		return AnnotationSeverityLow
+	default:
+		return AnnotationSeverityHigh
+	}
+}
+
+func min(x, y int) int {
+	if x < y {
+		return x
+	}
+	return y
+}
@@ -0,0 +1,316 @@
+package bitbucket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	nf "github.com/nightfallai/nightfall-go-sdk"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+	bitbucketlogger "github.com/nightfallai/nightfall_code_scanner/internal/clients/logger/bitbucket_logger"
+	"github.com/nightfallai/nightfall_code_scanner/internal/mocks/clients/gitdiff_mock"
+	"github.com/nightfallai/nightfall_code_scanner/internal/nightfallconfig"
+	"github.com/stretchr/testify/suite"
+)
+
+const expectedDiffResponseStr = `diff --git a/blah.txt b/blah.txt
+new file mode 100644
+index 0000000..e9ea42a
+--- /dev/null
++++ b/blah.txt
+@@ -0,0 +1 @@
++this is a text file`
+
+var expectedFileDiffs = []*diffreviewer.FileDiff{{
+	PathOld: "/dev/null",
+	PathNew: "blah.txt",
+	Hunks: []*diffreviewer.Hunk{{
+		StartLineOld:  0,
+		LineLengthOld: 0,
+		StartLineNew:  1,
+		LineLengthNew: 1,
+		Lines: []*diffreviewer.Line{{
+			Type:     diffreviewer.LineAdded,
+			Content:  "this is a text file",
+			LnumDiff: 1,
+			LnumOld:  0,
+			LnumNew:  1,
+		}},
+	}},
+	Extended: []string{"diff --git a/blah.txt b/blah.txt", "new file mode 100644", "index 0000000..e9ea42a"},
+}}
+
+const commitSha = "7b46da6e4d3259b1a1c470ee468e2cb3d9733802"
+const testWorkspace = "nightfallai"
+const testRepoSlug = "test-repo"
+const testConfigFileName = "nightfall_test_config.json"
+const excludedCreditCardRegex = "4242-4242-4242-[0-9]{4}"
+const excludedApiToken = "xG0Ct4Wsu3OTcJnE1dFLAQfRgL6b8tIv"
+const excludedIPRegex = "^127\\."
+
+var envVars = []string{
+	WorkspacePathEnvVar,
+	CommitShaEnvVar,
+	RepoWorkspaceEnvVar,
+	RepoSlugEnvVar,
+	PullRequestDestinationBranchEnvVar,
+	NightfallAPIKeyEnvVar,
+}
+
+var bbLogger = bitbucketlogger.NewDefaultBitbucketLogger()
+
+type bitbucketTestSuite struct {
+	suite.Suite
+}
+
+func (b *bitbucketTestSuite) AfterTest(_, _ string) {
+	for _, e := range envVars {
+		err := os.Unsetenv(e)
+		b.NoErrorf(err, "Error unsetting var %s", e)
+	}
+}
+
+func (b *bitbucketTestSuite) setEnv() {
+	workspace, err := os.Getwd()
+	b.NoError(err, "Error getting workspace")
+	_ = os.Setenv(WorkspacePathEnvVar, path.Join(workspace, "../../../../test/data"))
+	_ = os.Setenv(CommitShaEnvVar, commitSha)
+	_ = os.Setenv(RepoWorkspaceEnvVar, testWorkspace)
+	_ = os.Setenv(RepoSlugEnvVar, testRepoSlug)
+	_ = os.Setenv(PullRequestDestinationBranchEnvVar, "main")
+}
+
+func (b *bitbucketTestSuite) TestLoadConfig() {
+	b.setEnv()
+	apiKey := "api-key"
+	_ = os.Setenv(NightfallAPIKeyEnvVar, apiKey)
+	s := &Service{Logger: bbLogger}
+
+	expectedNightfallConfig := &nightfallconfig.Config{
+		NightfallAPIKey: apiKey,
+		NightfallDetectionRules: []nf.DetectionRule{
+			{
+				Name: "my detection rule",
+				Detectors: []nf.Detector{
+					{
+						MinNumFindings:    1,
+						MinConfidence:     nf.ConfidencePossible,
+						DetectorType:      nf.DetectorTypeNightfallDetector,
+						DisplayName:       "cc",
+						NightfallDetector: "CREDIT_CARD_NUMBER",
+					},
+					{
+						MinNumFindings:    1,
+						MinConfidence:     nf.ConfidencePossible,
+						DetectorType:      nf.DetectorTypeNightfallDetector,
+						DisplayName:       "phone",
+						NightfallDetector: "PHONE_NUMBER",
+					},
+					{
+						MinNumFindings:    1,
+						MinConfidence:     nf.ConfidenceLikely,
+						DetectorType:      nf.DetectorTypeNightfallDetector,
+						DisplayName:       "ip",
+						NightfallDetector: "IP_ADDRESS",
+					},
+				},
+				LogicalOp: nf.LogicalOpAny,
+			},
+		},
+		NightfallMaxNumberRoutines: 20,
+		TokenExclusionList:         []string{excludedCreditCardRegex, excludedApiToken, excludedIPRegex},
+		FileInclusionList:          []string{"*"},
+		FileExclusionList:          []string{".nightfalldlp/config.json", ".nightfalldlp/baseline.json"},
+		DefaultRedactionConfig: &nf.RedactionConfig{
+			SubstitutionConfig: &nf.SubstitutionConfig{SubstitutionPhrase: "REDACTED"},
+		},
+		AnnotationLevel: "warning",
+		Engine:          "api",
+		FailurePolicy:   "fail-closed",
+	}
+	expectedPrDetails := prDetails{
+		CommitSha: commitSha,
+		Workspace: testWorkspace,
+		RepoSlug:  testRepoSlug,
+	}
+
+	nightfallConfig, err := s.LoadConfig(testConfigFileName)
+	b.NoError(err, "Unexpected error in LoadConfig")
+	b.Equal(expectedNightfallConfig, nightfallConfig, "Incorrect nightfall config")
+	b.Equal(expectedPrDetails, s.PrDetails, "Incorrect pull request details")
+}
+
+func (b *bitbucketTestSuite) TestLoadConfigMissingRepoSlug() {
+	b.setEnv()
+	_ = os.Unsetenv(RepoSlugEnvVar)
+	s := &Service{Logger: bbLogger}
+
+	_, err := s.LoadConfig(testConfigFileName)
+	b.EqualError(err, "missing env var for repository slug", "incorrect error from missing repo slug test")
+}
+
+func (b *bitbucketTestSuite) TestGetDiff() {
+	ctrl := gomock.NewController(b.T())
+	defer ctrl.Finish()
+	mockGitDiff := gitdiff_mock.NewGitDiff(ctrl)
+	s := &Service{
+		Logger:  bbLogger,
+		GitDiff: mockGitDiff,
+	}
+
+	mockGitDiff.EXPECT().GetDiff().Return(expectedDiffResponseStr, nil)
+
+	fileDiffs, err := s.GetDiff()
+	b.NoError(err, "unexpected error in GetDiff")
+	b.Equal(expectedFileDiffs, fileDiffs, "invalid fileDiff return value")
+}
+
+func (b *bitbucketTestSuite) TestWriteComments() {
+	tests := []struct {
+		numComments        int
+		level              string
+		failure            *diffreviewer.ScanFailure
+		wantResult         string
+		wantDetails        string
+		wantSeverity       string
+		wantAnnotationReqs int
+		wantErr            error
+		desc               string
+	}{
+		{
+			numComments:        120,
+			level:              nightfallconfig.AnnotationLevelFailure,
+			wantResult:         ReportResultFailed,
+			wantSeverity:       AnnotationSeverityHigh,
+			wantAnnotationReqs: 2,
+			wantErr:            errSensitiveItemsFound,
+			desc:               "failure multiple batch test",
+		},
+		{
+			numComments:        10,
+			level:              nightfallconfig.AnnotationLevelWarning,
+			wantResult:         ReportResultPassed,
+			wantSeverity:       AnnotationSeverityMedium,
+			wantAnnotationReqs: 1,
+			desc:               "warning single batch test",
+		},
+		{
+			numComments:        0,
+			level:              nightfallconfig.AnnotationLevelFailure,
+			wantResult:         ReportResultPassed,
+			wantAnnotationReqs: 0,
+			desc:               "no comments test",
+		},
+		{
+			numComments:        1,
+			level:              nightfallconfig.AnnotationLevelNotice,
+			failure:            &diffreviewer.ScanFailure{Policy: nightfallconfig.FailurePolicyFailClosed, Files: []string{"big.json"}, Err: errors.New("timeout")},
+			wantResult:         ReportResultFailed,
+			wantDetails:        "Nightfall DLP has found 1 potentially sensitive items. Nightfall DLP could not scan 1 files: big.json",
+			wantSeverity:       AnnotationSeverityLow,
+			wantAnnotationReqs: 1,
+			wantErr:            errors.New("unable to scan 1 files: timeout"),
+			desc:               "fail closed test",
+		},
+		{
+			numComments:        0,
+			level:              nightfallconfig.AnnotationLevelFailure,
+			failure:            &diffreviewer.ScanFailure{Policy: nightfallconfig.FailurePolicyFailOpen, Files: []string{"big.json"}, Err: errors.New("timeout")},
+			wantResult:         ReportResultPassed,
+			wantDetails:        "Nightfall DLP has found 0 potentially sensitive items. Nightfall DLP could not scan 1 files: big.json",
+			wantAnnotationReqs: 0,
+			desc:               "fail open test",
+		},
+	}
+
+	reportPath := fmt.Sprintf("/repositories/%s/%s/commit/%s/reports/%s", testWorkspace, testRepoSlug, commitSha, ReportID)
+	for _, tt := range tests {
+		var report Report
+		annotations := make([]*Annotation, 0)
+		annotationReqs := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b.Equal("Bearer token", r.Header.Get(authorizationKey), "missing authorization header")
+			switch {
+			case r.Method == http.MethodPut && r.URL.Path == reportPath:
+				b.NoError(json.NewDecoder(r.Body).Decode(&report), "invalid report body")
+			case r.Method == http.MethodPost && r.URL.Path == reportPath+"/annotations":
+				var batch []*Annotation
+				b.NoError(json.NewDecoder(r.Body).Decode(&batch), "invalid annotations body")
+				b.LessOrEqual(len(batch), MaxAnnotationsPerRequest, "too many annotations in request")
+				annotations = append(annotations, batch...)
+				annotationReqs++
+			default:
+				b.Failf("unexpected request", "%s %s", r.Method, r.URL.Path)
+			}
+		}))
+		s := &Service{
+			Client: NewAuthenticatedClient("token", server.URL),
+			Logger: bbLogger,
+			PrDetails: prDetails{
+				CommitSha: commitSha,
+				Workspace: testWorkspace,
+				RepoSlug:  testRepoSlug,
+			},
+		}
+		comments := makeTestComments(tt.numComments)
+
+		err := s.WriteComments(comments, tt.level, tt.failure)
+		server.Close()
+		if tt.wantErr != nil {
+			b.EqualError(err, tt.wantErr.Error(), fmt.Sprintf("invalid error for %s", tt.desc))
+		} else {
+			b.NoError(err, fmt.Sprintf("unexpected error for %s", tt.desc))
+		}
+		b.Equal(tt.wantResult, report.Result, fmt.Sprintf("invalid report result for %s", tt.desc))
+		wantDetails := tt.wantDetails
+		if wantDetails == "" {
+			wantDetails = fmt.Sprintf(summaryString, tt.numComments)
+		}
+		b.Equal(wantDetails, report.Details, fmt.Sprintf("invalid report details for %s", tt.desc))
+		b.Equal(tt.wantAnnotationReqs, annotationReqs, fmt.Sprintf("invalid number of annotation requests for %s", tt.desc))
+		b.Len(annotations, tt.numComments, fmt.Sprintf("invalid number of annotations for %s", tt.desc))
+		for i, a := range annotations {
+			b.Equal(&Annotation{
+				ExternalID:     fmt.Sprintf("%s-%d", ReportID, i+1),
+				AnnotationType: AnnotationTypeVulnerability,
+				Summary:        "title",
+				Details:        "testComment",
+				Path:           "/comments.txt",
+				Line:           i + 1,
+				Severity:       tt.wantSeverity,
+			}, a, fmt.Sprintf("invalid annotation for %s", tt.desc))
+		}
+	}
+}
+
+func (b *bitbucketTestSuite) TestWriteCommentsWithoutToken() {
+	s := NewBitbucketService().(*Service)
+	s.PrDetails = prDetails{CommitSha: commitSha, Workspace: testWorkspace, RepoSlug: testRepoSlug}
+
+	err := s.WriteComments(makeTestComments(2), nightfallconfig.AnnotationLevelFailure, nil)
+	b.EqualError(err, errSensitiveItemsFound.Error(), "findings should still fail the step without a token")
+	b.NoError(s.WriteComments(makeTestComments(0), nightfallconfig.AnnotationLevelFailure, nil), "no findings should pass without a token")
+}
+
+func makeTestComments(size int) []*diffreviewer.Comment {
+	comments := make([]*diffreviewer.Comment, size)
+	for i := 0; i < size; i++ {
+		comments[i] = &diffreviewer.Comment{
+			Title:      "title",
+			Body:       "testComment",
+			FilePath:   "/comments.txt",
+			LineNumber: i + 1,
+		}
+	}
+	return comments
+}
+
+func TestBitbucketClient(t *testing.T) {
+	suite.Run(t, new(bitbucketTestSuite))
+}
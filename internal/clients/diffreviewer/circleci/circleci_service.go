@@ -29,6 +29,7 @@ const (
 	CircleBeforeCommitEnvVar     = "EVENT_BEFORE"
 	CirclePullRequestUrlEnvVar   = "CIRCLE_PULL_REQUEST"
 	CircleBranchEnvVar           = "CIRCLE_BRANCH" // branch that triggered the workflow
+	PullRequestSummaryEnvVar     = "NIGHTFALL_PULL_REQUEST_SUMMARY"
 
 	GithubBaseBranchEnvVar = "GITHUB_BASE_BRANCH" // optional user input variable if base branch is not master
 	DefaultBaseBranchName  = "master"             // diff against base branch if workflow triggered by PR
@@ -46,6 +47,10 @@ type Service struct {
 	Logger       logger.Logger
 	GitDiff      gitdiffintf.GitDiff
 	PrDetails    prDetails
+	// PullRequestSummary sums up the findings of a pull request in a single comment instead of a comment per finding
+	PullRequestSummary bool
+	// ServerURL is the web url of the github server, linked from the summary comment
+	ServerURL string
 }
 
 type prDetails struct {
@@ -67,9 +72,23 @@ func NewCircleCiServiceWithGithubComments(token, baseUrl string) diffreviewer.Di
 	return &Service{
 		GithubClient: gc.NewAuthenticatedClient(token, baseUrl),
 		Logger:       circlelogger.NewDefaultCircleLogger(),
+		ServerURL:    gc.ServerURL(baseUrl),
 	}
 }
 
+// NewCircleCiServiceWithGithubAppComments creates a new CircleCi service with a Github client authenticated as a GitHub App installation
+func NewCircleCiServiceWithGithubAppComments(credentials gc.AppCredentials, baseUrl string) (diffreviewer.DiffReviewer, error) {
+	client, err := gc.NewAppAuthenticatedClient(credentials, baseUrl)
+	if err != nil {
+		return nil, err
+	}
+	return &Service{
+		GithubClient: client,
+		Logger:       circlelogger.NewDefaultCircleLogger(),
+		ServerURL:    gc.ServerURL(baseUrl),
+	}, nil
+}
+
 // GetLogger gets the github service logger
 func (s *Service) GetLogger() logger.Logger {
 	return s.Logger
@@ -93,6 +112,12 @@ func (s *Service) LoadConfig(nightfallConfigFileName string) (*nightfallconfig.C
 		return nil, err
 	}
 	s.PrDetails = *prDetails
+	if summary, ok := os.LookupEnv(PullRequestSummaryEnvVar); ok {
+		s.PullRequestSummary, err = strconv.ParseBool(summary)
+		if err != nil {
+			s.Logger.Warning(fmt.Sprintf("Invalid %s value: %s. Defaulting to false", PullRequestSummaryEnvVar, summary))
+		}
+	}
 	s.GitDiff = &gitdiff.GitDiff{
 		WorkDir:    workspacePath,
 		BaseBranch: baseBranch,
@@ -106,10 +131,14 @@ func (s *Service) LoadConfig(nightfallConfigFileName string) (*nightfallconfig.C
 			"with either a Condition Set UUID or at least one Condition enabled")
 		return nil, err
 	}
-	nightfallAPIKey, ok := os.LookupEnv(NightfallAPIKeyEnvVar)
-	if !ok || nightfallAPIKey == "" {
-		s.Logger.Error(fmt.Sprintf("Error getting Nightfall API key. Ensure you have %s set in the Github secrets of the repo", NightfallAPIKeyEnvVar))
-		return nil, errors.New("missing env var for nightfall api key")
+	baseline, err := nightfallconfig.GetBaseline(workspacePath, nightfallconfig.BaselineFileName, s.Logger)
+	if err != nil {
+		s.Logger.Error(fmt.Sprintf("Error getting Nightfall baseline file. Recreate %s with the baseline create command", nightfallconfig.BaselineFileName))
+		return nil, err
+	}
+	nightfallAPIKey := os.Getenv(NightfallAPIKeyEnvVar)
+	if nightfallAPIKey == "" && nightfallConfig.UsesAPIEngine() {
+		s.Logger.Warning(fmt.Sprintf("Nightfall API key not found, falling back to the local detector engine. Ensure you have %s set in the Github secrets of the repo to scan with the Nightfall API", NightfallAPIKeyEnvVar))
 	}
 	return &nightfallconfig.Config{
 		NightfallAPIKey:             nightfallAPIKey,
@@ -121,6 +150,13 @@ func (s *Service) LoadConfig(nightfallConfigFileName string) (*nightfallconfig.C
 		FileExclusionList:           nightfallConfig.FileExclusionList,
 		DefaultRedactionConfig:      nightfallConfig.DefaultRedactionConfig,
 		AnnotationLevel:             nightfallConfig.AnnotationLevel,
+		Engine:                      nightfallConfig.Engine,
+		EntropyDetection:            nightfallConfig.EntropyDetection,
+		Baseline:                    baseline,
+		FailurePolicy:               nightfallConfig.FailurePolicy,
+		CacheDirectory:              nightfallConfig.CacheDirectory,
+		Engines:                     nightfallConfig.Engines,
+		CommandDetectors:            nightfallConfig.CommandDetectors,
 	}, nil
 }
 
@@ -198,45 +234,26 @@ func (s *Service) GetDiff() ([]*diffreviewer.FileDiff, error) {
 	return fileDiffs, nil
 }
 
-// WriteComments posts the findings as annotations to the github check
-func (s *Service) WriteComments(comments []*diffreviewer.Comment, level string) error {
+// WriteComments logs the findings to CircleCI and, with a Github client, comments them on the pull request or commit.
+// Pull request comments of previous runs are resolved when their finding is gone. With PullRequestSummary the findings
+// of a pull request are summed up in a single comment instead.
+func (s *Service) WriteComments(comments []*diffreviewer.Comment, level string, failure *diffreviewer.ScanFailure) error {
+	returnErr := diffreviewer.LogScanFailure(s.Logger, failure)
 	if len(comments) == 0 {
 		s.Logger.Info("no sensitive items found")
-		return nil
-	}
-	s.logCommentsToCircle(comments, level)
-	var returnErr error
-	if level == nightfallconfig.AnnotationLevelFailure {
-		returnErr = errSensitiveItemsFound
+	} else {
+		s.logCommentsToCircle(comments, level)
+		if returnErr == nil && level == nightfallconfig.AnnotationLevelFailure {
+			returnErr = errSensitiveItemsFound
+		}
 	}
 	if s.GithubClient == nil {
 		return returnErr
 	}
-	if s.PrDetails.PrNumber != nil {
-		existingComments, _, err := s.GithubClient.PullRequestsService().ListComments(
-			context.Background(),
-			s.PrDetails.Owner,
-			s.PrDetails.Repo,
-			*s.PrDetails.PrNumber,
-			&github.PullRequestListCommentsOptions{},
-		)
-		if err != nil {
-			s.Logger.Error(fmt.Sprintf("Error listing existing pull request comments: %s", err.Error()))
-		}
-		githubComments := s.createGithubPullRequestComments(comments, level)
-		filteredGithubComments := filterExistingComments(githubComments, existingComments)
-		for _, c := range filteredGithubComments {
-			_, _, err := s.GithubClient.PullRequestsService().CreateComment(
-				context.Background(),
-				s.PrDetails.Owner,
-				s.PrDetails.Repo,
-				*s.PrDetails.PrNumber,
-				c,
-			)
-			if err != nil {
-				s.Logger.Error(fmt.Sprintf("Error writing comment to pull request: %s", err.Error()))
-			}
-		}
+	if s.PrDetails.PrNumber != nil && s.PullRequestSummary {
+		s.writePullRequestSummary(comments, level, failure)
+	} else if s.PrDetails.PrNumber != nil {
+		s.writePullRequestComments(comments, level, failure)
 	} else {
 		githubComments := s.createGithubRepositoryComments(comments, level)
 		for _, c := range githubComments {
@@ -256,6 +273,78 @@ func (s *Service) WriteComments(comments []*diffreviewer.Comment, level string)
 	return returnErr
 }
 
+// writePullRequestComments comments the findings not commented yet, and resolves the comments of previous runs
+// whose finding is gone or moved. Comments on files that could not be scanned are left as they are.
+func (s *Service) writePullRequestComments(comments []*diffreviewer.Comment, level string, failure *diffreviewer.ScanFailure) {
+	existingComments, err := gc.ListAllComments(
+		s.GithubClient.PullRequestsService(),
+		s.PrDetails.Owner,
+		s.PrDetails.Repo,
+		*s.PrDetails.PrNumber,
+	)
+	if err != nil {
+		// without the existing comments none can be resolved, and findings may be commented twice
+		s.Logger.Error(fmt.Sprintf("Error listing existing pull request comments: %s", err.Error()))
+		existingComments = nil
+	}
+	var unscannedPaths []string
+	if failure != nil {
+		unscannedPaths = failure.Files
+	}
+	githubComments := s.createGithubPullRequestComments(comments, level)
+	newComments, edits := gc.ReconcileComments(githubComments, existingComments, s.PrDetails.CommitSha, unscannedPaths)
+	for _, edit := range edits {
+		_, _, err := s.GithubClient.PullRequestsService().EditComment(
+			context.Background(),
+			s.PrDetails.Owner,
+			s.PrDetails.Repo,
+			edit.ID,
+			&github.PullRequestComment{Body: github.String(edit.Body)},
+		)
+		if err != nil {
+			s.Logger.Error(fmt.Sprintf("Error resolving pull request comment %d: %s", edit.ID, err.Error()))
+		}
+	}
+	for _, c := range newComments {
+		_, _, err := s.GithubClient.PullRequestsService().CreateComment(
+			context.Background(),
+			s.PrDetails.Owner,
+			s.PrDetails.Repo,
+			*s.PrDetails.PrNumber,
+			c,
+		)
+		if err != nil {
+			s.Logger.Error(fmt.Sprintf("Error writing comment to pull request: %s", err.Error()))
+		}
+	}
+}
+
+// writePullRequestSummary edits the summary comment of the pull request, which is only created once there is something to report
+func (s *Service) writePullRequestSummary(comments []*diffreviewer.Comment, level string, failure *diffreviewer.ScanFailure) {
+	serverURL := s.ServerURL
+	if serverURL == "" {
+		serverURL = gc.DefaultServerURL
+	}
+	body := gc.PullRequestSummary(
+		comments,
+		level,
+		s.PrDetails.CommitSha,
+		gc.BlobURL(serverURL, s.PrDetails.Owner, s.PrDetails.Repo, s.PrDetails.CommitSha),
+		failure,
+	)
+	err := gc.WriteSummaryComment(
+		s.GithubClient.IssuesService(),
+		s.PrDetails.Owner,
+		s.PrDetails.Repo,
+		*s.PrDetails.PrNumber,
+		body,
+		len(comments) > 0 || failure != nil,
+	)
+	if err != nil {
+		s.Logger.Error(fmt.Sprintf("Error writing the pull request summary comment: %s", err.Error()))
+	}
+}
+
 func (s *Service) logCommentsToCircle(comments []*diffreviewer.Comment, level string) {
 	for _, comment := range comments {
 		logString := fmt.Sprintf(
@@ -279,8 +368,9 @@ func (s *Service) logCommentsToCircle(comments []*diffreviewer.Comment, level st
 
 func (s *Service) createGithubPullRequestComments(comments []*diffreviewer.Comment, level string) []*github.PullRequestComment {
 	githubComments := make([]*github.PullRequestComment, len(comments))
+	fingerprints := gc.CommentFingerprints(comments)
 	for i, comment := range comments {
-		body := fmt.Sprintf("%s: %s", level, comment.Body)
+		body := gc.WithFingerprint(fmt.Sprintf("%s: %s", level, comment.Body), fingerprints[i])
 		githubComments[i] = &github.PullRequestComment{
 			CommitID: &s.PrDetails.CommitSha,
 			Body:     &body,
@@ -305,33 +395,3 @@ func (s *Service) createGithubRepositoryComments(comments []*diffreviewer.Commen
 	}
 	return githubComments
 }
-
-type prComment struct {
-	Body string
-	Path string
-	Line int
-}
-
-func filterExistingComments(comments []*github.PullRequestComment, existingComments []*github.PullRequestComment) []*github.PullRequestComment {
-	existingCommentsMap := make(map[prComment]bool, len(existingComments))
-	for _, ec := range existingComments {
-		comment := prComment{
-			Body: ec.GetBody(),
-			Path: ec.GetPath(),
-			Line: ec.GetLine(),
-		}
-		existingCommentsMap[comment] = true
-	}
-	filteredComments := make([]*github.PullRequestComment, 0, len(comments))
-	for _, c := range comments {
-		comment := prComment{
-			Body: c.GetBody(),
-			Path: c.GetPath(),
-			Line: c.GetLine(),
-		}
-		if _, ok := existingCommentsMap[comment]; !ok {
-			filteredComments = append(filteredComments, c)
-		}
-	}
-	return filteredComments
-}
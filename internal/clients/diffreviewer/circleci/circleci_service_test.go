@@ -13,9 +13,11 @@ import (
 	"github.com/google/uuid"
 	nf "github.com/nightfallai/nightfall-go-sdk"
 	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+	gc "github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer/github"
 	circlelogger "github.com/nightfallai/nightfall_code_scanner/internal/clients/logger/circle_logger"
 	"github.com/nightfallai/nightfall_code_scanner/internal/mocks/clients/gitdiff_mock"
 	"github.com/nightfallai/nightfall_code_scanner/internal/mocks/clients/githubclient_mock"
+	"github.com/nightfallai/nightfall_code_scanner/internal/mocks/clients/githubissues_mock"
 	"github.com/nightfallai/nightfall_code_scanner/internal/mocks/clients/githubpullrequests_mock"
 	"github.com/nightfallai/nightfall_code_scanner/internal/mocks/clients/githubrepositories_mock"
 	loggermock "github.com/nightfallai/nightfall_code_scanner/internal/mocks/logger"
@@ -212,11 +214,13 @@ func (c *circleCiTestSuite) TestLoadConfig() {
 		NightfallMaxNumberRoutines: 20,
 		TokenExclusionList:         []string{excludedCreditCardRegex, excludedApiToken, excludedIPRegex},
 		FileInclusionList:          []string{"*"},
-		FileExclusionList:          []string{".nightfalldlp/config.json"},
+		FileExclusionList:          []string{".nightfalldlp/config.json", ".nightfalldlp/baseline.json"},
 		DefaultRedactionConfig: &nf.RedactionConfig{
 			SubstitutionConfig: &nf.SubstitutionConfig{SubstitutionPhrase: "REDACTED"},
 		},
 		AnnotationLevel: "warning",
+		Engine:          "api",
+		FailurePolicy:   "fail-closed",
 	}
 
 	nightfallConfig, err := tp.cs.LoadConfig(testConfigFileName)
@@ -246,8 +250,10 @@ func (c *circleCiTestSuite) TestLoadConfigDetectionRuleUUID() {
 		NightfallMaxNumberRoutines:  20,
 		TokenExclusionList:          []string{excludedCreditCardRegex, excludedApiToken, excludedIPRegex},
 		FileInclusionList:           []string{"*"},
-		FileExclusionList:           []string{".nightfalldlp/config.json"},
+		FileExclusionList:           []string{".nightfalldlp/config.json", ".nightfalldlp/baseline.json"},
 		AnnotationLevel:             "failure",
+		Engine:                      "api",
+		FailurePolicy:               "fail-closed",
 	}
 
 	nightfallConfig, err := tp.cs.LoadConfig(testConfigDetectionRuleUUIDFileName)
@@ -267,12 +273,9 @@ func (c *circleCiTestSuite) TestLoadConfigMissingApiKey() {
 	_ = os.Setenv(CircleOwnerNameEnvVar, testOwner)
 	_ = os.Setenv(CircleRepoNameEnvVar, testRepo)
 
-	_, err = tp.cs.LoadConfig(testConfigFileName)
-	c.EqualError(
-		err,
-		"missing env var for nightfall api key",
-		"incorrect error from missing api key test",
-	)
+	nightfallConfig, err := tp.cs.LoadConfig(testConfigFileName)
+	c.NoError(err, "missing api key should fall back to the local detector engine")
+	c.Empty(nightfallConfig.NightfallAPIKey, "unexpected api key")
 }
 
 func (c *circleCiTestSuite) TestLoadEmptyConfig() {
@@ -329,6 +332,8 @@ func (c *circleCiTestSuite) TestLoadEmptyConfig() {
 			},
 		},
 		AnnotationLevel: "failure",
+		Engine:          "api",
+		FailurePolicy:   "fail-closed",
 	}
 
 	nightfallConfig, err := tp.cs.LoadConfig(testEmptyConfigFileName)
@@ -403,7 +408,7 @@ func (c *circleCiTestSuite) TestWriteCircleComments() {
 				comment.LineNumber,
 			))
 		}
-		err := tp.cs.WriteComments(tt.giveComments, "notice")
+		err := tp.cs.WriteComments(tt.giveComments, "notice", nil)
 		if tt.wantErr == nil {
 			c.NoError(err, fmt.Sprintf("unexpected error writing comments for %s test", tt.desc))
 		} else {
@@ -488,7 +493,7 @@ func (c *circleCiTestSuite) TestWritePullRequestComments() {
 				gc.GetLine(),
 			))
 		}
-		err := tp.cs.WriteComments(tt.giveComments, "failure")
+		err := tp.cs.WriteComments(tt.giveComments, "failure", nil)
 		if len(tt.giveComments) > 0 {
 			c.EqualError(
 				err,
@@ -501,6 +506,124 @@ func (c *circleCiTestSuite) TestWritePullRequestComments() {
 	}
 }
 
+func (c *circleCiTestSuite) TestWritePullRequestCommentsResolve() {
+	tp := c.initTestParams()
+	ctrl := gomock.NewController(c.T())
+	defer ctrl.Finish()
+	mockClient := githubclient_mock.NewGithubClient(tp.ctrl)
+	mockPullRequests := githubpullrequests_mock.NewGithubPullRequests(tp.ctrl)
+	mockLogger := loggermock.NewLogger(ctrl)
+	testCircleService := &Service{
+		GithubClient: mockClient,
+		Logger:       mockLogger,
+		PrDetails: prDetails{
+			CommitSha: commitSha,
+			Owner:     testOwner,
+			Repo:      testRepo,
+			PrNumber:  github.Int(3),
+		},
+	}
+	tp.cs = testCircleService
+
+	_, staleGithubComments := makeTestGithubPullRequestComments(
+		"staleComment",
+		"/comments.txt",
+		"previousSha",
+		1,
+		"failure",
+	)
+	staleGithubComments[0].ID = github.Int64(42)
+	wantBody := fmt.Sprintf(
+		"~~failure: staleComment~~\n\nResolved in %s\n\n<!-- nightfalldlp:fingerprint=%s resolved -->",
+		commitSha,
+		gc.CommentFingerprints([]*diffreviewer.Comment{{Body: "staleComment", FilePath: "/comments.txt"}})[0],
+	)
+
+	mockClient.EXPECT().PullRequestsService().Return(mockPullRequests).Times(2)
+	mockPullRequests.EXPECT().ListComments(
+		context.Background(),
+		testCircleService.PrDetails.Owner,
+		testCircleService.PrDetails.Repo,
+		*testCircleService.PrDetails.PrNumber,
+		&github.PullRequestListCommentsOptions{},
+	).Return(staleGithubComments, &github.Response{}, nil)
+	mockPullRequests.EXPECT().EditComment(
+		context.Background(),
+		testCircleService.PrDetails.Owner,
+		testCircleService.PrDetails.Repo,
+		int64(42),
+		&github.PullRequestComment{Body: github.String(wantBody)},
+	)
+	mockLogger.EXPECT().Info("no sensitive items found")
+
+	err := tp.cs.WriteComments(make([]*diffreviewer.Comment, 0), "failure", nil)
+	c.NoError(err, "Error writing comments for resolve test")
+}
+
+func (c *circleCiTestSuite) TestWritePullRequestSummary() {
+	tp := c.initTestParams()
+	ctrl := gomock.NewController(c.T())
+	defer ctrl.Finish()
+	mockClient := githubclient_mock.NewGithubClient(tp.ctrl)
+	mockIssues := githubissues_mock.NewGithubIssues(tp.ctrl)
+	mockLogger := loggermock.NewLogger(ctrl)
+	testCircleService := &Service{
+		GithubClient: mockClient,
+		Logger:       mockLogger,
+		PrDetails: prDetails{
+			CommitSha: commitSha,
+			Owner:     testOwner,
+			Repo:      testRepo,
+			PrNumber:  github.Int(3),
+		},
+		PullRequestSummary: true,
+		ServerURL:          gc.DefaultServerURL,
+	}
+	tp.cs = testCircleService
+
+	testComments, _ := makeTestGithubPullRequestComments(
+		"testComment",
+		"/comments.txt",
+		tp.cs.PrDetails.CommitSha,
+		2,
+		"failure",
+	)
+	wantBody := gc.PullRequestSummary(
+		testComments,
+		"failure",
+		commitSha,
+		gc.BlobURL(gc.DefaultServerURL, testOwner, testRepo, commitSha),
+		nil,
+	)
+
+	for _, comment := range testComments {
+		mockLogger.EXPECT().Error(fmt.Sprintf(
+			"%s at %s on line %d",
+			comment.Body,
+			comment.FilePath,
+			comment.LineNumber,
+		))
+	}
+	mockClient.EXPECT().IssuesService().Return(mockIssues)
+	mockIssues.EXPECT().ListComments(
+		context.Background(),
+		testOwner,
+		testRepo,
+		3,
+		&github.IssueListCommentsOptions{},
+	).Return([]*github.IssueComment{}, &github.Response{}, nil)
+	mockIssues.EXPECT().CreateComment(
+		context.Background(),
+		testOwner,
+		testRepo,
+		3,
+		&github.IssueComment{Body: github.String(wantBody)},
+	)
+
+	err := tp.cs.WriteComments(testComments, "failure", nil)
+	c.EqualError(err, errSensitiveItemsFound.Error(), "invalid error writing comments for summary test")
+}
+
 func makeTestGithubPullRequestComments(
 	body,
 	filePath,
@@ -517,7 +640,10 @@ func makeTestGithubPullRequestComments(
 			FilePath:   filePath,
 			LineNumber: i + 1,
 		}
-		githubBody := fmt.Sprintf("%s: %s", level, body)
+	}
+	fingerprints := gc.CommentFingerprints(comments)
+	for i := 0; i < size; i++ {
+		githubBody := gc.WithFingerprint(fmt.Sprintf("%s: %s", level, body), fingerprints[i])
 		githubComments[i] = &github.PullRequestComment{
 			CommitID: &commitSha,
 			Body:     &githubBody,
@@ -593,7 +719,7 @@ func (c *circleCiTestSuite) TestWriteRepositoryComments() {
 				tt.giveComments[index].LineNumber,
 			))
 		}
-		err := tp.cs.WriteComments(tt.giveComments, "warning")
+		err := tp.cs.WriteComments(tt.giveComments, "warning", nil)
 		if len(tt.giveComments) > 0 {
 			c.NoError(
 				err,
@@ -632,57 +758,6 @@ func makeTestGithubRepositoryComments(
 	return comments, githubComments
 }
 
-func (c *circleCiTestSuite) TestFilterExistingComments() {
-	bodyStrs := []string{"a", "b", "c"}
-	pathStrs := []string{"a.txt", "b.txt", "c.txt"}
-	lineNums := []int{1, 2, 3, 6}
-	existingComments := []*github.PullRequestComment{
-		{
-			Body: &bodyStrs[0],
-			Path: &pathStrs[0],
-			Line: &lineNums[0],
-		},
-		{
-			Body: &bodyStrs[1],
-			Path: &pathStrs[1],
-			Line: &lineNums[1],
-		},
-		{
-			Body: &bodyStrs[2],
-			Path: &pathStrs[2],
-			Line: &lineNums[2],
-		},
-		{
-			Body: &bodyStrs[0],
-			Path: &pathStrs[0],
-			Line: nil,
-		},
-	}
-	newComment1 := &github.PullRequestComment{
-		Body: &bodyStrs[0],
-		Path: &pathStrs[0],
-		Line: &lineNums[3],
-	}
-	newComment2 := &github.PullRequestComment{
-		Body: &bodyStrs[1],
-		Path: &pathStrs[1],
-		Line: &lineNums[2],
-	}
-	comments := []*github.PullRequestComment{
-		existingComments[0],
-		existingComments[1],
-		existingComments[2],
-		newComment1,
-		newComment2,
-	}
-	expectedFilteredComments := []*github.PullRequestComment{
-		newComment1,
-		newComment2,
-	}
-	filteredComments := filterExistingComments(comments, existingComments)
-	c.Equal(expectedFilteredComments, filteredComments, "invalid filtered comments value")
-}
-
 func TestCircleCiClient(t *testing.T) {
 	suite.Run(t, new(circleCiTestSuite))
 }
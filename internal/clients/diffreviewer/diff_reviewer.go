@@ -1,6 +1,7 @@
 package diffreviewer
 
 import (
+	nf "github.com/nightfallai/nightfall-go-sdk"
 	"github.com/nightfallai/nightfall_code_scanner/internal/clients/logger"
 	"github.com/nightfallai/nightfall_code_scanner/internal/nightfallconfig"
 )
@@ -13,8 +14,9 @@ type DiffReviewer interface {
 	LoadConfig(nightfallConfigFileName string) (*nightfallconfig.Config, error)
 	// GetDiff fetches the diff from the code repository and return a parsed array of FileDiffs
 	GetDiff() ([]*FileDiff, error)
-	// WriteComments posts the Nightfall DLP findings as comments/a review to the diff at a given alert level
-	WriteComments(comments []*Comment, level string) error
+	// WriteComments posts the Nightfall DLP findings as comments/a review to the diff at a given alert level,
+	// along with the files that could not be scanned if failure is not nil
+	WriteComments(comments []*Comment, level string, failure *ScanFailure) error
 	// GetLogger gets the logger for the diff reviewer
 	GetLogger() logger.Logger
 }
@@ -25,6 +27,38 @@ type Comment struct {
 	Body       string
 	FilePath   string
 	LineNumber int
+	// DetectorName is the display name of the detector that produced the finding
+	DetectorName string
+	// Finding is the structured finding the comment was created from
+	Finding *nf.Finding
+	// Commit is the commit that added the content, only set when scanning git history
+	Commit *Commit
+	// StartColumn and EndColumn locate the finding on its line in 1-based codepoint
+	// columns, the end is exclusive. They are 0 when unknown
+	StartColumn int
+	EndColumn   int
+}
+
+// Commit identifies the commit a file diff was taken from when scanning git history
+type Commit struct {
+	SHA    string
+	Author string
+	// Date is the author date in strict ISO 8601 format
+	Date string
+}
+
+// Suppression is an inline nightfall:ignore marker found in the added or context lines of a diff
+type Suppression struct {
+	// the line of the new file the suppression applies to
+	LineNumber int
+	// the line of the new file holding the marker
+	MarkerLineNumber int
+	// the detectors to suppress, all detectors if empty
+	Detectors []string
+	// optional reason given with reason=
+	Reason string
+	// optional YYYY-MM-DD date given with expires= after which the suppression no longer applies
+	Expires string
 }
 
 // Git Structs from https://github.com/reviewdog/reviewdog/blob/master/diff/diff.go
@@ -47,6 +81,12 @@ type FileDiff struct {
 
 	Hunks []*Hunk
 
+	// the inline suppressions found in the added and context lines of the hunks
+	Suppressions []*Suppression
+
+	// the commit of the diff, only set when scanning git history
+	Commit *Commit
+
 	// extended header lines (e.g., git's "new mode <mode>", "rename from <path>", index fb14f33..c19311b 100644, etc.)
 	Extended []string
 
@@ -12,6 +12,8 @@ func FilterFileDiffs(fileDiffs []*diffreviewer.FileDiff) []*diffreviewer.FileDif
 	}
 	filteredFileDiffs := []*diffreviewer.FileDiff{}
 	for _, fileDiff := range fileDiffs {
+		// suppression markers may be on context lines, so they are collected before filtering
+		fileDiff.Suppressions = findSuppressions(fileDiff.Hunks)
 		fileDiff.Hunks = filterHunks(fileDiff.Hunks)
 		if len(fileDiff.Hunks) > 0 {
 			filteredFileDiffs = append(filteredFileDiffs, fileDiff)
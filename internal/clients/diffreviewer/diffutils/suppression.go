@@ -0,0 +1,70 @@
+package diffutils
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+)
+
+// suppressionRegex matches markers placed in a comment of any language, of the form
+// nightfall:ignore[-next-line] [DETECTOR[,DETECTOR...]] [reason="..."] [expires=YYYY-MM-DD]
+var suppressionRegex = regexp.MustCompile(`nightfall:ignore(-next-line)?\b(.*)`)
+
+var (
+	suppressionOptionRegex = regexp.MustCompile(`\b(reason|expires)=(?:"([^"]*)"|'([^']*)'|(\S+))`)
+	suppressionDetector    = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+)
+
+// ParseSuppression returns the suppression declared by a nightfall:ignore marker in
+// the content of the line at lineNumber, or nil if the line has no marker
+func ParseSuppression(content string, lineNumber int) *diffreviewer.Suppression {
+	match := suppressionRegex.FindStringSubmatch(content)
+	if match == nil {
+		return nil
+	}
+	suppression := &diffreviewer.Suppression{
+		LineNumber:       lineNumber,
+		MarkerLineNumber: lineNumber,
+		Detectors:        make([]string, 0),
+	}
+	if match[1] != "" {
+		suppression.LineNumber++
+	}
+
+	rest := match[2]
+	for _, option := range suppressionOptionRegex.FindAllStringSubmatch(rest, -1) {
+		value := option[2] + option[3] + option[4]
+		switch option[1] {
+		case "reason":
+			suppression.Reason = value
+		case "expires":
+			suppression.Expires = value
+		}
+	}
+	rest = suppressionOptionRegex.ReplaceAllString(rest, " ")
+
+	// anything that is not a detector name, such as a closing */ or -->, is ignored
+	for _, field := range strings.Fields(strings.Replace(rest, ",", " ", -1)) {
+		if suppressionDetector.MatchString(field) {
+			suppression.Detectors = append(suppression.Detectors, field)
+		}
+	}
+	return suppression
+}
+
+// findSuppressions returns the suppressions of the added and context lines of the hunks
+func findSuppressions(hunks []*diffreviewer.Hunk) []*diffreviewer.Suppression {
+	var suppressions []*diffreviewer.Suppression
+	for _, hunk := range hunks {
+		for _, line := range hunk.Lines {
+			if line.Type != diffreviewer.LineAdded && line.Type != diffreviewer.LineUnchanged {
+				continue
+			}
+			if suppression := ParseSuppression(line.Content, line.LnumNew); suppression != nil {
+				suppressions = append(suppressions, suppression)
+			}
+		}
+	}
+	return suppressions
+}
@@ -0,0 +1,76 @@
+package diffutils_test
+
+import (
+	"testing"
+
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer/diffutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSuppression(t *testing.T) {
+	tests := []struct {
+		haveContent string
+		want        *diffreviewer.Suppression
+		desc        string
+	}{
+		{
+			haveContent: `token := "abc"`,
+			want:        nil,
+			desc:        "no marker",
+		},
+		{
+			haveContent: `token := "abc" // nightfall:ignore`,
+			want:        &diffreviewer.Suppression{LineNumber: 10, MarkerLineNumber: 10, Detectors: []string{}},
+			desc:        "same line",
+		},
+		{
+			haveContent: `# nightfall:ignore-next-line API_KEY`,
+			want:        &diffreviewer.Suppression{LineNumber: 11, MarkerLineNumber: 10, Detectors: []string{"API_KEY"}},
+			desc:        "next line with detector",
+		},
+		{
+			haveContent: `/* nightfall:ignore API_KEY,High_Entropy_String reason="test fixture" expires=2030-01-31 */`,
+			want: &diffreviewer.Suppression{
+				LineNumber:       10,
+				MarkerLineNumber: 10,
+				Detectors:        []string{"API_KEY", "High_Entropy_String"},
+				Reason:           "test fixture",
+				Expires:          "2030-01-31",
+			},
+			desc: "detectors, reason and expiry",
+		},
+		{
+			haveContent: `<!-- nightfall:ignore reason=docs -->`,
+			want:        &diffreviewer.Suppression{LineNumber: 10, MarkerLineNumber: 10, Detectors: []string{}, Reason: "docs"},
+			desc:        "unquoted reason",
+		},
+		{
+			haveContent: `// nightfall:ignored`,
+			want:        nil,
+			desc:        "marker must be a whole word",
+		},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, diffutils.ParseSuppression(tt.haveContent, 10), tt.desc)
+	}
+}
+
+func TestFilterFileDiffsSuppressions(t *testing.T) {
+	fileDiffs := []*diffreviewer.FileDiff{{
+		PathNew: "main.go",
+		Hunks: []*diffreviewer.Hunk{{
+			Lines: []*diffreviewer.Line{
+				{Type: diffreviewer.LineUnchanged, Content: "// nightfall:ignore-next-line", LnumNew: 4},
+				{Type: diffreviewer.LineAdded, Content: `key := "secret"`, LnumNew: 5},
+				{Type: diffreviewer.LineDeleted, Content: "// nightfall:ignore", LnumOld: 6},
+			},
+		}},
+	}}
+
+	filtered := diffutils.FilterFileDiffs(fileDiffs)
+	assert.Len(t, filtered[0].Hunks[0].Lines, 1, "only added lines should be scanned")
+	assert.Equal(t, []*diffreviewer.Suppression{
+		{LineNumber: 5, MarkerLineNumber: 4, Detectors: []string{}},
+	}, filtered[0].Suppressions, "suppressions should be collected from context lines but not deleted lines")
+}
@@ -0,0 +1,159 @@
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	// appJWTLifetime stays below the 10 minutes github accepts for an app JWT
+	appJWTLifetime = 9 * time.Minute
+	// appJWTClockDrift backdates the JWT in case the clock of the CI runner is ahead of github
+	appJWTClockDrift = 60 * time.Second
+	// installationTokenExpiryDelta refreshes an installation token this long before it expires
+	installationTokenExpiryDelta = time.Minute
+	installationTokenPath        = "app/installations/%d/access_tokens"
+	installationTokenTimeout     = 30 * time.Second
+)
+
+// AppCredentials authenticate as a GitHub App installation instead of with a static token
+type AppCredentials struct {
+	// AppID is the ID of the GitHub App
+	AppID int64
+	// InstallationID is the ID of the installation of the app on the owner of the repository
+	InstallationID int64
+	// PrivateKey is the PEM encoded private key of the app
+	PrivateKey []byte
+}
+
+type installationToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// installationTokenSource exchanges JWTs signed with the app private key for installation tokens
+type installationTokenSource struct {
+	appID      int64
+	key        *rsa.PrivateKey
+	tokenURL   string
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewAppAuthenticatedClient generates a github client authenticated as a GitHub App installation.
+// Installation tokens expire after an hour, they are refreshed before they expire.
+func NewAppAuthenticatedClient(credentials AppCredentials, baseUrl string) (*Client, error) {
+	ts, err := newInstallationTokenSource(credentials, apiBaseURL(baseUrl).String(), &http.Client{Timeout: installationTokenTimeout})
+	if err != nil {
+		return nil, err
+	}
+	return newClient(oauth2.ReuseTokenSource(nil, ts), baseUrl), nil
+}
+
+func newInstallationTokenSource(credentials AppCredentials, apiURL string, httpClient *http.Client) (*installationTokenSource, error) {
+	if credentials.AppID == 0 || credentials.InstallationID == 0 {
+		return nil, errors.New("github app ID and installation ID are required")
+	}
+	key, err := parseAppPrivateKey(credentials.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return &installationTokenSource{
+		appID:      credentials.AppID,
+		key:        key,
+		tokenURL:   strings.TrimSuffix(apiURL, "/") + "/" + fmt.Sprintf(installationTokenPath, credentials.InstallationID),
+		httpClient: httpClient,
+		now:        time.Now,
+	}, nil
+}
+
+// parseAppPrivateKey parses the PKCS #1 key github generates for apps, or a PKCS #8 RSA key
+func parseAppPrivateKey(privateKey []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(privateKey)
+	if block == nil {
+		return nil, errors.New("github app private key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid github app private key: %v", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("github app private key is not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// Token creates a new installation token
+func (s *installationTokenSource) Token() (*oauth2.Token, error) {
+	jwt, err := s.appJWT()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.tokenURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create github app installation token: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to create github app installation token: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	var token installationToken
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, fmt.Errorf("invalid github app installation token: %v", err)
+	}
+	return &oauth2.Token{
+		AccessToken: token.Token,
+		Expiry:      token.ExpiresAt.Add(-installationTokenExpiryDelta),
+	}, nil
+}
+
+// appJWT creates the RS256 JWT authenticating as the app
+// https://docs.github.com/en/developers/apps/authenticating-with-github-apps#authenticating-as-a-github-app
+func (s *installationTokenSource) appJWT() (string, error) {
+	now := s.now()
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-appJWTClockDrift).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": strconv.FormatInt(s.appID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign github app JWT: %v", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
@@ -0,0 +1,108 @@
+package github
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+)
+
+func (g *githubTestSuite) TestInstallationTokenSource() {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	g.NoError(err, "Error generating the test key")
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	now := time.Unix(1600000000, 0)
+	expiresAt := now.Add(time.Hour)
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		g.Equal(http.MethodPost, r.Method, "invalid method")
+		g.Equal("/app/installations/42/access_tokens", r.URL.Path, "invalid path")
+		parts := strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), ".")
+		g.Len(parts, 3, "invalid JWT")
+		signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+		g.NoError(err, "invalid JWT signature encoding")
+		hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		g.NoError(rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hash[:], signature), "invalid JWT signature")
+		claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+		g.NoError(err, "invalid JWT claims encoding")
+		var claims map[string]interface{}
+		g.NoError(json.Unmarshal(claimsJSON, &claims), "invalid JWT claims")
+		g.Equal(map[string]interface{}{
+			"iat": float64(now.Add(-time.Minute).Unix()),
+			"exp": float64(now.Add(9 * time.Minute).Unix()),
+			"iss": "7",
+		}, claims, "invalid JWT claims")
+
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"token":"token-%d","expires_at":%q}`, requests, expiresAt.Format(time.RFC3339))
+	}))
+	defer server.Close()
+
+	ts, err := newInstallationTokenSource(
+		AppCredentials{AppID: 7, InstallationID: 42, PrivateKey: privateKey},
+		server.URL+"/",
+		server.Client(),
+	)
+	g.NoError(err, "Error creating the token source")
+	ts.now = func() time.Time { return now }
+
+	token, err := ts.Token()
+	g.NoError(err, "Error creating an installation token")
+	g.Equal("token-1", token.AccessToken, "invalid installation token")
+	g.True(token.Expiry.Equal(expiresAt.Add(-time.Minute)), "the token should be refreshed a minute before it expires")
+	token, err = ts.Token()
+	g.NoError(err, "Error refreshing the installation token")
+	g.Equal("token-2", token.AccessToken, "invalid refreshed installation token")
+}
+
+func (g *githubTestSuite) TestInstallationTokenSourceError() {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	g.NoError(err, "Error generating the test key")
+	pkcs8Key, err := x509.MarshalPKCS8PrivateKey(key)
+	g.NoError(err, "Error encoding the test key")
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8Key})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"message":"Bad credentials"}`)
+	}))
+	defer server.Close()
+
+	ts, err := newInstallationTokenSource(AppCredentials{AppID: 7, InstallationID: 42, PrivateKey: privateKey}, server.URL, server.Client())
+	g.NoError(err, "Error creating the token source with a PKCS #8 key")
+	_, err = ts.Token()
+	g.EqualError(err, `failed to create github app installation token: 401 Unauthorized: {"message":"Bad credentials"}`, "invalid error")
+}
+
+func (g *githubTestSuite) TestNewInstallationTokenSourceInvalidCredentials() {
+	tests := []struct {
+		have AppCredentials
+		want string
+		desc string
+	}{
+		{
+			have: AppCredentials{AppID: 7, PrivateKey: []byte("key")},
+			want: "github app ID and installation ID are required",
+			desc: "missing installation ID",
+		},
+		{
+			have: AppCredentials{AppID: 7, InstallationID: 42, PrivateKey: []byte("key")},
+			want: "github app private key is not PEM encoded",
+			desc: "invalid private key",
+		},
+	}
+	for _, tt := range tests {
+		_, err := newInstallationTokenSource(tt.have, DefaultServerURL, http.DefaultClient)
+		g.EqualError(err, tt.want, tt.desc)
+	}
+}
@@ -0,0 +1,175 @@
+package github
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v33/github"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+)
+
+const (
+	fingerprintMarker         = "<!-- nightfalldlp:fingerprint=%s -->"
+	resolvedFingerprintMarker = "<!-- nightfalldlp:fingerprint=%s resolved -->"
+	resolvedCommentString     = "Resolved in %s"
+	movedCommentString        = "Moved to line %d in %s"
+)
+
+var fingerprintMarkerRegex = regexp.MustCompile(`\s*<!-- nightfalldlp:fingerprint=([0-9a-f]+-[0-9]+)( resolved)? -->`)
+
+// legacyCommentRegex matches the body of the comments posted before fingerprint markers were added
+var legacyCommentRegex = regexp.MustCompile(`^(failure|warning|notice): Suspicious content detected \(`)
+
+// CommentEdit is an edit of a pull request comment posted by a previous run
+type CommentEdit struct {
+	ID   int64
+	Body string
+}
+
+// CommentFingerprints identify the finding of every comment across runs. The hash covers the path, the detector
+// and the comment body, which only holds the redacted finding, and identical findings of a file are numbered in order.
+func CommentFingerprints(comments []*diffreviewer.Comment) []string {
+	fingerprints := make([]string, len(comments))
+	counts := make(map[string]int, len(comments))
+	for i, c := range comments {
+		sum := sha256.Sum256([]byte(c.FilePath + "\x00" + c.DetectorName + "\x00" + c.Body))
+		hash := hex.EncodeToString(sum[:8])
+		counts[hash]++
+		fingerprints[i] = fmt.Sprintf("%s-%d", hash, counts[hash])
+	}
+	return fingerprints
+}
+
+// WithFingerprint appends the hidden fingerprint marker to the body of a comment
+func WithFingerprint(body, fingerprint string) string {
+	return body + "\n\n" + fmt.Sprintf(fingerprintMarker, fingerprint)
+}
+
+// parseFingerprint returns the fingerprint of a comment posted by the scanner, and whether it was already resolved
+func parseFingerprint(body string) (fingerprint string, resolved bool, ok bool) {
+	match := fingerprintMarkerRegex.FindStringSubmatch(body)
+	if match == nil {
+		return "", false, false
+	}
+	return match[1], match[2] != "", true
+}
+
+// legacyFingerprint identifies a comment posted without a fingerprint marker, it is numbered 0
+// so that it never matches the fingerprint of a finding
+func legacyFingerprint(c *github.PullRequestComment) string {
+	sum := sha256.Sum256([]byte(c.GetPath() + "\x00" + c.GetBody()))
+	return hex.EncodeToString(sum[:8]) + "-0"
+}
+
+// legacyKey matches a comment posted without a fingerprint marker to the comment of the same finding
+func legacyKey(path, body string) string {
+	return path + "\x00" + strings.TrimSpace(fingerprintMarkerRegex.ReplaceAllString(body, ""))
+}
+
+// resolvedBody strikes through the body of a comment whose finding is gone, and explains why with note
+func resolvedBody(body, fingerprint, note string) string {
+	body = strings.TrimSpace(fingerprintMarkerRegex.ReplaceAllString(body, ""))
+	return fmt.Sprintf("~~%s~~\n\n%s\n\n"+resolvedFingerprintMarker, body, note, fingerprint)
+}
+
+// ReconcileComments compares the fingerprinted comments of the findings of commit sha with the comments posted by
+// previous runs. It returns the comments not posted yet, and the edits resolving the previous comments whose finding
+// is gone, or moved to another line. Comments posted before fingerprint markers were added are recognised by their
+// body: those still on the line of their finding are edited to carry its marker, the others are resolved.
+// Comments on the unscanned paths are left as they are.
+func ReconcileComments(
+	comments []*github.PullRequestComment,
+	existingComments []*github.PullRequestComment,
+	sha string,
+	unscannedPaths []string,
+) ([]*github.PullRequestComment, []*CommentEdit) {
+	commentsByFingerprint := make(map[string]*github.PullRequestComment, len(comments))
+	commentsByLegacyKey := make(map[string][]*github.PullRequestComment, len(comments))
+	for _, c := range comments {
+		if fingerprint, _, ok := parseFingerprint(c.GetBody()); ok {
+			commentsByFingerprint[fingerprint] = c
+			key := legacyKey(c.GetPath(), c.GetBody())
+			commentsByLegacyKey[key] = append(commentsByLegacyKey[key], c)
+		}
+	}
+	unscanned := make(map[string]bool, len(unscannedPaths))
+	for _, path := range unscannedPaths {
+		unscanned[path] = true
+	}
+
+	posted := make(map[string]bool, len(existingComments))
+	edits := make([]*CommentEdit, 0)
+	for _, ec := range existingComments {
+		if unscanned[ec.GetPath()] {
+			continue
+		}
+		fingerprint, resolved, ok := parseFingerprint(ec.GetBody())
+		if !ok {
+			if edit := reconcileLegacyComment(ec, commentsByLegacyKey, posted, sha); edit != nil {
+				edits = append(edits, edit)
+			}
+			continue
+		}
+		if resolved {
+			continue
+		}
+		c, found := commentsByFingerprint[fingerprint]
+		switch {
+		case !found:
+			edits = append(edits, &CommentEdit{
+				ID:   ec.GetID(),
+				Body: resolvedBody(ec.GetBody(), fingerprint, fmt.Sprintf(resolvedCommentString, sha)),
+			})
+		case !posted[fingerprint] && ec.Line != nil && ec.GetPath() == c.GetPath() && ec.GetLine() == c.GetLine():
+			posted[fingerprint] = true
+		default:
+			edits = append(edits, &CommentEdit{
+				ID:   ec.GetID(),
+				Body: resolvedBody(ec.GetBody(), fingerprint, fmt.Sprintf(movedCommentString, c.GetLine(), sha)),
+			})
+		}
+	}
+
+	newComments := make([]*github.PullRequestComment, 0, len(comments))
+	for _, c := range comments {
+		if fingerprint, _, ok := parseFingerprint(c.GetBody()); ok && posted[fingerprint] {
+			continue
+		}
+		newComments = append(newComments, c)
+	}
+	return newComments, edits
+}
+
+// reconcileLegacyComment returns the edit of a comment posted without a fingerprint marker, nil if the comment
+// was not posted by the scanner
+func reconcileLegacyComment(
+	ec *github.PullRequestComment,
+	commentsByLegacyKey map[string][]*github.PullRequestComment,
+	posted map[string]bool,
+	sha string,
+) *CommentEdit {
+	candidates := commentsByLegacyKey[legacyKey(ec.GetPath(), ec.GetBody())]
+	if len(candidates) == 0 && !legacyCommentRegex.MatchString(ec.GetBody()) {
+		return nil
+	}
+	if len(candidates) == 0 {
+		return &CommentEdit{
+			ID:   ec.GetID(),
+			Body: resolvedBody(ec.GetBody(), legacyFingerprint(ec), fmt.Sprintf(resolvedCommentString, sha)),
+		}
+	}
+	for _, c := range candidates {
+		fingerprint, _, _ := parseFingerprint(c.GetBody())
+		if !posted[fingerprint] && ec.Line != nil && ec.GetLine() == c.GetLine() {
+			posted[fingerprint] = true
+			return &CommentEdit{ID: ec.GetID(), Body: c.GetBody()}
+		}
+	}
+	return &CommentEdit{
+		ID:   ec.GetID(),
+		Body: resolvedBody(ec.GetBody(), legacyFingerprint(ec), fmt.Sprintf(movedCommentString, candidates[0].GetLine(), sha)),
+	}
+}
@@ -0,0 +1,120 @@
+package github
+
+import (
+	"fmt"
+
+	"github.com/google/go-github/v33/github"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+)
+
+func (g *githubTestSuite) TestCommentFingerprints() {
+	comments := []*diffreviewer.Comment{
+		{FilePath: "a.go", DetectorName: "API_KEY", Body: "key"},
+		{FilePath: "a.go", DetectorName: "API_KEY", Body: "key"},
+		{FilePath: "b.go", DetectorName: "API_KEY", Body: "key"},
+	}
+	fingerprints := CommentFingerprints(comments)
+	g.Len(fingerprints, 3, "there should be a fingerprint for every comment")
+	g.Regexp("^[0-9a-f]{16}-1$", fingerprints[0], "invalid fingerprint")
+	g.Equal(fingerprints[0][:16]+"-2", fingerprints[1], "identical findings should be numbered")
+	g.NotEqual(fingerprints[0][:16], fingerprints[2][:16], "findings of another file should have another hash")
+	g.Equal(fingerprints, CommentFingerprints(comments), "fingerprints should be stable across runs")
+
+	fingerprint, resolved, ok := parseFingerprint(WithFingerprint("failure: key", fingerprints[1]))
+	g.True(ok, "the fingerprint marker should be found")
+	g.False(resolved, "the comment should not be resolved")
+	g.Equal(fingerprints[1], fingerprint, "incorrect fingerprint")
+}
+
+func (g *githubTestSuite) TestReconcileComments() {
+	sha := "7b46da6e4d3259b1a1c470ee468e2cb3d9733802"
+	newComment := func(body, path string, line int) *github.PullRequestComment {
+		return &github.PullRequestComment{
+			Body: github.String(body),
+			Path: github.String(path),
+			Line: github.Int(line),
+		}
+	}
+	existingComment := func(id int64, body, path string, line *int) *github.PullRequestComment {
+		return &github.PullRequestComment{
+			ID:   github.Int64(id),
+			Body: github.String(body),
+			Path: github.String(path),
+			Line: line,
+		}
+	}
+	kept := newComment(WithFingerprint("failure: kept", "0000000000000001-1"), "a.go", 3)
+	moved := newComment(WithFingerprint("failure: moved", "0000000000000002-1"), "a.go", 9)
+	added := newComment(WithFingerprint("failure: added", "0000000000000003-1"), "a.go", 12)
+	comments := []*github.PullRequestComment{kept, moved, added}
+	existingComments := []*github.PullRequestComment{
+		existingComment(1, kept.GetBody(), "a.go", github.Int(3)),
+		existingComment(2, moved.GetBody(), "a.go", github.Int(5)),
+		existingComment(3, WithFingerprint("failure: removed", "0000000000000004-1"), "a.go", nil),
+		existingComment(4, "~~failure: resolved~~\n\nResolved in 123\n\n<!-- nightfalldlp:fingerprint=0000000000000005-1 resolved -->", "a.go", nil),
+		existingComment(5, WithFingerprint("failure: unscanned", "0000000000000006-1"), "big.json", github.Int(1)),
+		existingComment(6, "looks good to me", "a.go", github.Int(3)),
+	}
+
+	newComments, edits := ReconcileComments(comments, existingComments, sha, []string{"big.json"})
+	g.Equal([]*github.PullRequestComment{moved, added}, newComments, "invalid comments to create")
+	g.Equal([]*CommentEdit{
+		{
+			ID:   2,
+			Body: fmt.Sprintf("~~failure: moved~~\n\nMoved to line 9 in %s\n\n<!-- nightfalldlp:fingerprint=0000000000000002-1 resolved -->", sha),
+		},
+		{
+			ID:   3,
+			Body: fmt.Sprintf("~~failure: removed~~\n\nResolved in %s\n\n<!-- nightfalldlp:fingerprint=0000000000000004-1 resolved -->", sha),
+		},
+	}, edits, "invalid comment edits")
+}
+
+func (g *githubTestSuite) TestReconcileLegacyComments() {
+	sha := "7b46da6e4d3259b1a1c470ee468e2cb3d9733802"
+	newComment := func(body, path string, line int) *github.PullRequestComment {
+		return &github.PullRequestComment{
+			Body: github.String(body),
+			Path: github.String(path),
+			Line: github.Int(line),
+		}
+	}
+	existingComment := func(id int64, body, path string, line int) *github.PullRequestComment {
+		return &github.PullRequestComment{
+			ID:   github.Int64(id),
+			Body: github.String(body),
+			Path: github.String(path),
+			Line: github.Int(line),
+		}
+	}
+	keptBody := "failure: Suspicious content detected (\"49***\", type \"CREDIT_CARD_NUMBER\")"
+	movedBody := "failure: Suspicious content detected (\"sk***\", type \"API_KEY\")"
+	removedBody := "failure: Suspicious content detected (\"12***\", type \"US_SOCIAL_SECURITY_NUMBER\")"
+	kept := newComment(WithFingerprint(keptBody, "0000000000000001-1"), "a.go", 3)
+	moved := newComment(WithFingerprint(movedBody, "0000000000000002-1"), "a.go", 9)
+	comments := []*github.PullRequestComment{kept, moved}
+	removed := existingComment(3, removedBody, "a.go", 7)
+	existingComments := []*github.PullRequestComment{
+		existingComment(1, keptBody, "a.go", 3),
+		existingComment(2, movedBody, "a.go", 5),
+		removed,
+		existingComment(4, "please remove this key", "a.go", 9),
+	}
+
+	newComments, edits := ReconcileComments(comments, existingComments, sha, nil)
+	g.Equal([]*github.PullRequestComment{moved}, newComments, "invalid comments to create")
+	g.Equal([]*CommentEdit{
+		{
+			ID:   1,
+			Body: kept.GetBody(),
+		},
+		{
+			ID:   2,
+			Body: fmt.Sprintf("~~%s~~\n\nMoved to line 9 in %s\n\n<!-- nightfalldlp:fingerprint=%s resolved -->", movedBody, sha, legacyFingerprint(existingComments[1])),
+		},
+		{
+			ID:   3,
+			Body: fmt.Sprintf("~~%s~~\n\nResolved in %s\n\n<!-- nightfalldlp:fingerprint=%s resolved -->", removedBody, sha, legacyFingerprint(removed)),
+		},
+	}, edits, "invalid comment edits")
+}
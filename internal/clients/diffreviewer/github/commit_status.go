@@ -0,0 +1,107 @@
+package github
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v33/github"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+	"github.com/nightfallai/nightfall_code_scanner/internal/nightfallconfig"
+)
+
+const (
+	commitStatusPending = "pending"
+	commitStatusSuccess = "success"
+	commitStatusFailure = "failure"
+
+	commitStatusPendingDescription = "Nightfall DLP is reporting findings"
+	commitStatusUnscannedString    = ", %d files could not be scanned"
+	// maxCommitStatusDescription is the length github accepts for the description of a commit status
+	maxCommitStatusDescription = 140
+	workflowRunURLFormat       = "%s/%s/%s/actions/runs/%s"
+)
+
+// writeCommitStatus reports the findings with a commit status instead of a check run, for tokens without the
+// checks:write permission. A status holds no annotations, so the findings are logged to the workflow run it links to.
+func (s *Service) writeCommitStatus(comments []*diffreviewer.Comment, level string, failure *diffreviewer.ScanFailure) error {
+	err := s.createCommitStatus(commitStatusPending, commitStatusPendingDescription)
+	if err != nil {
+		s.Logger.Error("Error creating a Github commit status")
+		return err
+	}
+	s.logComments(comments, level)
+	description := fmt.Sprintf(summaryString, len(comments))
+	if failure != nil {
+		s.Logger.Warning(failure.Summary())
+		description += fmt.Sprintf(commitStatusUnscannedString, len(failure.Files))
+	}
+	if s.PullRequestReview && s.CheckRequest.PullRequest != 0 {
+		err := s.writePullRequestReview(comments, level, failure)
+		if err != nil {
+			s.Logger.Warning(fmt.Sprintf("Unable to write the findings as a pull request review: %v", err))
+		}
+	}
+	state := commitStatusSuccess
+	if *getConclusion(createAnnotations(comments, level), failure) == checkRunConclusionFailure {
+		state = commitStatusFailure
+	}
+	err = s.createCommitStatus(state, description)
+	if err != nil {
+		s.Logger.Error(fmt.Sprintf("Unable to update commit status to %s", state))
+		return err
+	}
+	return nil
+}
+
+// createCommitStatus sets the state of the commit status of the head commit, linking to the workflow run if known
+func (s *Service) createCommitStatus(state, description string) error {
+	if len(description) > maxCommitStatusDescription {
+		description = description[:maxCommitStatusDescription-3] + "..."
+	}
+	status := &github.RepoStatus{
+		State:       github.String(state),
+		Description: github.String(description),
+		Context:     github.String(getCheckName(s.CheckRequest.Name)),
+	}
+	if s.TargetURL != "" {
+		status.TargetURL = github.String(s.TargetURL)
+	}
+	_, _, err := s.Client.StatusesService().CreateStatus(
+		context.Background(),
+		s.CheckRequest.Owner,
+		s.CheckRequest.Repo,
+		s.CheckRequest.SHA,
+		status,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create commit status: %v", err)
+	}
+	return nil
+}
+
+// logComments logs every finding to the workflow run at the annotation level
+func (s *Service) logComments(comments []*diffreviewer.Comment, level string) {
+	for _, comment := range comments {
+		logString := fmt.Sprintf(
+			"%s at %s on line %d",
+			comment.Body,
+			comment.FilePath,
+			comment.LineNumber,
+		)
+		switch level {
+		case nightfallconfig.AnnotationLevelFailure:
+			s.Logger.Error(logString)
+		case nightfallconfig.AnnotationLevelWarning:
+			s.Logger.Warning(logString)
+		case nightfallconfig.AnnotationLevelNotice:
+			s.Logger.Info(logString)
+		default:
+			s.Logger.Error(logString)
+		}
+	}
+}
+
+// workflowRunURL returns the web url of the workflow run of runID
+func workflowRunURL(serverURL, owner, repo, runID string) string {
+	return fmt.Sprintf(workflowRunURLFormat, serverURL, owner, repo, runID)
+}
@@ -0,0 +1,146 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/v33/github"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+	"github.com/nightfallai/nightfall_code_scanner/internal/mocks/clients/githubchecks_mock"
+	"github.com/nightfallai/nightfall_code_scanner/internal/mocks/clients/githubclient_mock"
+	"github.com/nightfallai/nightfall_code_scanner/internal/mocks/clients/githubstatuses_mock"
+	"github.com/nightfallai/nightfall_code_scanner/internal/nightfallconfig"
+)
+
+func (g *githubTestSuite) TestWriteCommentsCommitStatus() {
+	tp := g.initTestParams()
+	defer tp.ctrl.Finish()
+	targetURL := workflowRunURL(DefaultServerURL, testPRCheckRequest.Owner, testPRCheckRequest.Repo, "1234")
+	failedComments, _ := makeTestCommentsAndAnnotations("testComment", "main.go", nightfallconfig.AnnotationLevelFailure, 2)
+	warningComments, _ := makeTestCommentsAndAnnotations("testComment", "main.go", nightfallconfig.AnnotationLevelWarning, 2)
+
+	tests := []struct {
+		haveComments    []*diffreviewer.Comment
+		haveLevel       string
+		haveFailure     *diffreviewer.ScanFailure
+		wantState       string
+		wantDescription string
+		desc            string
+	}{
+		{
+			haveComments:    failedComments,
+			haveLevel:       nightfallconfig.AnnotationLevelFailure,
+			wantState:       "failure",
+			wantDescription: fmt.Sprintf(summaryString, 2),
+			desc:            "failure findings",
+		},
+		{
+			haveComments:    warningComments,
+			haveLevel:       nightfallconfig.AnnotationLevelWarning,
+			wantState:       "success",
+			wantDescription: fmt.Sprintf(summaryString, 2),
+			desc:            "warning findings",
+		},
+		{
+			haveComments: []*diffreviewer.Comment{},
+			haveLevel:    nightfallconfig.AnnotationLevelFailure,
+			haveFailure: &diffreviewer.ScanFailure{
+				Policy: nightfallconfig.FailurePolicyFailClosed,
+				Files:  []string{"main.go"},
+				Err:    errors.New("request failed"),
+			},
+			wantState:       "failure",
+			wantDescription: fmt.Sprintf(summaryString, 0) + ", 1 files could not be scanned",
+			desc:            "unscanned files",
+		},
+	}
+	for _, tt := range tests {
+		mockClient := githubclient_mock.NewGithubClient(tp.ctrl)
+		mockStatuses := githubstatuses_mock.NewGithubStatuses(tp.ctrl)
+		tp.gc = &Service{
+			Client:       mockClient,
+			CheckRequest: testPRCheckRequest,
+			Logger:       log,
+			CommitStatus: true,
+			TargetURL:    targetURL,
+		}
+
+		mockClient.EXPECT().StatusesService().Return(mockStatuses).Times(2)
+		gomock.InOrder(
+			mockStatuses.EXPECT().CreateStatus(
+				context.Background(),
+				testPRCheckRequest.Owner,
+				testPRCheckRequest.Repo,
+				testPRCheckRequest.SHA,
+				&github.RepoStatus{
+					State:       github.String("pending"),
+					Description: github.String(commitStatusPendingDescription),
+					Context:     github.String("Nightfall DLP"),
+					TargetURL:   github.String(targetURL),
+				},
+			),
+			mockStatuses.EXPECT().CreateStatus(
+				context.Background(),
+				testPRCheckRequest.Owner,
+				testPRCheckRequest.Repo,
+				testPRCheckRequest.SHA,
+				&github.RepoStatus{
+					State:       github.String(tt.wantState),
+					Description: github.String(tt.wantDescription),
+					Context:     github.String("Nightfall DLP"),
+					TargetURL:   github.String(targetURL),
+				},
+			),
+		)
+
+		err := tp.gc.WriteComments(tt.haveComments, tt.haveLevel, tt.haveFailure)
+		g.NoError(err, fmt.Sprintf("Error writing commit status for %s test", tt.desc))
+	}
+}
+
+func (g *githubTestSuite) TestWriteCommentsCheckRunFallback() {
+	tp := g.initTestParams()
+	defer tp.ctrl.Finish()
+	mockClient := githubclient_mock.NewGithubClient(tp.ctrl)
+	mockChecks := githubchecks_mock.NewGithubChecks(tp.ctrl)
+	mockStatuses := githubstatuses_mock.NewGithubStatuses(tp.ctrl)
+	tp.gc = &Service{
+		Client:       mockClient,
+		CheckRequest: testPRCheckRequest,
+		Logger:       log,
+	}
+
+	mockClient.EXPECT().ChecksService().Return(mockChecks)
+	mockChecks.EXPECT().CreateCheckRun(context.Background(), testPRCheckRequest.Owner, testPRCheckRequest.Repo, gomock.Any()).
+		Return(nil, nil, errors.New("Resource not accessible by integration"))
+	mockClient.EXPECT().StatusesService().Return(mockStatuses).Times(2)
+	gomock.InOrder(
+		mockStatuses.EXPECT().CreateStatus(
+			context.Background(),
+			testPRCheckRequest.Owner,
+			testPRCheckRequest.Repo,
+			testPRCheckRequest.SHA,
+			&github.RepoStatus{
+				State:       github.String("pending"),
+				Description: github.String(commitStatusPendingDescription),
+				Context:     github.String("Nightfall DLP"),
+			},
+		),
+		mockStatuses.EXPECT().CreateStatus(
+			context.Background(),
+			testPRCheckRequest.Owner,
+			testPRCheckRequest.Repo,
+			testPRCheckRequest.SHA,
+			&github.RepoStatus{
+				State:       github.String("success"),
+				Description: github.String(fmt.Sprintf(summaryString, 0)),
+				Context:     github.String("Nightfall DLP"),
+			},
+		),
+	)
+
+	err := tp.gc.WriteComments([]*diffreviewer.Comment{}, nightfallconfig.AnnotationLevelFailure, nil)
+	g.NoError(err, "Error falling back to a commit status")
+}
@@ -17,24 +17,33 @@ type Client struct {
 
 // NewAuthenticatedClient generates an authenticated github client
 func NewAuthenticatedClient(token string, baseUrl string) *Client {
-	ctx := context.Background()
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
 	)
-	tc := oauth2.NewClient(ctx, ts)
+	return newClient(ts, baseUrl)
+}
+
+func newClient(ts oauth2.TokenSource, baseUrl string) *Client {
+	tc := oauth2.NewClient(context.Background(), ts)
 	githubClient := github.NewClient(tc)
+	githubClient.BaseURL = apiBaseURL(baseUrl)
+	return &Client{githubClient}
+}
+
+// apiBaseURL returns the url of the API of github.com, or of the enterprise server at baseUrl
+func apiBaseURL(baseUrl string) *url.URL {
+	if baseUrl == "" {
+		return github.NewClient(nil).BaseURL
+	}
 	// for enterprise
-	if baseUrl != "" {
-		u, _ := url.Parse(baseUrl)
-		if !strings.HasSuffix(u.Path, "/") {
-			u.Path += "/"
-		}
-		if !strings.HasSuffix(u.Path, "/api/v3/") {
-			u.Path += "api/v3/"
-		}
-		githubClient.BaseURL = u
+	u, _ := url.Parse(baseUrl)
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
 	}
-	return &Client{githubClient}
+	if !strings.HasSuffix(u.Path, "/api/v3/") {
+		u.Path += "api/v3/"
+	}
+	return u
 }
 
 // ChecksService gets the github client's checks service
@@ -42,6 +51,11 @@ func (c *Client) ChecksService() githubintf.GithubChecks {
 	return c.Client.Checks
 }
 
+// IssuesService gets the github client's issues service
+func (c *Client) IssuesService() githubintf.GithubIssues {
+	return c.Client.Issues
+}
+
 // PullRequestsService gets the github client's pull requests service
 func (c *Client) PullRequestsService() githubintf.GithubPullRequests {
 	return c.Client.PullRequests
@@ -51,3 +65,8 @@ func (c *Client) PullRequestsService() githubintf.GithubPullRequests {
 func (c *Client) RepositoriesService() githubintf.GithubRepositories {
 	return c.Client.Repositories
 }
+
+// StatusesService gets the github client's repositories service, which creates commit statuses
+func (c *Client) StatusesService() githubintf.GithubStatuses {
+	return c.Client.Repositories
+}
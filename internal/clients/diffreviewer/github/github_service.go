@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/google/go-github/v33/github"
@@ -27,6 +28,11 @@ const (
 	EventPathEnvVar          = "GITHUB_EVENT_PATH"
 	BaseRefEnvVar            = "GITHUB_BASE_REF"
 	NightfallAPIKeyEnvVar    = "NIGHTFALL_API_KEY"
+	PullRequestReviewEnvVar  = "NIGHTFALL_PULL_REQUEST_REVIEW"
+	PullRequestSummaryEnvVar = "NIGHTFALL_PULL_REQUEST_SUMMARY"
+	ServerURLEnvVar          = "GITHUB_SERVER_URL"
+	RunIDEnvVar              = "GITHUB_RUN_ID"
+	CommitStatusEnvVar       = "NIGHTFALL_COMMIT_STATUS"
 	MaxAnnotationsPerRequest = 50 // https://developer.github.com/v3/checks/runs/#output-object
 
 	imageURL      = "https://cdn.nightfall.ai/nightfall-dark-logo-tm.png"
@@ -121,6 +127,16 @@ type Service struct {
 	Logger       logger.Logger
 	CheckRequest *CheckRequest
 	GitDiff      gitdiffintf.GitDiff
+	// PullRequestReview also comments the findings of a pull request in a review
+	PullRequestReview bool
+	// PullRequestSummary also maintains a single summary comment of the findings on the pull request
+	PullRequestSummary bool
+	// ServerURL is the web url of the github server, linked from the summary comment
+	ServerURL string
+	// CommitStatus reports the findings with a commit status instead of a check run
+	CommitStatus bool
+	// TargetURL is the url of the workflow run, linked from the commit status
+	TargetURL string
 }
 
 // NewAuthenticatedGithubService creates a new authenticated github service with the github token
@@ -131,6 +147,18 @@ func NewAuthenticatedGithubService(githubToken, baseURL string) diffreviewer.Dif
 	}
 }
 
+// NewAppAuthenticatedGithubService creates a new github service authenticated as a GitHub App installation
+func NewAppAuthenticatedGithubService(credentials AppCredentials, baseURL string) (diffreviewer.DiffReviewer, error) {
+	client, err := NewAppAuthenticatedClient(credentials, baseURL)
+	if err != nil {
+		return nil, err
+	}
+	return &Service{
+		Client: client,
+		Logger: githublogger.NewDefaultGithubLogger(),
+	}, nil
+}
+
 func getEventFile(eventPath string) (*event, error) {
 	f, err := os.Open(eventPath)
 	if err != nil {
@@ -176,6 +204,31 @@ func (s *Service) LoadConfig(nightfallConfigFileName string) (*nightfallconfig.C
 	if s.CheckRequest.SHA == "" {
 		s.CheckRequest.SHA = event.HeadCommit.ID
 	}
+	if review, ok := os.LookupEnv(PullRequestReviewEnvVar); ok {
+		s.PullRequestReview, err = strconv.ParseBool(review)
+		if err != nil {
+			s.Logger.Warning(fmt.Sprintf("Invalid %s value: %s. Defaulting to false", PullRequestReviewEnvVar, review))
+		}
+	}
+	if summary, ok := os.LookupEnv(PullRequestSummaryEnvVar); ok {
+		s.PullRequestSummary, err = strconv.ParseBool(summary)
+		if err != nil {
+			s.Logger.Warning(fmt.Sprintf("Invalid %s value: %s. Defaulting to false", PullRequestSummaryEnvVar, summary))
+		}
+	}
+	s.ServerURL = os.Getenv(ServerURLEnvVar)
+	if s.ServerURL == "" {
+		s.ServerURL = DefaultServerURL
+	}
+	if commitStatus, ok := os.LookupEnv(CommitStatusEnvVar); ok {
+		s.CommitStatus, err = strconv.ParseBool(commitStatus)
+		if err != nil {
+			s.Logger.Warning(fmt.Sprintf("Invalid %s value: %s. Defaulting to false", CommitStatusEnvVar, commitStatus))
+		}
+	}
+	if runID := os.Getenv(RunIDEnvVar); runID != "" {
+		s.TargetURL = workflowRunURL(s.ServerURL, s.CheckRequest.Owner, s.CheckRequest.Repo, runID)
+	}
 	baseBranch := os.Getenv(BaseRefEnvVar)
 	s.GitDiff = &gitdiff.GitDiff{
 		WorkDir:    workspacePath,
@@ -190,10 +243,14 @@ func (s *Service) LoadConfig(nightfallConfigFileName string) (*nightfallconfig.C
 			"with either a Condition Set UUID or at least one inline Condition enabled")
 		return nil, err
 	}
-	nightfallAPIKey, ok := os.LookupEnv(NightfallAPIKeyEnvVar)
-	if !ok || nightfallAPIKey == "" {
-		s.Logger.Error(fmt.Sprintf("Error getting Nightfall API key. Ensure you have %s set in the Github secrets of the repo", NightfallAPIKeyEnvVar))
-		return nil, errors.New("Missing env var for nightfall api key")
+	baseline, err := nightfallconfig.GetBaseline(workspacePath, nightfallconfig.BaselineFileName, s.Logger)
+	if err != nil {
+		s.Logger.Error(fmt.Sprintf("Error getting Nightfall baseline file. Recreate %s with the baseline create command", nightfallconfig.BaselineFileName))
+		return nil, err
+	}
+	nightfallAPIKey := os.Getenv(NightfallAPIKeyEnvVar)
+	if nightfallAPIKey == "" && nightfallConfig.UsesAPIEngine() {
+		s.Logger.Warning(fmt.Sprintf("Nightfall API key not found, falling back to the local detector engine. Ensure you have %s set in the Github secrets of the repo to scan with the Nightfall API", NightfallAPIKeyEnvVar))
 	}
 	return &nightfallconfig.Config{
 		NightfallAPIKey:             nightfallAPIKey,
@@ -205,6 +262,13 @@ func (s *Service) LoadConfig(nightfallConfigFileName string) (*nightfallconfig.C
 		FileExclusionList:           nightfallConfig.FileExclusionList,
 		DefaultRedactionConfig:      nightfallConfig.DefaultRedactionConfig,
 		AnnotationLevel:             nightfallConfig.AnnotationLevel,
+		Engine:                      nightfallConfig.Engine,
+		EntropyDetection:            nightfallConfig.EntropyDetection,
+		Baseline:                    baseline,
+		FailurePolicy:               nightfallConfig.FailurePolicy,
+		CacheDirectory:              nightfallConfig.CacheDirectory,
+		Engines:                     nightfallConfig.Engines,
+		CommandDetectors:            nightfallConfig.CommandDetectors,
 	}, nil
 }
 
@@ -226,15 +290,32 @@ func (s *Service) GetDiff() ([]*diffreviewer.FileDiff, error) {
 	return fileDiffs, nil
 }
 
-// WriteComments posts the findings as annotations to the github check
-func (s *Service) WriteComments(comments []*diffreviewer.Comment, level string) error {
+// WriteComments posts the findings as annotations to the github check, and lists the files
+// that could not be scanned in the check summary. Findings of a pull request are also
+// commented in a review when PullRequestReview is set, and summed up in a single comment
+// when PullRequestSummary is set. With CommitStatus, or when the check run cannot be
+// created, the findings are reported with a commit status instead.
+func (s *Service) WriteComments(comments []*diffreviewer.Comment, level string, failure *diffreviewer.ScanFailure) error {
 	s.Logger.Debug(fmt.Sprintf("Writing %d annotations to Github", len(comments)))
+	if s.PullRequestSummary && s.CheckRequest.PullRequest != 0 {
+		s.writePullRequestSummary(comments, level, failure)
+	}
+	if s.CommitStatus {
+		return s.writeCommitStatus(comments, level, failure)
+	}
 	checkRun, err := s.createCheckRun()
 	if err != nil {
-		s.Logger.Error("Error creating a Github check run")
-		return err
+		// tokens without the checks:write permission can still create commit statuses
+		s.Logger.Warning(fmt.Sprintf("Unable to create a Github check run, reporting with a commit status instead: %v", err))
+		return s.writeCommitStatus(comments, level, failure)
+	}
+	if s.PullRequestReview && s.CheckRequest.PullRequest != 0 {
+		err := s.writePullRequestReview(comments, level, failure)
+		if err != nil {
+			s.Logger.Warning(fmt.Sprintf("Unable to write the findings as a pull request review: %v", err))
+		}
 	}
-	if len(comments) == 0 {
+	if len(comments) == 0 && failure == nil {
 		err := s.updateSuccessfulCheckRun(checkRun.GetID())
 		if err != nil {
 			s.Logger.Error("Error updating check run to success")
@@ -243,18 +324,18 @@ func (s *Service) WriteComments(comments []*diffreviewer.Comment, level string)
 		return nil
 	}
 	annotations := createAnnotations(comments, level)
-	// Only set conclusion as failure if there is a failure annotation - see #72
-	conclusion := &checkRunConclusionNeutral
-	for _, a := range annotations {
-		if a.GetAnnotationLevel() == nightfallconfig.AnnotationLevelFailure {
-			conclusion = &checkRunConclusionFailure
-			break
-		}
-	}
+	conclusion := getConclusion(annotations, failure)
 	annotationLength := len(comments)
 	summaryNumFindings := fmt.Sprintf(summaryString, annotationLength)
+	if failure != nil {
+		s.Logger.Warning(failure.Summary())
+		summaryNumFindings += "\n\n" + failure.Summary()
+	}
 	// numIntermediateUpdateRequests contains the number of intermediate requests to be made prior to the final update request
 	numIntermediateUpdateRequests := int(math.Ceil(float64(len(comments))/MaxAnnotationsPerRequest)) - 1
+	if numIntermediateUpdateRequests < 0 {
+		numIntermediateUpdateRequests = 0
+	}
 	for i := 0; i < numIntermediateUpdateRequests; i++ {
 		startCommentIdx := i * MaxAnnotationsPerRequest
 		endCommentIdx := min(startCommentIdx+MaxAnnotationsPerRequest, len(comments))
@@ -306,6 +387,45 @@ func (s *Service) WriteComments(comments []*diffreviewer.Comment, level string)
 	return nil
 }
 
+// writePullRequestSummary edits the summary comment of the pull request, which is only created once there is something to report
+func (s *Service) writePullRequestSummary(comments []*diffreviewer.Comment, level string, failure *diffreviewer.ScanFailure) {
+	body := PullRequestSummary(
+		comments,
+		level,
+		s.CheckRequest.SHA,
+		BlobURL(s.ServerURL, s.CheckRequest.Owner, s.CheckRequest.Repo, s.CheckRequest.SHA),
+		failure,
+	)
+	err := WriteSummaryComment(
+		s.Client.IssuesService(),
+		s.CheckRequest.Owner,
+		s.CheckRequest.Repo,
+		s.CheckRequest.PullRequest,
+		body,
+		len(comments) > 0 || failure != nil,
+	)
+	if err != nil {
+		s.Logger.Warning(fmt.Sprintf("Unable to write the pull request summary comment: %v", err))
+	}
+}
+
+// getConclusion returns the check run conclusion for the annotations and the files that could not be scanned
+func getConclusion(annotations []*github.CheckRunAnnotation, failure *diffreviewer.ScanFailure) *string {
+	// Only set conclusion as failure if there is a failure annotation - see #72
+	for _, a := range annotations {
+		if a.GetAnnotationLevel() == nightfallconfig.AnnotationLevelFailure {
+			return &checkRunConclusionFailure
+		}
+	}
+	if failure.FailsRun() {
+		return &checkRunConclusionFailure
+	}
+	if len(annotations) > 0 || failure.Neutral() {
+		return &checkRunConclusionNeutral
+	}
+	return &checkRunConclusionSuccess
+}
+
 func (s *Service) updateSuccessfulCheckRun(checkRunID int64) error {
 	annotationLength := 0
 	successfulSummary := fmt.Sprintf(summaryString, annotationLength)
@@ -208,11 +208,13 @@ func (g *githubTestSuite) TestLoadConfig() {
 		NightfallMaxNumberRoutines: 20,
 		TokenExclusionList:         []string{excludedCreditCardRegex, excludedApiToken, excludedIPRegex},
 		FileInclusionList:          []string{"*"},
-		FileExclusionList:          []string{".nightfalldlp/config.json"},
+		FileExclusionList:          []string{".nightfalldlp/config.json", ".nightfalldlp/baseline.json"},
 		DefaultRedactionConfig: &nf.RedactionConfig{
 			SubstitutionConfig: &nf.SubstitutionConfig{SubstitutionPhrase: "REDACTED"},
 		},
 		AnnotationLevel: "warning",
+		Engine:          "api",
+		FailurePolicy:   "fail-closed",
 	}
 	expectedGithubCheckRequest := &CheckRequest{
 		Owner:       owner,
@@ -248,8 +250,10 @@ func (g *githubTestSuite) TestLoadConfigDetectionRuleUUID() {
 		NightfallMaxNumberRoutines:  20,
 		TokenExclusionList:          []string{excludedCreditCardRegex, excludedApiToken, excludedIPRegex},
 		FileInclusionList:           []string{"*"},
-		FileExclusionList:           []string{".nightfalldlp/config.json"},
+		FileExclusionList:           []string{".nightfalldlp/config.json", ".nightfalldlp/baseline.json"},
 		AnnotationLevel:             "failure",
+		Engine:                      "api",
+		FailurePolicy:               "fail-closed",
 	}
 	expectedGithubCheckRequest := &CheckRequest{
 		Owner:       owner,
@@ -318,6 +322,8 @@ func (g *githubTestSuite) TestLoadEmptyConfig() {
 			},
 		},
 		AnnotationLevel: "failure",
+		Engine:          "api",
+		FailurePolicy:   "fail-closed",
 	}
 	expectedGithubCheckRequest := &CheckRequest{
 		Owner:       owner,
@@ -546,11 +552,60 @@ func (g *githubTestSuite) TestWriteComments() {
 				lastUpdateOpt,
 			).Return(expectedLastUpdatedCheckRun, nil, nil)
 		}
-		err := tp.gc.WriteComments(tt.giveComments, tt.annotationLevel)
+		err := tp.gc.WriteComments(tt.giveComments, tt.annotationLevel, nil)
 		g.NoError(err, fmt.Sprintf("Error writing comments for %s test", tt.desc))
 	}
 }
 
+func (g *githubTestSuite) TestGetConclusion() {
+	failClosed := &diffreviewer.ScanFailure{Policy: nightfallconfig.FailurePolicyFailClosed, Files: []string{"big.json"}}
+	failOpen := &diffreviewer.ScanFailure{Policy: nightfallconfig.FailurePolicyFailOpen, Files: []string{"big.json"}}
+	neutral := &diffreviewer.ScanFailure{Policy: nightfallconfig.FailurePolicyNeutral, Files: []string{"big.json"}}
+	_, failureAnnotations := makeTestCommentsAndAnnotations("comment", "file.go", nightfallconfig.AnnotationLevelFailure, 1)
+	_, noticeAnnotations := makeTestCommentsAndAnnotations("comment", "file.go", nightfallconfig.AnnotationLevelNotice, 1)
+	tests := []struct {
+		giveAnnotations []*github.CheckRunAnnotation
+		giveFailure     *diffreviewer.ScanFailure
+		wantConclusion  string
+		desc            string
+	}{
+		{
+			giveAnnotations: failureAnnotations,
+			giveFailure:     neutral,
+			wantConclusion:  checkRunConclusionFailure,
+			desc:            "failure annotation",
+		},
+		{
+			giveAnnotations: nil,
+			giveFailure:     failClosed,
+			wantConclusion:  checkRunConclusionFailure,
+			desc:            "fail closed",
+		},
+		{
+			giveAnnotations: nil,
+			giveFailure:     failOpen,
+			wantConclusion:  checkRunConclusionSuccess,
+			desc:            "fail open",
+		},
+		{
+			giveAnnotations: nil,
+			giveFailure:     neutral,
+			wantConclusion:  checkRunConclusionNeutral,
+			desc:            "neutral",
+		},
+		{
+			giveAnnotations: noticeAnnotations,
+			giveFailure:     nil,
+			wantConclusion:  checkRunConclusionNeutral,
+			desc:            "notice annotation",
+		},
+	}
+	for _, tt := range tests {
+		conclusion := getConclusion(tt.giveAnnotations, tt.giveFailure)
+		g.Equal(tt.wantConclusion, *conclusion, fmt.Sprintf("invalid conclusion for %s test", tt.desc))
+	}
+}
+
 func makeTestCommentsAndAnnotations(body, filePath, annotationLevel string, size int) ([]*diffreviewer.Comment, []*github.CheckRunAnnotation) {
 	comments := make([]*diffreviewer.Comment, size)
 	annotations := make([]*github.CheckRunAnnotation, size)
@@ -0,0 +1,115 @@
+package github
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v33/github"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+	"github.com/nightfallai/nightfall_code_scanner/internal/interfaces/githubintf"
+)
+
+const (
+	// right side is reserved for additions and unchanged lines
+	// https://developer.github.com/v3/pulls/comments/#create-a-review-comment-for-a-pull-request
+	reviewCommentRightSide = "RIGHT"
+	// reviewEventComment submits a review without approving or requesting changes
+	reviewEventComment = "COMMENT"
+)
+
+// ListAllComments lists the comments of every page of the pull request comments
+func ListAllComments(pullRequests githubintf.GithubPullRequests, owner, repo string, number int) ([]*github.PullRequestComment, error) {
+	var comments []*github.PullRequestComment
+	opts := &github.PullRequestListCommentsOptions{}
+	for {
+		page, resp, err := pullRequests.ListComments(context.Background(), owner, repo, number, opts)
+		if err != nil {
+			return comments, err
+		}
+		comments = append(comments, page...)
+		if resp == nil || resp.NextPage == 0 {
+			return comments, nil
+		}
+		opts = &github.PullRequestListCommentsOptions{ListOptions: github.ListOptions{Page: resp.NextPage}}
+	}
+}
+
+// writePullRequestReview submits a single review commenting the findings not already commented on the pull request,
+// and resolves the review comments of previous runs whose finding is gone or moved. Comments on files that could not
+// be scanned are left as they are.
+func (s *Service) writePullRequestReview(comments []*diffreviewer.Comment, level string, failure *diffreviewer.ScanFailure) error {
+	pullRequests := s.Client.PullRequestsService()
+	existingComments, err := ListAllComments(pullRequests, s.CheckRequest.Owner, s.CheckRequest.Repo, s.CheckRequest.PullRequest)
+	if err != nil {
+		// without the existing comments none can be resolved, and findings may be commented twice
+		s.Logger.Error(fmt.Sprintf("Error listing existing pull request comments: %s", err.Error()))
+		existingComments = nil
+	}
+	var unscannedPaths []string
+	if failure != nil {
+		unscannedPaths = failure.Files
+	}
+	githubComments, edits := ReconcileComments(createPullRequestComments(comments, level), existingComments, s.CheckRequest.SHA, unscannedPaths)
+	for _, edit := range edits {
+		_, _, err := pullRequests.EditComment(
+			context.Background(),
+			s.CheckRequest.Owner,
+			s.CheckRequest.Repo,
+			edit.ID,
+			&github.PullRequestComment{Body: github.String(edit.Body)},
+		)
+		if err != nil {
+			s.Logger.Error(fmt.Sprintf("Error resolving pull request comment %d: %s", edit.ID, err.Error()))
+		}
+	}
+	if len(githubComments) == 0 {
+		if len(comments) > 0 {
+			s.Logger.Info("All findings are already commented on the pull request")
+		}
+		return nil
+	}
+	summary := fmt.Sprintf(summaryString, len(comments))
+	if failure != nil {
+		summary += "\n\n" + failure.Summary()
+	}
+	draftComments := make([]*github.DraftReviewComment, len(githubComments))
+	for i, c := range githubComments {
+		draftComments[i] = &github.DraftReviewComment{
+			Path: c.Path,
+			Body: c.Body,
+			Line: c.Line,
+			Side: c.Side,
+		}
+	}
+	_, _, err = pullRequests.CreateReview(
+		context.Background(),
+		s.CheckRequest.Owner,
+		s.CheckRequest.Repo,
+		s.CheckRequest.PullRequest,
+		&github.PullRequestReviewRequest{
+			CommitID: github.String(s.CheckRequest.SHA),
+			Body:     github.String(summary),
+			Event:    github.String(reviewEventComment),
+			Comments: draftComments,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create pull request review: %v", err)
+	}
+	return nil
+}
+
+// createPullRequestComments creates the review comments of the findings, marked with their fingerprint
+func createPullRequestComments(comments []*diffreviewer.Comment, level string) []*github.PullRequestComment {
+	githubComments := make([]*github.PullRequestComment, len(comments))
+	fingerprints := CommentFingerprints(comments)
+	for i, comment := range comments {
+		githubComments[i] = &github.PullRequestComment{
+			Body: github.String(WithFingerprint(fmt.Sprintf("%s: %s", level, comment.Body), fingerprints[i])),
+			Path: github.String(comment.FilePath),
+			Line: github.Int(comment.LineNumber),
+			Side: github.String(reviewCommentRightSide),
+		}
+	}
+	return githubComments
+}
@@ -0,0 +1,113 @@
+package github
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/v33/github"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+	"github.com/nightfallai/nightfall_code_scanner/internal/mocks/clients/githubchecks_mock"
+	"github.com/nightfallai/nightfall_code_scanner/internal/mocks/clients/githubclient_mock"
+	"github.com/nightfallai/nightfall_code_scanner/internal/mocks/clients/githubpullrequests_mock"
+)
+
+func (g *githubTestSuite) TestWriteCommentsPullRequestReview() {
+	tp := g.initTestParams()
+	defer tp.ctrl.Finish()
+	mockClient := githubclient_mock.NewGithubClient(tp.ctrl)
+	mockChecks := githubchecks_mock.NewGithubChecks(tp.ctrl)
+	mockPullRequests := githubpullrequests_mock.NewGithubPullRequests(tp.ctrl)
+	tp.gc = &Service{
+		Client:            mockClient,
+		CheckRequest:      testPRCheckRequest,
+		Logger:            log,
+		PullRequestReview: true,
+	}
+	comments, _ := makeTestCommentsAndAnnotations("testComment", "main.go", "warning", 2)
+	fingerprints := CommentFingerprints(comments)
+	// posted without a fingerprint marker by an earlier version
+	existingComments := []*github.PullRequestComment{
+		{
+			ID:   github.Int64(7),
+			Body: github.String("warning: testComment"),
+			Path: github.String("main.go"),
+			Line: github.Int(1),
+		},
+	}
+	wantReview := &github.PullRequestReviewRequest{
+		CommitID: github.String(testPRCheckRequest.SHA),
+		Body:     github.String(fmt.Sprintf(summaryString, 2)),
+		Event:    github.String("COMMENT"),
+		Comments: []*github.DraftReviewComment{
+			{
+				Path: github.String("main.go"),
+				Body: github.String(WithFingerprint("warning: testComment", fingerprints[1])),
+				Line: github.Int(2),
+				Side: github.String("RIGHT"),
+			},
+		},
+	}
+
+	mockClient.EXPECT().ChecksService().Return(mockChecks).Times(2)
+	mockChecks.EXPECT().CreateCheckRun(context.Background(), testPRCheckRequest.Owner, testPRCheckRequest.Repo, gomock.Any()).
+		Return(&github.CheckRun{ID: github.Int64(1)}, nil, nil)
+	mockChecks.EXPECT().UpdateCheckRun(context.Background(), testPRCheckRequest.Owner, testPRCheckRequest.Repo, int64(1), gomock.Any())
+	mockClient.EXPECT().PullRequestsService().Return(mockPullRequests)
+	mockPullRequests.EXPECT().ListComments(
+		context.Background(),
+		testPRCheckRequest.Owner,
+		testPRCheckRequest.Repo,
+		testPRCheckRequest.PullRequest,
+		&github.PullRequestListCommentsOptions{},
+	).Return(existingComments, nil, nil)
+	mockPullRequests.EXPECT().EditComment(
+		context.Background(),
+		testPRCheckRequest.Owner,
+		testPRCheckRequest.Repo,
+		int64(7),
+		&github.PullRequestComment{Body: github.String(WithFingerprint("warning: testComment", fingerprints[0]))},
+	).Return(&github.PullRequestComment{}, nil, nil)
+	mockPullRequests.EXPECT().CreateReview(
+		context.Background(),
+		testPRCheckRequest.Owner,
+		testPRCheckRequest.Repo,
+		testPRCheckRequest.PullRequest,
+		wantReview,
+	).Return(&github.PullRequestReview{}, nil, nil)
+
+	err := tp.gc.WriteComments(comments, "warning", nil)
+	g.NoError(err, "Error writing comments for pull request review test")
+}
+
+func (g *githubTestSuite) TestWriteCommentsPullRequestReviewAlreadyCommented() {
+	tp := g.initTestParams()
+	defer tp.ctrl.Finish()
+	mockClient := githubclient_mock.NewGithubClient(tp.ctrl)
+	mockChecks := githubchecks_mock.NewGithubChecks(tp.ctrl)
+	mockPullRequests := githubpullrequests_mock.NewGithubPullRequests(tp.ctrl)
+	tp.gc = &Service{
+		Client:            mockClient,
+		CheckRequest:      testPRCheckRequest,
+		Logger:            log,
+		PullRequestReview: true,
+	}
+	comments := []*diffreviewer.Comment{{Title: "title", Body: "testComment", FilePath: "main.go", LineNumber: 1}}
+	existingComments := createPullRequestComments(comments, "warning")
+
+	mockClient.EXPECT().ChecksService().Return(mockChecks).Times(2)
+	mockChecks.EXPECT().CreateCheckRun(context.Background(), testPRCheckRequest.Owner, testPRCheckRequest.Repo, gomock.Any()).
+		Return(&github.CheckRun{ID: github.Int64(1)}, nil, nil)
+	mockChecks.EXPECT().UpdateCheckRun(context.Background(), testPRCheckRequest.Owner, testPRCheckRequest.Repo, int64(1), gomock.Any())
+	mockClient.EXPECT().PullRequestsService().Return(mockPullRequests)
+	mockPullRequests.EXPECT().ListComments(
+		context.Background(),
+		testPRCheckRequest.Owner,
+		testPRCheckRequest.Repo,
+		testPRCheckRequest.PullRequest,
+		&github.PullRequestListCommentsOptions{},
+	).Return(existingComments, nil, nil)
+
+	err := tp.gc.WriteComments(comments, "warning", nil)
+	g.NoError(err, "Error writing comments for already commented test")
+}
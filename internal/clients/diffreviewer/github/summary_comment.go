@@ -0,0 +1,251 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v33/github"
+	nf "github.com/nightfallai/nightfall-go-sdk"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+	"github.com/nightfallai/nightfall_code_scanner/internal/interfaces/githubintf"
+)
+
+const (
+	// DefaultServerURL is the web url of github.com, used when no enterprise url is set
+	DefaultServerURL = "https://github.com"
+
+	summaryCommentMarker  = "<!-- nightfalldlp:summary -->"
+	summaryCommentTitle   = "### Nightfall DLP"
+	summaryFindingsString = "Nightfall DLP has found %d potentially sensitive items in %d files at %s"
+	unknownConfidence     = "UNKNOWN"
+	// maxSummaryLines is the number of lines linked in a row of the findings table
+	maxSummaryLines = 10
+	// maxSummaryLength leaves room below the 65536 characters github accepts in a comment body
+	maxSummaryLength = 60000
+)
+
+// confidenceOrder lists the confidence levels from the most to the least likely
+var confidenceOrder = []string{
+	string(nf.ConfidenceVeryLikely),
+	string(nf.ConfidenceLikely),
+	string(nf.ConfidencePossible),
+	string(nf.ConfidenceUnlikely),
+	string(nf.ConfidenceVeryUnlikely),
+}
+
+type summaryRow struct {
+	Path     string
+	Detector string
+	Lines    []int
+	Count    int
+}
+
+// ServerURL returns the web url of the github server from the enterprise base url of the API, if any
+func ServerURL(baseUrl string) string {
+	if baseUrl == "" {
+		return DefaultServerURL
+	}
+	serverURL := strings.TrimSuffix(baseUrl, "/")
+	return strings.TrimSuffix(serverURL, "/api/v3")
+}
+
+// BlobURL returns the web url of the files of the repository at commit sha
+func BlobURL(serverURL, owner, repo, sha string) string {
+	return fmt.Sprintf("%s/%s/%s/blob/%s", strings.TrimSuffix(serverURL, "/"), owner, repo, sha)
+}
+
+// PullRequestSummary renders the body of the summary comment of a pull request: the counts of findings by
+// confidence, and a table of the findings grouped by file and detector linking to their lines at blobURL
+func PullRequestSummary(comments []*diffreviewer.Comment, level, sha, blobURL string, failure *diffreviewer.ScanFailure) string {
+	rows := summaryRows(comments)
+	files := make(map[string]bool, len(rows))
+	for _, r := range rows {
+		files[r.Path] = true
+	}
+
+	var b strings.Builder
+	b.WriteString(summaryCommentMarker + "\n" + summaryCommentTitle + "\n\n")
+	b.WriteString(fmt.Sprintf(summaryFindingsString, len(comments), len(files), shortSHA(sha)))
+	if len(comments) > 0 {
+		b.WriteString(fmt.Sprintf(" (%s)", level))
+	}
+	b.WriteString("\n")
+	if failure != nil {
+		b.WriteString("\n" + failure.Summary() + "\n")
+	}
+	if len(comments) == 0 {
+		return b.String()
+	}
+
+	b.WriteString("\n| Confidence | Findings |\n| --- | --- |\n")
+	counts := confidenceCounts(comments)
+	for _, confidence := range sortedConfidences(counts) {
+		b.WriteString(fmt.Sprintf("| %s | %d |\n", confidence, counts[confidence]))
+	}
+
+	b.WriteString("\n| File | Detector | Findings | Lines |\n| --- | --- | --- | --- |\n")
+	for i, r := range rows {
+		row := fmt.Sprintf("| %s | %s | %d | %s |\n", escapeCell(r.Path), escapeCell(r.Detector), r.Count, escapeCell(lineLinks(r, blobURL)))
+		if b.Len()+len(row) > maxSummaryLength {
+			b.WriteString(fmt.Sprintf("\n%d more rows are not listed\n", len(rows)-i))
+			break
+		}
+		b.WriteString(row)
+	}
+	return b.String()
+}
+
+// WriteSummaryComment edits the summary comment of the pull request in place, or creates it if
+// createComment is set and the pull request has none yet
+func WriteSummaryComment(issues githubintf.GithubIssues, owner, repo string, number int, body string, createComment bool) error {
+	existing, err := findSummaryComment(issues, owner, repo, number)
+	if err != nil {
+		return fmt.Errorf("failed to list pull request comments: %v", err)
+	}
+	if existing != nil {
+		if existing.GetBody() == body {
+			return nil
+		}
+		_, _, err := issues.EditComment(context.Background(), owner, repo, existing.GetID(), &github.IssueComment{Body: github.String(body)})
+		if err != nil {
+			return fmt.Errorf("failed to edit summary comment: %v", err)
+		}
+		return nil
+	}
+	if !createComment {
+		return nil
+	}
+	_, _, err = issues.CreateComment(context.Background(), owner, repo, number, &github.IssueComment{Body: github.String(body)})
+	if err != nil {
+		return fmt.Errorf("failed to create summary comment: %v", err)
+	}
+	return nil
+}
+
+// findSummaryComment returns the summary comment posted by a previous run, nil if there is none
+func findSummaryComment(issues githubintf.GithubIssues, owner, repo string, number int) (*github.IssueComment, error) {
+	opts := &github.IssueListCommentsOptions{}
+	for {
+		comments, resp, err := issues.ListComments(context.Background(), owner, repo, number, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range comments {
+			if strings.HasPrefix(c.GetBody(), summaryCommentMarker) {
+				return c, nil
+			}
+		}
+		if resp == nil || resp.NextPage == 0 {
+			return nil, nil
+		}
+		opts = &github.IssueListCommentsOptions{ListOptions: github.ListOptions{Page: resp.NextPage}}
+	}
+}
+
+// summaryRows groups the comments by file and detector, sorted by path then detector
+func summaryRows(comments []*diffreviewer.Comment) []*summaryRow {
+	type rowKey struct {
+		Path     string
+		Detector string
+	}
+	rowsByKey := make(map[rowKey]*summaryRow)
+	rows := make([]*summaryRow, 0)
+	for _, c := range comments {
+		key := rowKey{Path: strings.TrimPrefix(c.FilePath, "/"), Detector: c.DetectorName}
+		r, ok := rowsByKey[key]
+		if !ok {
+			r = &summaryRow{Path: key.Path, Detector: key.Detector}
+			rowsByKey[key] = r
+			rows = append(rows, r)
+		}
+		r.Count++
+		r.Lines = append(r.Lines, c.LineNumber)
+	}
+	for _, r := range rows {
+		sort.Ints(r.Lines)
+		r.Lines = uniqueLines(r.Lines)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Path != rows[j].Path {
+			return rows[i].Path < rows[j].Path
+		}
+		return rows[i].Detector < rows[j].Detector
+	})
+	return rows
+}
+
+func uniqueLines(lines []int) []int {
+	unique := lines[:0]
+	for i, l := range lines {
+		if i == 0 || l != lines[i-1] {
+			unique = append(unique, l)
+		}
+	}
+	return unique
+}
+
+func lineLinks(r *summaryRow, blobURL string) string {
+	fileURL := blobURL + "/" + (&url.URL{Path: r.Path}).EscapedPath()
+	links := make([]string, 0, maxSummaryLines+1)
+	for i, l := range r.Lines {
+		if i == maxSummaryLines {
+			links = append(links, fmt.Sprintf("%d more", len(r.Lines)-maxSummaryLines))
+			break
+		}
+		links = append(links, fmt.Sprintf("[%d](%s#L%d)", l, fileURL, l))
+	}
+	return strings.Join(links, ", ")
+}
+
+func confidenceCounts(comments []*diffreviewer.Comment) map[string]int {
+	counts := make(map[string]int)
+	for _, c := range comments {
+		confidence := unknownConfidence
+		if c.Finding != nil && c.Finding.Confidence != "" {
+			confidence = c.Finding.Confidence
+		}
+		counts[confidence]++
+	}
+	return counts
+}
+
+// sortedConfidences sorts the confidence levels from the most to the least likely, unknown levels last
+func sortedConfidences(counts map[string]int) []string {
+	confidences := make([]string, 0, len(counts))
+	for _, confidence := range confidenceOrder {
+		if _, ok := counts[confidence]; ok {
+			confidences = append(confidences, confidence)
+		}
+	}
+	others := make([]string, 0)
+	for confidence := range counts {
+		if !containsString(confidenceOrder, confidence) {
+			others = append(others, confidence)
+		}
+	}
+	sort.Strings(others)
+	return append(confidences, others...)
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func escapeCell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,169 @@
+package github
+
+import (
+	"context"
+	"errors"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-github/v33/github"
+	nf "github.com/nightfallai/nightfall-go-sdk"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+	"github.com/nightfallai/nightfall_code_scanner/internal/mocks/clients/githubchecks_mock"
+	"github.com/nightfallai/nightfall_code_scanner/internal/mocks/clients/githubclient_mock"
+	"github.com/nightfallai/nightfall_code_scanner/internal/mocks/clients/githubissues_mock"
+)
+
+func (g *githubTestSuite) TestServerURL() {
+	tests := []struct {
+		have string
+		want string
+		desc string
+	}{
+		{
+			have: "",
+			want: "https://github.com",
+			desc: "github.com",
+		},
+		{
+			have: "https://github.example.com",
+			want: "https://github.example.com",
+			desc: "enterprise url",
+		},
+		{
+			have: "https://github.example.com/api/v3/",
+			want: "https://github.example.com",
+			desc: "enterprise api url",
+		},
+	}
+	for _, tt := range tests {
+		g.Equal(tt.want, ServerURL(tt.have), tt.desc)
+	}
+}
+
+func (g *githubTestSuite) TestPullRequestSummary() {
+	comment := func(path, detector, confidence string, line int) *diffreviewer.Comment {
+		return &diffreviewer.Comment{
+			Body:         "finding",
+			FilePath:     path,
+			LineNumber:   line,
+			DetectorName: detector,
+			Finding:      &nf.Finding{Confidence: confidence},
+		}
+	}
+	comments := []*diffreviewer.Comment{
+		comment("main.go", "API Key", "POSSIBLE", 9),
+		comment("main.go", "API Key", "VERY_LIKELY", 3),
+		comment("main.go", "API Key", "VERY_LIKELY", 3),
+		comment("a|b.txt", "Email", "LIKELY", 1),
+	}
+	blobURL := BlobURL("https://github.com", "alan20854", "TestRepo", "7b46da6e4d3259b1a1c470ee468e2cb3d9733802")
+	want := `<!-- nightfalldlp:summary -->
+### Nightfall DLP
+
+Nightfall DLP has found 4 potentially sensitive items in 2 files at 7b46da6 (failure)
+
+| Confidence | Findings |
+| --- | --- |
+| VERY_LIKELY | 2 |
+| LIKELY | 1 |
+| POSSIBLE | 1 |
+
+| File | Detector | Findings | Lines |
+| --- | --- | --- | --- |
+| a\|b.txt | Email | 1 | [1](https://github.com/alan20854/TestRepo/blob/7b46da6e4d3259b1a1c470ee468e2cb3d9733802/a%7Cb.txt#L1) |
+| main.go | API Key | 3 | [3](https://github.com/alan20854/TestRepo/blob/7b46da6e4d3259b1a1c470ee468e2cb3d9733802/main.go#L3), [9](https://github.com/alan20854/TestRepo/blob/7b46da6e4d3259b1a1c470ee468e2cb3d9733802/main.go#L9) |
+`
+	g.Equal(want, PullRequestSummary(comments, "failure", "7b46da6e4d3259b1a1c470ee468e2cb3d9733802", blobURL, nil), "invalid summary")
+
+	wantEmpty := `<!-- nightfalldlp:summary -->
+### Nightfall DLP
+
+Nightfall DLP has found 0 potentially sensitive items in 0 files at 7b46da6
+`
+	g.Equal(wantEmpty, PullRequestSummary(nil, "failure", "7b46da6e4d3259b1a1c470ee468e2cb3d9733802", blobURL, nil), "invalid summary without findings")
+}
+
+func (g *githubTestSuite) TestWriteSummaryComment() {
+	tp := g.initTestParams()
+	defer tp.ctrl.Finish()
+	owner, repo, number := "alan20854", "TestRepo", 3
+	body := summaryCommentMarker + "\nnew"
+	otherComment := &github.IssueComment{ID: github.Int64(1), Body: github.String("looks good to me")}
+	summaryComment := &github.IssueComment{ID: github.Int64(2), Body: github.String(summaryCommentMarker + "\nold")}
+
+	tests := []struct {
+		haveComments  []*github.IssueComment
+		createComment bool
+		wantEdit      bool
+		wantCreate    bool
+		desc          string
+	}{
+		{
+			haveComments:  []*github.IssueComment{otherComment, summaryComment},
+			createComment: false,
+			wantEdit:      true,
+			desc:          "edit existing summary",
+		},
+		{
+			haveComments:  []*github.IssueComment{otherComment},
+			createComment: true,
+			wantCreate:    true,
+			desc:          "create summary",
+		},
+		{
+			haveComments:  []*github.IssueComment{otherComment},
+			createComment: false,
+			desc:          "nothing to report",
+		},
+		{
+			haveComments:  []*github.IssueComment{{ID: github.Int64(2), Body: github.String(body)}},
+			createComment: true,
+			desc:          "summary unchanged",
+		},
+	}
+	for _, tt := range tests {
+		mockIssues := githubissues_mock.NewGithubIssues(tp.ctrl)
+		mockIssues.EXPECT().ListComments(context.Background(), owner, repo, number, &github.IssueListCommentsOptions{}).
+			Return(tt.haveComments, &github.Response{}, nil)
+		if tt.wantEdit {
+			mockIssues.EXPECT().EditComment(context.Background(), owner, repo, int64(2), &github.IssueComment{Body: github.String(body)})
+		}
+		if tt.wantCreate {
+			mockIssues.EXPECT().CreateComment(context.Background(), owner, repo, number, &github.IssueComment{Body: github.String(body)})
+		}
+		err := WriteSummaryComment(mockIssues, owner, repo, number, body, tt.createComment)
+		g.NoError(err, tt.desc)
+	}
+}
+
+func (g *githubTestSuite) TestWriteCommentsPullRequestSummary() {
+	tp := g.initTestParams()
+	defer tp.ctrl.Finish()
+	mockClient := githubclient_mock.NewGithubClient(tp.ctrl)
+	mockChecks := githubchecks_mock.NewGithubChecks(tp.ctrl)
+	mockIssues := githubissues_mock.NewGithubIssues(tp.ctrl)
+	tp.gc = &Service{
+		Client:             mockClient,
+		CheckRequest:       testPRCheckRequest,
+		Logger:             log,
+		PullRequestSummary: true,
+		ServerURL:          DefaultServerURL,
+	}
+	comments, _ := makeTestCommentsAndAnnotations("testComment", "main.go", "warning", 2)
+
+	mockClient.EXPECT().IssuesService().Return(mockIssues)
+	mockIssues.EXPECT().ListComments(
+		context.Background(),
+		testPRCheckRequest.Owner,
+		testPRCheckRequest.Repo,
+		testPRCheckRequest.PullRequest,
+		&github.IssueListCommentsOptions{},
+	).Return(nil, nil, errors.New("listing failed"))
+	mockClient.EXPECT().ChecksService().Return(mockChecks).Times(2)
+	mockChecks.EXPECT().CreateCheckRun(context.Background(), testPRCheckRequest.Owner, testPRCheckRequest.Repo, gomock.Any()).
+		Return(&github.CheckRun{ID: github.Int64(1)}, nil, nil)
+	mockChecks.EXPECT().UpdateCheckRun(context.Background(), testPRCheckRequest.Owner, testPRCheckRequest.Repo, int64(1), gomock.Any())
+
+	err := tp.gc.WriteComments(comments, "warning", nil)
+	g.NoError(err, "a failing summary comment should not fail the check")
+}
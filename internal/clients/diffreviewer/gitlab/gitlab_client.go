@@ -0,0 +1,161 @@
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	defaultBaseURL   = "https://gitlab.com/api/v4"
+	privateTokenKey  = "PRIVATE-TOKEN"
+	nextPageHeader   = "X-Next-Page"
+	maxItemsPerPage  = 100
+	positionTypeText = "text"
+)
+
+// Client is a minimal client for the GitLab REST API (v4)
+type Client struct {
+	BaseURL    string
+	Token      string
+	HTTPClient *http.Client
+}
+
+// MergeRequestVersion is a single diff version of a merge request
+// https://docs.gitlab.com/ee/api/merge_requests.html#get-mr-diff-versions
+type MergeRequestVersion struct {
+	ID             int64  `json:"id"`
+	HeadCommitSHA  string `json:"head_commit_sha"`
+	BaseCommitSHA  string `json:"base_commit_sha"`
+	StartCommitSHA string `json:"start_commit_sha"`
+}
+
+// Position anchors a discussion to a line of a merge request diff
+// https://docs.gitlab.com/ee/api/discussions.html#create-a-new-thread-in-the-merge-request-diff
+type Position struct {
+	PositionType string `json:"position_type"`
+	BaseSHA      string `json:"base_sha"`
+	StartSHA     string `json:"start_sha"`
+	HeadSHA      string `json:"head_sha"`
+	OldPath      string `json:"old_path,omitempty"`
+	NewPath      string `json:"new_path"`
+	NewLine      int    `json:"new_line,omitempty"`
+}
+
+// Note is a single comment within a discussion
+type Note struct {
+	ID       int64     `json:"id"`
+	Body     string    `json:"body"`
+	Position *Position `json:"position,omitempty"`
+}
+
+// Discussion is a merge request discussion thread
+type Discussion struct {
+	ID    string  `json:"id"`
+	Notes []*Note `json:"notes"`
+}
+
+// CreateDiscussionOptions are the parameters used to open a new merge request discussion
+type CreateDiscussionOptions struct {
+	Body     string    `json:"body"`
+	Position *Position `json:"position,omitempty"`
+}
+
+// NewAuthenticatedClient generates a GitLab client authenticated with a personal or project access token
+func NewAuthenticatedClient(token string, baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		Token:      token,
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// GetMergeRequestVersions lists the diff versions of a merge request, most recent first
+func (c *Client) GetMergeRequestVersions(ctx context.Context, projectID string, mrIID int) ([]*MergeRequestVersion, error) {
+	var versions []*MergeRequestVersion
+	err := c.do(ctx, http.MethodGet, c.mergeRequestPath(projectID, mrIID, "versions"), nil, &versions)
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// ListMergeRequestDiscussions lists every discussion on a merge request, following pagination
+func (c *Client) ListMergeRequestDiscussions(ctx context.Context, projectID string, mrIID int) ([]*Discussion, error) {
+	discussions := make([]*Discussion, 0)
+	page := "1"
+	for page != "" {
+		path := fmt.Sprintf("%s?per_page=%d&page=%s", c.mergeRequestPath(projectID, mrIID, "discussions"), maxItemsPerPage, page)
+		var pageDiscussions []*Discussion
+		resp, err := c.doWithResponse(ctx, http.MethodGet, path, nil, &pageDiscussions)
+		if err != nil {
+			return nil, err
+		}
+		discussions = append(discussions, pageDiscussions...)
+		page = resp.Header.Get(nextPageHeader)
+	}
+	return discussions, nil
+}
+
+// CreateMergeRequestDiscussion opens a new discussion thread on a merge request
+func (c *Client) CreateMergeRequestDiscussion(ctx context.Context, projectID string, mrIID int, opts *CreateDiscussionOptions) (*Discussion, error) {
+	var discussion Discussion
+	err := c.do(ctx, http.MethodPost, c.mergeRequestPath(projectID, mrIID, "discussions"), opts, &discussion)
+	if err != nil {
+		return nil, err
+	}
+	return &discussion, nil
+}
+
+func (c *Client) mergeRequestPath(projectID string, mrIID int, resource string) string {
+	return fmt.Sprintf("/projects/%s/merge_requests/%d/%s", url.PathEscape(projectID), mrIID, resource)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, v interface{}) error {
+	_, err := c.doWithResponse(ctx, method, path, body, v)
+	return err
+}
+
+func (c *Client) doWithResponse(ctx context.Context, method, path string, body, v interface{}) (*http.Response, error) {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, c.BaseURL+path, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set(privateTokenKey, c.Token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return resp, fmt.Errorf("gitlab api %s %s returned %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if v != nil {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			return resp, err
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,295 @@
+package gitlab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer/diffutils"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/gitdiff"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/logger"
+	gitlablogger "github.com/nightfallai/nightfall_code_scanner/internal/clients/logger/gitlab_logger"
+	"github.com/nightfallai/nightfall_code_scanner/internal/interfaces/gitdiffintf"
+	"github.com/nightfallai/nightfall_code_scanner/internal/nightfallconfig"
+)
+
+// Predefined GitLab CI variables https://docs.gitlab.com/ee/ci/variables/predefined_variables.html
+const (
+	WorkspacePathEnvVar            = "CI_PROJECT_DIR"
+	NightfallAPIKeyEnvVar          = "NIGHTFALL_API_KEY"
+	ProjectIDEnvVar                = "CI_PROJECT_ID"
+	CommitShaEnvVar                = "CI_COMMIT_SHA"
+	CommitBeforeShaEnvVar          = "CI_COMMIT_BEFORE_SHA"
+	MergeRequestIIDEnvVar          = "CI_MERGE_REQUEST_IID"
+	MergeRequestTargetBranchEnvVar = "CI_MERGE_REQUEST_TARGET_BRANCH_NAME"
+)
+
+// devNullPath is the old path of the files added by the diff
+const devNullPath = "/dev/null"
+
+var errSensitiveItemsFound = errors.New("potentially sensitive items found")
+
+// Service contains the GitLab client that makes GitLab api calls
+type Service struct {
+	Client    *Client
+	Logger    logger.Logger
+	GitDiff   gitdiffintf.GitDiff
+	MrDetails mrDetails
+	// OldPaths maps the new path of every file renamed in the diff to its old path
+	OldPaths map[string]string
+}
+
+type mrDetails struct {
+	ProjectID string
+	CommitSha string
+	MrIID     *int
+}
+
+// NewGitlabService creates a new GitLab CI service
+func NewGitlabService() diffreviewer.DiffReviewer {
+	return &Service{
+		Logger: gitlablogger.NewDefaultGitlabLogger(),
+	}
+}
+
+// NewGitlabServiceWithMergeRequestComments creates a new GitLab CI service with an authenticated GitLab client
+func NewGitlabServiceWithMergeRequestComments(token, baseURL string) diffreviewer.DiffReviewer {
+	return &Service{
+		Client: NewAuthenticatedClient(token, baseURL),
+		Logger: gitlablogger.NewDefaultGitlabLogger(),
+	}
+}
+
+// GetLogger gets the GitLab service logger
+func (s *Service) GetLogger() logger.Logger {
+	return s.Logger
+}
+
+// LoadConfig gets all config values from files or environment and creates a config
+func (s *Service) LoadConfig(nightfallConfigFileName string) (*nightfallconfig.Config, error) {
+	s.Logger.Info("Loading configuration")
+	workspacePath, ok := os.LookupEnv(WorkspacePathEnvVar)
+	if !ok || workspacePath == "" {
+		s.Logger.Error(fmt.Sprintf("Environment variable %s cannot be found", WorkspacePathEnvVar))
+		return nil, errors.New("missing env var for workspace path")
+	}
+	mrDetails, err := s.getMrDetails()
+	if err != nil {
+		return nil, err
+	}
+	s.MrDetails = *mrDetails
+	beforeCommitSha, _ := os.LookupEnv(CommitBeforeShaEnvVar)
+	targetBranch, _ := os.LookupEnv(MergeRequestTargetBranchEnvVar)
+	s.GitDiff = &gitdiff.GitDiff{
+		WorkDir:    workspacePath,
+		BaseBranch: targetBranch,
+		BaseSHA:    beforeCommitSha,
+		Head:       s.MrDetails.CommitSha,
+	}
+	nightfallConfig, err := nightfallconfig.GetNightfallConfigFile(workspacePath, nightfallConfigFileName, s.Logger)
+	if err != nil {
+		s.Logger.Error("Error getting Nightfall config file. " +
+			"Ensure you have a Nightfall config file located in the root of your repository at .nightfalldlp/config.json " +
+			"with either a Condition Set UUID or at least one Condition enabled")
+		return nil, err
+	}
+	baseline, err := nightfallconfig.GetBaseline(workspacePath, nightfallconfig.BaselineFileName, s.Logger)
+	if err != nil {
+		s.Logger.Error(fmt.Sprintf("Error getting Nightfall baseline file. Recreate %s with the baseline create command", nightfallconfig.BaselineFileName))
+		return nil, err
+	}
+	nightfallAPIKey := os.Getenv(NightfallAPIKeyEnvVar)
+	if nightfallAPIKey == "" && nightfallConfig.UsesAPIEngine() {
+		s.Logger.Warning(fmt.Sprintf("Nightfall API key not found, falling back to the local detector engine. Ensure you have %s set in the CI/CD variables of the project to scan with the Nightfall API", NightfallAPIKeyEnvVar))
+	}
+	return &nightfallconfig.Config{
+		NightfallAPIKey:             nightfallAPIKey,
+		NightfallDetectionRuleUUIDs: nightfallConfig.DetectionRuleUUIDs,
+		NightfallDetectionRules:     nightfallConfig.DetectionRules,
+		NightfallMaxNumberRoutines:  nightfallConfig.MaxNumberRoutines,
+		TokenExclusionList:          nightfallConfig.TokenExclusionList,
+		FileInclusionList:           nightfallConfig.FileInclusionList,
+		FileExclusionList:           nightfallConfig.FileExclusionList,
+		DefaultRedactionConfig:      nightfallConfig.DefaultRedactionConfig,
+		AnnotationLevel:             nightfallConfig.AnnotationLevel,
+		Engine:                      nightfallConfig.Engine,
+		EntropyDetection:            nightfallConfig.EntropyDetection,
+		Baseline:                    baseline,
+		FailurePolicy:               nightfallConfig.FailurePolicy,
+		CacheDirectory:              nightfallConfig.CacheDirectory,
+		Engines:                     nightfallConfig.Engines,
+		CommandDetectors:            nightfallConfig.CommandDetectors,
+	}, nil
+}
+
+func (s *Service) getMrDetails() (*mrDetails, error) {
+	commitSha, ok := os.LookupEnv(CommitShaEnvVar)
+	if !ok || commitSha == "" {
+		s.Logger.Error(fmt.Sprintf("Environment variable %s cannot be found", CommitShaEnvVar))
+		return nil, errors.New("missing env var for commit sha")
+	}
+	projectID, ok := os.LookupEnv(ProjectIDEnvVar)
+	if !ok || projectID == "" {
+		s.Logger.Error(fmt.Sprintf("Environment variable %s cannot be found", ProjectIDEnvVar))
+		return nil, errors.New("missing env var for project id")
+	}
+	var mrIID *int
+	mrIIDStr, ok := os.LookupEnv(MergeRequestIIDEnvVar)
+	if ok && mrIIDStr != "" {
+		iid, err := strconv.Atoi(mrIIDStr)
+		if err != nil {
+			s.Logger.Error(fmt.Sprintf("Environment variable %s has an invalid format: %s", MergeRequestIIDEnvVar, mrIIDStr))
+			return nil, errors.New("invalid format of merge request iid env var")
+		}
+		mrIID = &iid
+	}
+	return &mrDetails{
+		ProjectID: projectID,
+		CommitSha: commitSha,
+		MrIID:     mrIID,
+	}, nil
+}
+
+// GetDiff retrieves the file diff from the requested merge request
+func (s *Service) GetDiff() ([]*diffreviewer.FileDiff, error) {
+	s.Logger.Info("Getting diff from GitLab")
+	content, err := s.GitDiff.GetDiff()
+	if err != nil {
+		s.Logger.Error(fmt.Sprintf("Error getting the raw diff from GitLab: %v", err))
+		return nil, err
+	}
+
+	fileDiffs, err := diffutils.ParseMultiFile(strings.NewReader(content))
+	if err != nil {
+		s.Logger.Error("Error parsing the raw diff from GitLab")
+		return nil, err
+	}
+	fileDiffs = diffutils.FilterFileDiffs(fileDiffs)
+	s.OldPaths = make(map[string]string)
+	for _, fd := range fileDiffs {
+		if fd.PathOld != devNullPath && fd.PathOld != fd.PathNew {
+			s.OldPaths[fd.PathNew] = fd.PathOld
+		}
+	}
+	return fileDiffs, nil
+}
+
+// WriteComments logs the findings and unscanned files to the job output and posts the findings as merge request discussions
+func (s *Service) WriteComments(comments []*diffreviewer.Comment, level string, failure *diffreviewer.ScanFailure) error {
+	returnErr := diffreviewer.LogScanFailure(s.Logger, failure)
+	if len(comments) == 0 {
+		s.Logger.Info("no sensitive items found")
+		return returnErr
+	}
+	s.logCommentsToGitlab(comments, level)
+	if returnErr == nil && level == nightfallconfig.AnnotationLevelFailure {
+		returnErr = errSensitiveItemsFound
+	}
+	if s.Client == nil || s.MrDetails.MrIID == nil {
+		return returnErr
+	}
+
+	ctx := context.Background()
+	versions, err := s.Client.GetMergeRequestVersions(ctx, s.MrDetails.ProjectID, *s.MrDetails.MrIID)
+	if err != nil || len(versions) == 0 {
+		s.Logger.Error(fmt.Sprintf("Error getting merge request diff versions: %v", err))
+		return returnErr
+	}
+	existingDiscussions, err := s.Client.ListMergeRequestDiscussions(ctx, s.MrDetails.ProjectID, *s.MrDetails.MrIID)
+	if err != nil {
+		s.Logger.Error(fmt.Sprintf("Error listing existing merge request discussions: %s", err.Error()))
+	}
+	discussions := s.createDiscussions(comments, level, versions[0])
+	filteredDiscussions := filterExistingDiscussions(discussions, existingDiscussions)
+	for _, d := range filteredDiscussions {
+		_, err := s.Client.CreateMergeRequestDiscussion(ctx, s.MrDetails.ProjectID, *s.MrDetails.MrIID, d)
+		if err != nil {
+			s.Logger.Error(fmt.Sprintf("Error writing discussion to merge request: %s", err.Error()))
+		}
+	}
+	// returning error to fail the GitLab job
+	return returnErr
+}
+
+func (s *Service) logCommentsToGitlab(comments []*diffreviewer.Comment, level string) {
+	for _, comment := range comments {
+		logString := fmt.Sprintf(
+			"%s at %s on line %d",
+			comment.Body,
+			comment.FilePath,
+			comment.LineNumber,
+		)
+		switch level {
+		case nightfallconfig.AnnotationLevelFailure:
+			s.Logger.Error(logString)
+		case nightfallconfig.AnnotationLevelWarning:
+			s.Logger.Warning(logString)
+		case nightfallconfig.AnnotationLevelNotice:
+			s.Logger.Info(logString)
+		default:
+			s.Logger.Error(logString)
+		}
+	}
+}
+
+func (s *Service) createDiscussions(comments []*diffreviewer.Comment, level string, version *MergeRequestVersion) []*CreateDiscussionOptions {
+	discussions := make([]*CreateDiscussionOptions, len(comments))
+	for i, comment := range comments {
+		oldPath, ok := s.OldPaths[comment.FilePath]
+		if !ok {
+			oldPath = comment.FilePath
+		}
+		discussions[i] = &CreateDiscussionOptions{
+			Body: fmt.Sprintf("%s: %s", level, comment.Body),
+			Position: &Position{
+				PositionType: positionTypeText,
+				BaseSHA:      version.BaseCommitSHA,
+				StartSHA:     version.StartCommitSHA,
+				HeadSHA:      version.HeadCommitSHA,
+				OldPath:      oldPath,
+				NewPath:      comment.FilePath,
+				NewLine:      comment.LineNumber,
+			},
+		}
+	}
+	return discussions
+}
+
+type mrNote struct {
+	Body string
+	Path string
+	Line int
+}
+
+func filterExistingDiscussions(discussions []*CreateDiscussionOptions, existingDiscussions []*Discussion) []*CreateDiscussionOptions {
+	existingNotesMap := make(map[mrNote]bool)
+	for _, ed := range existingDiscussions {
+		for _, n := range ed.Notes {
+			if n.Position == nil {
+				continue
+			}
+			note := mrNote{
+				Body: n.Body,
+				Path: n.Position.NewPath,
+				Line: n.Position.NewLine,
+			}
+			existingNotesMap[note] = true
+		}
+	}
+	filteredDiscussions := make([]*CreateDiscussionOptions, 0, len(discussions))
+	for _, d := range discussions {
+		note := mrNote{
+			Body: d.Body,
+			Path: d.Position.NewPath,
+			Line: d.Position.NewLine,
+		}
+		if _, ok := existingNotesMap[note]; !ok {
+			filteredDiscussions = append(filteredDiscussions, d)
+		}
+	}
+	return filteredDiscussions
+}
@@ -0,0 +1,361 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	nf "github.com/nightfallai/nightfall-go-sdk"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+	gitlablogger "github.com/nightfallai/nightfall_code_scanner/internal/clients/logger/gitlab_logger"
+	"github.com/nightfallai/nightfall_code_scanner/internal/mocks/clients/gitdiff_mock"
+	loggermock "github.com/nightfallai/nightfall_code_scanner/internal/mocks/logger"
+	"github.com/nightfallai/nightfall_code_scanner/internal/nightfallconfig"
+	"github.com/stretchr/testify/suite"
+)
+
+const expectedDiffResponseStr = `diff --git a/blah.txt b/blah.txt
+new file mode 100644
+index 0000000..e9ea42a
+--- /dev/null
++++ b/blah.txt
+@@ -0,0 +1 @@
++this is a text file`
+
+var expectedFileDiffs = []*diffreviewer.FileDiff{{
+	PathOld: "/dev/null",
+	PathNew: "blah.txt",
+	Hunks: []*diffreviewer.Hunk{{
+		StartLineOld:  0,
+		LineLengthOld: 0,
+		StartLineNew:  1,
+		LineLengthNew: 1,
+		Lines: []*diffreviewer.Line{{
+			Type:     diffreviewer.LineAdded,
+			Content:  "this is a text file",
+			LnumDiff: 1,
+			LnumOld:  0,
+			LnumNew:  1,
+		}},
+	}},
+	Extended: []string{"diff --git a/blah.txt b/blah.txt", "new file mode 100644", "index 0000000..e9ea42a"},
+}}
+
+const commitSha = "7b46da6e4d3259b1a1c470ee468e2cb3d9733802"
+const baseSha = "15bf9548d16caff9f398b5aae78a611fc60d55bd"
+const startSha = "3c1e9b1e4f1b8c4f3b4a2d6c9e8f7a6b5c4d3e2f"
+const testProjectID = "4242"
+const testMrIID = 7
+const testTargetBranch = "main"
+const testConfigFileName = "nightfall_test_config.json"
+const excludedCreditCardRegex = "4242-4242-4242-[0-9]{4}"
+const excludedApiToken = "xG0Ct4Wsu3OTcJnE1dFLAQfRgL6b8tIv"
+const excludedIPRegex = "^127\\."
+
+var envVars = []string{
+	WorkspacePathEnvVar,
+	ProjectIDEnvVar,
+	CommitShaEnvVar,
+	CommitBeforeShaEnvVar,
+	MergeRequestIIDEnvVar,
+	MergeRequestTargetBranchEnvVar,
+	NightfallAPIKeyEnvVar,
+}
+
+type gitlabTestSuite struct {
+	suite.Suite
+}
+
+func (g *gitlabTestSuite) AfterTest(_, _ string) {
+	for _, e := range envVars {
+		err := os.Unsetenv(e)
+		g.NoErrorf(err, "Error unsetting var %s", e)
+	}
+}
+
+func (g *gitlabTestSuite) setEnv() {
+	workspace, err := os.Getwd()
+	g.NoError(err, "Error getting workspace")
+	_ = os.Setenv(WorkspacePathEnvVar, path.Join(workspace, "../../../../test/data"))
+	_ = os.Setenv(ProjectIDEnvVar, testProjectID)
+	_ = os.Setenv(CommitShaEnvVar, commitSha)
+	_ = os.Setenv(MergeRequestIIDEnvVar, fmt.Sprint(testMrIID))
+	_ = os.Setenv(MergeRequestTargetBranchEnvVar, testTargetBranch)
+}
+
+func (g *gitlabTestSuite) TestLoadConfig() {
+	g.setEnv()
+	apiKey := "api-key"
+	_ = os.Setenv(NightfallAPIKeyEnvVar, apiKey)
+	s := &Service{Logger: gitlablogger.NewDefaultGitlabLogger()}
+
+	expectedNightfallConfig := &nightfallconfig.Config{
+		NightfallAPIKey: apiKey,
+		NightfallDetectionRules: []nf.DetectionRule{
+			{
+				Name: "my detection rule",
+				Detectors: []nf.Detector{
+					{
+						MinNumFindings:    1,
+						MinConfidence:     nf.ConfidencePossible,
+						DetectorType:      nf.DetectorTypeNightfallDetector,
+						DisplayName:       "cc",
+						NightfallDetector: "CREDIT_CARD_NUMBER",
+					},
+					{
+						MinNumFindings:    1,
+						MinConfidence:     nf.ConfidencePossible,
+						DetectorType:      nf.DetectorTypeNightfallDetector,
+						DisplayName:       "phone",
+						NightfallDetector: "PHONE_NUMBER",
+					},
+					{
+						MinNumFindings:    1,
+						MinConfidence:     nf.ConfidenceLikely,
+						DetectorType:      nf.DetectorTypeNightfallDetector,
+						DisplayName:       "ip",
+						NightfallDetector: "IP_ADDRESS",
+					},
+				},
+				LogicalOp: nf.LogicalOpAny,
+			},
+		},
+		NightfallMaxNumberRoutines: 20,
+		TokenExclusionList:         []string{excludedCreditCardRegex, excludedApiToken, excludedIPRegex},
+		FileInclusionList:          []string{"*"},
+		FileExclusionList:          []string{".nightfalldlp/config.json", ".nightfalldlp/baseline.json"},
+		DefaultRedactionConfig: &nf.RedactionConfig{
+			SubstitutionConfig: &nf.SubstitutionConfig{SubstitutionPhrase: "REDACTED"},
+		},
+		AnnotationLevel: "warning",
+		Engine:          "api",
+		FailurePolicy:   "fail-closed",
+	}
+	mrIID := testMrIID
+	expectedMrDetails := mrDetails{
+		ProjectID: testProjectID,
+		CommitSha: commitSha,
+		MrIID:     &mrIID,
+	}
+
+	nightfallConfig, err := s.LoadConfig(testConfigFileName)
+	g.NoError(err, "Unexpected error in LoadConfig")
+	g.Equal(expectedNightfallConfig, nightfallConfig, "Incorrect nightfall config")
+	g.Equal(expectedMrDetails, s.MrDetails, "Incorrect merge request details")
+}
+
+func (g *gitlabTestSuite) TestLoadConfigMissingApiKey() {
+	g.setEnv()
+	s := &Service{Logger: gitlablogger.NewDefaultGitlabLogger()}
+
+	nightfallConfig, err := s.LoadConfig(testConfigFileName)
+	g.NoError(err, "missing api key should fall back to the local detector engine")
+	g.Empty(nightfallConfig.NightfallAPIKey, "unexpected api key")
+}
+
+func (g *gitlabTestSuite) TestLoadConfigInvalidMrIID() {
+	g.setEnv()
+	_ = os.Setenv(MergeRequestIIDEnvVar, "not-a-number")
+	s := &Service{Logger: gitlablogger.NewDefaultGitlabLogger()}
+
+	_, err := s.LoadConfig(testConfigFileName)
+	g.EqualError(err, "invalid format of merge request iid env var", "incorrect error from invalid mr iid test")
+}
+
+func (g *gitlabTestSuite) TestGetDiff() {
+	ctrl := gomock.NewController(g.T())
+	defer ctrl.Finish()
+	mockGitDiff := gitdiff_mock.NewGitDiff(ctrl)
+	s := &Service{
+		Logger:  gitlablogger.NewDefaultGitlabLogger(),
+		GitDiff: mockGitDiff,
+	}
+
+	mockGitDiff.EXPECT().GetDiff().Return(expectedDiffResponseStr, nil)
+
+	fileDiffs, err := s.GetDiff()
+	g.NoError(err, "unexpected error in GetDiff")
+	g.Equal(expectedFileDiffs, fileDiffs, "invalid fileDiff return value")
+}
+
+func (g *gitlabTestSuite) TestCreateDiscussionsRenamedFile() {
+	ctrl := gomock.NewController(g.T())
+	defer ctrl.Finish()
+	mockGitDiff := gitdiff_mock.NewGitDiff(ctrl)
+	s := &Service{
+		Logger:  gitlablogger.NewDefaultGitlabLogger(),
+		GitDiff: mockGitDiff,
+	}
+	renamedDiff := `diff --git a/old.txt b/new.txt
+similarity index 90%
+rename from old.txt
+rename to new.txt
+index e9ea42a..f2c3b4d 100644
+--- a/old.txt
++++ b/new.txt
+@@ -1 +1,2 @@
+ this is a text file
++4242-4242-4242-4242
+` + expectedDiffResponseStr
+	version := &MergeRequestVersion{HeadCommitSHA: commitSha, BaseCommitSHA: baseSha, StartCommitSHA: startSha}
+	comments := []*diffreviewer.Comment{
+		{Title: "title", Body: "renamed", FilePath: "new.txt", LineNumber: 2},
+		{Title: "title", Body: "added", FilePath: "blah.txt", LineNumber: 1},
+	}
+
+	mockGitDiff.EXPECT().GetDiff().Return(renamedDiff, nil)
+
+	_, err := s.GetDiff()
+	g.NoError(err, "unexpected error in GetDiff")
+	discussions := s.createDiscussions(comments, nightfallconfig.AnnotationLevelFailure, version)
+	if g.Len(discussions, 2, "there should be a discussion for every comment") {
+		g.Equal("old.txt", discussions[0].Position.OldPath, "renamed file should keep its old path")
+		g.Equal("new.txt", discussions[0].Position.NewPath, "incorrect new path")
+		g.Equal("blah.txt", discussions[1].Position.OldPath, "added file should use its new path")
+	}
+}
+
+func (g *gitlabTestSuite) TestWriteMergeRequestDiscussions() {
+	ctrl := gomock.NewController(g.T())
+	defer ctrl.Finish()
+	mockLogger := loggermock.NewLogger(ctrl)
+
+	comments := []*diffreviewer.Comment{
+		{Title: "title", Body: "existing", FilePath: "a.txt", LineNumber: 1},
+		{Title: "title", Body: "new", FilePath: "a.txt", LineNumber: 2},
+	}
+	existing := []*Discussion{{
+		ID: "abc",
+		Notes: []*Note{{
+			ID:       1,
+			Body:     "failure: existing",
+			Position: &Position{NewPath: "a.txt", NewLine: 1},
+		}},
+	}}
+	expectedCreated := []*CreateDiscussionOptions{{
+		Body: "failure: new",
+		Position: &Position{
+			PositionType: positionTypeText,
+			BaseSHA:      baseSha,
+			StartSHA:     startSha,
+			HeadSHA:      commitSha,
+			OldPath:      "a.txt",
+			NewPath:      "a.txt",
+			NewLine:      2,
+		},
+	}}
+
+	created := make([]*CreateDiscussionOptions, 0)
+	discussionsPath := fmt.Sprintf("/projects/%s/merge_requests/%d/discussions", testProjectID, testMrIID)
+	versionsPath := fmt.Sprintf("/projects/%s/merge_requests/%d/versions", testProjectID, testMrIID)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Equal("token", r.Header.Get(privateTokenKey), "missing token header")
+		switch {
+		case r.URL.Path == versionsPath:
+			_ = json.NewEncoder(w).Encode([]*MergeRequestVersion{{
+				ID:             1,
+				HeadCommitSHA:  commitSha,
+				BaseCommitSHA:  baseSha,
+				StartCommitSHA: startSha,
+			}})
+		case r.URL.Path == discussionsPath && r.Method == http.MethodGet:
+			_ = json.NewEncoder(w).Encode(existing)
+		case r.URL.Path == discussionsPath && r.Method == http.MethodPost:
+			var d CreateDiscussionOptions
+			g.NoError(json.NewDecoder(r.Body).Decode(&d), "invalid discussion body")
+			created = append(created, &d)
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(&Discussion{ID: "def"})
+		default:
+			g.Failf("unexpected request", "%s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	mrIID := testMrIID
+	s := &Service{
+		Client: NewAuthenticatedClient("token", server.URL),
+		Logger: mockLogger,
+		MrDetails: mrDetails{
+			ProjectID: testProjectID,
+			CommitSha: commitSha,
+			MrIID:     &mrIID,
+		},
+	}
+	for _, c := range comments {
+		mockLogger.EXPECT().Error(fmt.Sprintf("%s at %s on line %d", c.Body, c.FilePath, c.LineNumber))
+	}
+
+	err := s.WriteComments(comments, nightfallconfig.AnnotationLevelFailure, nil)
+	g.EqualError(err, errSensitiveItemsFound.Error(), "invalid error writing comments")
+	g.Equal(expectedCreated, created, "invalid discussions created")
+}
+
+func (g *gitlabTestSuite) TestWriteCommentsWithoutMergeRequest() {
+	ctrl := gomock.NewController(g.T())
+	defer ctrl.Finish()
+	mockLogger := loggermock.NewLogger(ctrl)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.Failf("unexpected request", "%s %s", r.Method, r.URL.Path)
+	}))
+	defer server.Close()
+	s := &Service{
+		Client: NewAuthenticatedClient("token", server.URL),
+		Logger: mockLogger,
+		MrDetails: mrDetails{
+			ProjectID: testProjectID,
+			CommitSha: commitSha,
+		},
+	}
+
+	tests := []struct {
+		giveComments []*diffreviewer.Comment
+		desc         string
+	}{
+		{
+			giveComments: []*diffreviewer.Comment{{Body: "testComment", FilePath: "a.txt", LineNumber: 3}},
+			desc:         "single comment test",
+		},
+		{
+			giveComments: []*diffreviewer.Comment{},
+			desc:         "no comments test",
+		},
+	}
+
+	for _, tt := range tests {
+		if len(tt.giveComments) == 0 {
+			mockLogger.EXPECT().Info("no sensitive items found")
+		}
+		for _, c := range tt.giveComments {
+			mockLogger.EXPECT().Warning(fmt.Sprintf("%s at %s on line %d", c.Body, c.FilePath, c.LineNumber))
+		}
+		err := s.WriteComments(tt.giveComments, nightfallconfig.AnnotationLevelWarning, nil)
+		g.NoError(err, fmt.Sprintf("unexpected error writing comments for %s", tt.desc))
+	}
+}
+
+func (g *gitlabTestSuite) TestListMergeRequestDiscussionsPagination() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		if page == "1" {
+			w.Header().Set(nextPageHeader, "2")
+		}
+		_ = json.NewEncoder(w).Encode([]*Discussion{{ID: page}})
+	}))
+	defer server.Close()
+	client := NewAuthenticatedClient("token", server.URL)
+
+	discussions, err := client.ListMergeRequestDiscussions(context.Background(), testProjectID, testMrIID)
+	g.NoError(err, "unexpected error listing discussions")
+	g.Equal([]*Discussion{{ID: "1"}, {ID: "2"}}, discussions, "invalid paginated discussions")
+}
+
+func TestGitlabClient(t *testing.T) {
+	suite.Run(t, new(gitlabTestSuite))
+}
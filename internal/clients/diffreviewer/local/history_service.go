@@ -0,0 +1,113 @@
+package local
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer/diffutils"
+	locallogger "github.com/nightfallai/nightfall_code_scanner/internal/clients/logger/local_logger"
+)
+
+const (
+	// commitSeparator starts the header of every commit in the git log output, only at the start of a line
+	// as the lines of a diff always start with their prefix
+	commitSeparator = "\x1e"
+	// commitFieldSeparator separates the SHA, author and date in the commit header
+	commitFieldSeparator = "\x1f"
+	commitFormat         = "--format=%x1e%H%x1f%an%x1f%aI"
+
+	historyFindingString = "%s:%d: %s: %s (commit %s by %s on %s)\n"
+	shortSHALength       = 12
+)
+
+// commitSHARegex matches the full SHA-1 or SHA-256 object name of a commit
+var commitSHARegex = regexp.MustCompile(`^([0-9a-f]{40}|[0-9a-f]{64})$`)
+
+// NewHistoryService creates a local service scanning the lines added by every commit of the range base..head
+// of the repository at repoPath, or by every commit reachable from head if base is empty
+func NewHistoryService(repoPath, base, head string) diffreviewer.DiffReviewer {
+	return &Service{
+		Logger:   locallogger.NewDefaultLocalLogger(),
+		Out:      os.Stdout,
+		Color:    locallogger.UseColor(os.Stdout),
+		RepoPath: repoPath,
+		Base:     base,
+		Head:     head,
+		History:  true,
+	}
+}
+
+// historyRange returns the revision range passed to git log
+func (s *Service) historyRange() string {
+	if s.Base == "" {
+		return s.Head
+	}
+	return fmt.Sprintf("%s..%s", s.Base, s.Head)
+}
+
+// getHistoryFileDiffs returns the diff of every file changed by each commit of the range,
+// oldest commit first, with the commit the diff was taken from
+func (s *Service) getHistoryFileDiffs() ([]*diffreviewer.FileDiff, error) {
+	revisionRange := s.historyRange()
+	s.Logger.Info(fmt.Sprintf("Getting history of %s", revisionRange))
+	out, err := runGit(s.RepoPath, "log", "-p", "--reverse", "--no-color", "--no-ext-diff", "-M", commitFormat, revisionRange, "--")
+	if err != nil {
+		s.Logger.Error(fmt.Sprintf("Error getting the history of %s: %v", revisionRange, err))
+		return nil, err
+	}
+
+	fileDiffs := make([]*diffreviewer.FileDiff, 0)
+	numCommits := 0
+	for _, entry := range strings.Split("\n"+out, "\n"+commitSeparator) {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		commit, diff, err := parseCommitEntry(entry)
+		if err != nil {
+			s.Logger.Error(fmt.Sprintf("Error parsing the history of %s", revisionRange))
+			return nil, err
+		}
+		numCommits++
+		commitFileDiffs, err := diffutils.ParseMultiFile(strings.NewReader(diff))
+		if err != nil {
+			s.Logger.Error(fmt.Sprintf("Error parsing the diff of commit %s", commit.SHA))
+			return nil, err
+		}
+		for _, fd := range diffutils.FilterFileDiffs(commitFileDiffs) {
+			fd.Commit = commit
+			fileDiffs = append(fileDiffs, fd)
+		}
+	}
+	s.Logger.Info(fmt.Sprintf("Scanning %d file changes in %d commits", len(fileDiffs), numCommits))
+	return fileDiffs, nil
+}
+
+// parseCommitEntry splits a commit of the git log output into its header and its diff
+func parseCommitEntry(entry string) (*diffreviewer.Commit, string, error) {
+	header := entry
+	diff := ""
+	if i := strings.Index(entry, "\n"); i >= 0 {
+		header = entry[:i]
+		diff = strings.TrimLeft(entry[i+1:], "\n")
+	}
+	fields := strings.Split(header, commitFieldSeparator)
+	if len(fields) != 3 || !commitSHARegex.MatchString(fields[0]) {
+		return nil, "", fmt.Errorf("invalid commit header %q", header)
+	}
+	return &diffreviewer.Commit{
+		SHA:    fields[0],
+		Author: fields[1],
+		Date:   fields[2],
+	}, diff, nil
+}
+
+// shortSHA abbreviates a commit SHA for display
+func shortSHA(sha string) string {
+	if len(sha) > shortSHALength {
+		return sha[:shortSHALength]
+	}
+	return sha
+}
@@ -0,0 +1,148 @@
+package local
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func commitFile(t *testing.T, repoPath, author, name, content string) string {
+	path := filepath.Join(repoPath, name)
+	if content == "" {
+		require.NoError(t, os.Remove(path))
+	} else {
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+	for _, args := range [][]string{
+		{"add", "-A"},
+		{"-c", "user.name=" + author, "-c", "user.email=test@example.com", "commit", "-q", "-m", "update " + name},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoPath
+		require.NoError(t, cmd.Run(), "Error running git %v", args)
+	}
+	sha, err := runGit(repoPath, "rev-parse", "HEAD")
+	require.NoError(t, err)
+	return sha
+}
+
+func TestHistoryServiceGetDiff(t *testing.T) {
+	repoPath := initTestRepo(t)
+	defer os.RemoveAll(repoPath)
+	base, err := runGit(repoPath, "rev-parse", "HEAD")
+	require.NoError(t, err)
+	addSHA := commitFile(t, repoPath, "Jane Doe", "main.go", "package main\n\nconst key = \"secret\"\n")
+	commitFile(t, repoPath, "John Doe", "main.go", "")
+
+	tests := []struct {
+		haveBase string
+		wantSHAs []string
+		desc     string
+	}{
+		{
+			haveBase: base,
+			wantSHAs: []string{addSHA},
+			desc:     "range test",
+		},
+		{
+			haveBase: "",
+			wantSHAs: []string{addSHA},
+			desc:     "whole history test",
+		},
+		{
+			haveBase: addSHA,
+			wantSHAs: []string{},
+			desc:     "deletion only test",
+		},
+	}
+	for _, tt := range tests {
+		s := NewHistoryService(repoPath, tt.haveBase, "HEAD").(*Service)
+		s.Logger = lLogger
+		_, err := s.LoadConfig(testConfigFileName)
+		require.NoError(t, err, "unexpected error in LoadConfig for %s", tt.desc)
+
+		fileDiffs, err := s.GetDiff()
+		require.NoError(t, err, "unexpected error in GetDiff for %s", tt.desc)
+		shas := make([]string, len(fileDiffs))
+		for i, fd := range fileDiffs {
+			shas[i] = fd.Commit.SHA
+		}
+		assert.Equal(t, tt.wantSHAs, shas, "invalid commits for %s", tt.desc)
+	}
+
+	s := NewHistoryService(repoPath, "", "HEAD").(*Service)
+	s.Logger = lLogger
+	_, err = s.LoadConfig(testConfigFileName)
+	require.NoError(t, err, "unexpected error in LoadConfig")
+	fileDiffs, err := s.GetDiff()
+	require.NoError(t, err, "unexpected error in GetDiff")
+	require.Len(t, fileDiffs, 1, "only the commit adding lines should be scanned")
+	fd := fileDiffs[0]
+	assert.Equal(t, "main.go", fd.PathNew, "invalid file path")
+	assert.Equal(t, "Jane Doe", fd.Commit.Author, "the finding should be attributed to the author of the commit")
+	assert.NotEmpty(t, fd.Commit.Date, "the commit date should be set")
+	assert.Equal(t, []*diffreviewer.Line{
+		{Type: diffreviewer.LineAdded, Content: "package main", LnumDiff: 1, LnumNew: 1},
+		{Type: diffreviewer.LineAdded, Content: `const key = "secret"`, LnumDiff: 3, LnumNew: 3},
+	}, fd.Hunks[0].Lines, "invalid added lines")
+}
+
+func TestHistoryServiceSeparatorInContent(t *testing.T) {
+	repoPath := initTestRepo(t)
+	defer os.RemoveAll(repoPath)
+	sha := commitFile(t, repoPath, "Jane\x1eDoe", "main.go", "package main\n\x1e9fceb02d0ae598e95dc970b74767f19372d61af8\x1fa\x1fb\n")
+
+	s := NewHistoryService(repoPath, "", "HEAD").(*Service)
+	s.Logger = lLogger
+	_, err := s.LoadConfig(testConfigFileName)
+	require.NoError(t, err, "unexpected error in LoadConfig")
+	fileDiffs, err := s.GetDiff()
+	require.NoError(t, err, "unexpected error in GetDiff")
+	require.Len(t, fileDiffs, 1, "the separator in the content should not start a commit")
+	fd := fileDiffs[0]
+	assert.Equal(t, sha, fd.Commit.SHA, "invalid commit")
+	assert.Equal(t, "Jane\x1eDoe", fd.Commit.Author, "invalid author")
+	assert.Equal(t, []*diffreviewer.Line{
+		{Type: diffreviewer.LineAdded, Content: "package main", LnumDiff: 1, LnumNew: 1},
+		{Type: diffreviewer.LineAdded, Content: "\x1e9fceb02d0ae598e95dc970b74767f19372d61af8\x1fa\x1fb", LnumDiff: 2, LnumNew: 2},
+	}, fd.Hunks[0].Lines, "invalid added lines")
+}
+
+func TestParseCommitEntry(t *testing.T) {
+	tests := []struct {
+		haveEntry string
+		desc      string
+	}{
+		{
+			haveEntry: "9fceb02d0ae598e95dc970b74767f19372d61af8\n",
+			desc:      "a header without author and date should be rejected",
+		},
+		{
+			haveEntry: "Doe\x1f2021-01-01T00:00:00+00:00\x1f2021-01-01T00:00:00+00:00\n",
+			desc:      "a header without a commit SHA should be rejected",
+		},
+		{
+			haveEntry: "9fceb02d\x1fJane Doe\x1f2021-01-01T00:00:00+00:00\n",
+			desc:      "a header with an abbreviated commit SHA should be rejected",
+		},
+	}
+	for _, tt := range tests {
+		_, _, err := parseCommitEntry(tt.haveEntry)
+		assert.Error(t, err, tt.desc)
+	}
+
+	commit, diff, err := parseCommitEntry("9fceb02d0ae598e95dc970b74767f19372d61af8\x1fJane Doe\x1f2021-01-01T00:00:00+00:00\n\ndiff --git a/a b/a\n")
+	assert.NoError(t, err, "unexpected error parsing a valid commit entry")
+	assert.Equal(t, &diffreviewer.Commit{
+		SHA:    "9fceb02d0ae598e95dc970b74767f19372d61af8",
+		Author: "Jane Doe",
+		Date:   "2021-01-01T00:00:00+00:00",
+	}, commit, "invalid commit")
+	assert.Equal(t, "diff --git a/a b/a\n", diff, "invalid diff")
+}
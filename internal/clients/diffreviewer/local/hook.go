@@ -0,0 +1,106 @@
+package local
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+)
+
+// Supported git hooks
+const (
+	PreCommitHook = "pre-commit"
+	PrePushHook   = "pre-push"
+
+	// upstreamRev is the remote tracking branch of the current branch
+	upstreamRev = "@{u}"
+	// defaultBranchRev is used to find the outgoing commits of a branch without an upstream
+	defaultBranchRev = "origin/HEAD"
+
+	hookMarker   = "# installed by nightfalldlp install-hook"
+	hookTemplate = "#!/bin/sh\n" + hookMarker + "\nexec %s --hook %s --repo-path \"$(git rev-parse --show-toplevel)\"\n"
+)
+
+// NewHookService creates a new local service scanning the changes seen by the given git hook
+func NewHookService(repoPath, hook string) (diffreviewer.DiffReviewer, error) {
+	if err := validateHook(hook); err != nil {
+		return nil, err
+	}
+	s := NewLocalService(repoPath, "", "HEAD").(*Service)
+	s.Hook = hook
+	return s, nil
+}
+
+// InstallHook writes a hook script running nightfalldlp into the hooks directory
+// of the repository at repoPath. Hooks not written by InstallHook are never overwritten.
+func InstallHook(repoPath, hook string) (string, error) {
+	if err := validateHook(hook); err != nil {
+		return "", err
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	// git resolves core.hooksPath and linked worktrees for us
+	hooksDir, err := runGit(repoPath, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(hooksDir) {
+		hooksDir = filepath.Join(repoPath, hooksDir)
+	}
+	hookPath := filepath.Join(hooksDir, hook)
+	existing, err := ioutil.ReadFile(hookPath)
+	if err == nil && !bytes.Contains(existing, []byte(hookMarker)) {
+		return "", fmt.Errorf("%s already exists, remove it or call nightfalldlp from it", hookPath)
+	}
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		return "", err
+	}
+	script := fmt.Sprintf(hookTemplate, shellQuote(executable), hook)
+	if err := ioutil.WriteFile(hookPath, []byte(script), 0755); err != nil {
+		return "", err
+	}
+	return hookPath, nil
+}
+
+// shellQuote quotes s as a single word for /bin/sh, closing and reopening the quotes around each single quote
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func validateHook(hook string) error {
+	switch hook {
+	case PreCommitHook, PrePushHook:
+		return nil
+	default:
+		return fmt.Errorf("unsupported git hook %q, expected %s or %s", hook, PreCommitHook, PrePushHook)
+	}
+}
+
+// outgoingBase finds the commit the outgoing changes of HEAD are based on,
+// preferring the upstream of the current branch
+func outgoingBase(repoPath string) (string, error) {
+	for _, rev := range []string{upstreamRev, defaultBranchRev} {
+		base, err := runGit(repoPath, "merge-base", rev, "HEAD")
+		if err == nil {
+			return base, nil
+		}
+	}
+	return "", fmt.Errorf("unable to find %s or %s to compare outgoing commits against", upstreamRev, defaultBranchRev)
+}
+
+func runGit(repoPath string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repoPath
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
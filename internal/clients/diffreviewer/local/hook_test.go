@@ -0,0 +1,132 @@
+package local
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/gitdiff"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func initTestRepo(t *testing.T) string {
+	repoPath, err := ioutil.TempDir("", "nightfalldlp-hook")
+	require.NoError(t, err, "Error creating temp dir")
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "init"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoPath
+		require.NoError(t, cmd.Run(), "Error running git %v", args)
+	}
+	return repoPath
+}
+
+func TestInstallHook(t *testing.T) {
+	repoPath := initTestRepo(t)
+	defer os.RemoveAll(repoPath)
+
+	hookPath, err := InstallHook(repoPath, PrePushHook)
+	require.NoError(t, err, "unexpected error installing hook")
+	assert.Equal(t, filepath.Join(repoPath, ".git", "hooks", PrePushHook), hookPath, "invalid hook path")
+	script, err := ioutil.ReadFile(hookPath)
+	require.NoError(t, err, "error reading hook")
+	assert.Contains(t, string(script), hookMarker, "hook is missing marker")
+	assert.Contains(t, string(script), "--hook pre-push", "hook does not scan pre-push changes")
+	info, err := os.Stat(hookPath)
+	require.NoError(t, err, "error reading hook info")
+	assert.NotZero(t, info.Mode()&0100, "hook is not executable")
+
+	_, err = InstallHook(repoPath, PrePushHook)
+	assert.NoError(t, err, "reinstalling a nightfalldlp hook should succeed")
+}
+
+func TestInstallHookExisting(t *testing.T) {
+	repoPath := initTestRepo(t)
+	defer os.RemoveAll(repoPath)
+	hookPath := filepath.Join(repoPath, ".git", "hooks", PreCommitHook)
+	require.NoError(t, ioutil.WriteFile(hookPath, []byte("#!/bin/sh\nmake lint\n"), 0755))
+
+	_, err := InstallHook(repoPath, PreCommitHook)
+	assert.Error(t, err, "existing hooks should not be overwritten")
+	script, _ := ioutil.ReadFile(hookPath)
+	assert.Equal(t, "#!/bin/sh\nmake lint\n", string(script), "existing hook was modified")
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		have string
+		desc string
+	}{
+		{have: "/usr/local/bin/nightfalldlp", desc: "plain path"},
+		{have: "/home/o'brien/go/bin/nightfalldlp", desc: "single quote"},
+		{have: "/tmp/$HOME/`id`/\\\"nightfall dlp\"", desc: "shell metacharacters"},
+	}
+	for _, tt := range tests {
+		out, err := exec.Command("/bin/sh", "-c", "printf %s "+shellQuote(tt.have)).Output()
+		require.NoError(t, err, "error running shell for %s test", tt.desc)
+		assert.Equal(t, tt.have, string(out), "invalid quoting for %s test", tt.desc)
+	}
+}
+
+func TestInstallHookUnsupported(t *testing.T) {
+	_, err := InstallHook(".", "post-commit")
+	assert.EqualError(t, err, `unsupported git hook "post-commit", expected pre-commit or pre-push`)
+}
+
+func TestHookServiceLoadConfig(t *testing.T) {
+	repoPath := initTestRepo(t)
+	defer os.RemoveAll(repoPath)
+	require.NoError(t, os.Setenv(NightfallAPIKeyEnvVar, "api-key"))
+	defer os.Unsetenv(NightfallAPIKeyEnvVar)
+	testData, err := filepath.Abs("../../../../test/data")
+	require.NoError(t, err)
+	headSha, err := runGit(repoPath, "rev-parse", "HEAD")
+	require.NoError(t, err)
+
+	tests := []struct {
+		hook        string
+		repoPath    string
+		wantGitDiff *gitdiff.GitDiff
+		wantErr     bool
+		desc        string
+	}{
+		{
+			hook:        PreCommitHook,
+			repoPath:    testData,
+			wantGitDiff: &gitdiff.GitDiff{WorkDir: testData, Staged: true},
+			desc:        "pre-commit test",
+		},
+		{
+			hook:     PrePushHook,
+			repoPath: repoPath,
+			wantErr:  true,
+			desc:     "pre-push without upstream test",
+		},
+	}
+
+	for _, tt := range tests {
+		s, err := NewHookService(tt.repoPath, tt.hook)
+		require.NoError(t, err, "unexpected error for %s", tt.desc)
+		s.(*Service).Logger = lLogger
+		_, err = s.LoadConfig(testConfigFileName)
+		if tt.wantErr {
+			assert.Error(t, err, "expected error for %s", tt.desc)
+			continue
+		}
+		assert.NoError(t, err, "unexpected error for %s", tt.desc)
+		assert.Equal(t, tt.wantGitDiff, s.(*Service).GitDiff, "invalid git diff for %s", tt.desc)
+	}
+
+	// with an origin/HEAD the outgoing commits are compared against its merge base
+	cmd := exec.Command("git", "update-ref", "refs/remotes/origin/HEAD", "HEAD")
+	cmd.Dir = repoPath
+	require.NoError(t, cmd.Run())
+	base, err := outgoingBase(repoPath)
+	assert.NoError(t, err, "unexpected error finding outgoing base")
+	assert.Equal(t, headSha, base, "invalid outgoing base")
+}
@@ -0,0 +1,209 @@
+package local
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer/diffutils"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/gitdiff"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/logger"
+	locallogger "github.com/nightfallai/nightfall_code_scanner/internal/clients/logger/local_logger"
+	"github.com/nightfallai/nightfall_code_scanner/internal/interfaces/gitdiffintf"
+	"github.com/nightfallai/nightfall_code_scanner/internal/nightfallconfig"
+)
+
+// NightfallAPIKeyEnvVar is the only value read from the environment in local mode
+const NightfallAPIKeyEnvVar = "NIGHTFALL_API_KEY"
+
+// EmptyTree is the hash git uses for the empty tree, a range starting at it includes every file
+const EmptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+const (
+	findingString = "%s:%d: %s: %s\n"
+	summaryString = "Nightfall DLP has found %d potentially sensitive items\n"
+)
+
+var errSensitiveItemsFound = errors.New("potentially sensitive items found")
+
+// Service scans a git range of a local repository and prints the findings to the terminal
+type Service struct {
+	Logger   logger.Logger
+	GitDiff  gitdiffintf.GitDiff
+	Out      io.Writer
+	Color    bool
+	RepoPath string
+	Base     string
+	Head     string
+	Hook     string
+	// Path is the directory scanned as a whole by the scan command, instead of a git range
+	Path string
+	// History scans each commit of the range separately instead of the diff between base and head
+	History bool
+}
+
+// NewLocalService creates a new local service for the range base..head of the repository at repoPath
+func NewLocalService(repoPath, base, head string) diffreviewer.DiffReviewer {
+	return &Service{
+		Logger:   locallogger.NewDefaultLocalLogger(),
+		Out:      os.Stdout,
+		Color:    locallogger.UseColor(os.Stdout),
+		RepoPath: repoPath,
+		Base:     base,
+		Head:     head,
+	}
+}
+
+// GetLogger gets the local service logger
+func (s *Service) GetLogger() logger.Logger {
+	return s.Logger
+}
+
+// LoadConfig gets all config values from files or environment and creates a config
+func (s *Service) LoadConfig(nightfallConfigFileName string) (*nightfallconfig.Config, error) {
+	s.Logger.Info("Loading configuration")
+	repoPath, err := filepath.Abs(s.RepoPath)
+	if err != nil {
+		s.Logger.Error(fmt.Sprintf("Error resolving repository path %s", s.RepoPath))
+		return nil, err
+	}
+	switch {
+	case s.Path != "":
+		// the config and baseline of the repository containing the path apply
+		repoPath, err = runGit(repoPath, "rev-parse", "--show-toplevel")
+		if err != nil {
+			s.Logger.Error(fmt.Sprintf("Error finding the git repository of %s, the scan command requires a git working tree", s.Path))
+			return nil, err
+		}
+		s.RepoPath = repoPath
+	case s.History:
+		// every commit is read with git log, so no diff client is needed
+		s.RepoPath = repoPath
+	case s.Hook == PreCommitHook:
+		s.GitDiff = &gitdiff.GitDiff{
+			WorkDir: repoPath,
+			Staged:  true,
+		}
+	case s.Hook == PrePushHook:
+		base, err := outgoingBase(repoPath)
+		if err != nil {
+			s.Logger.Error(fmt.Sprintf("Error finding the outgoing commits: %v", err))
+			return nil, err
+		}
+		s.Base = base
+		fallthrough
+	default:
+		s.GitDiff = &gitdiff.GitDiff{
+			WorkDir: repoPath,
+			BaseSHA: s.Base,
+			Head:    s.Head,
+			Local:   true,
+		}
+	}
+	nightfallConfig, err := nightfallconfig.GetNightfallConfigFile(repoPath, nightfallConfigFileName, s.Logger)
+	if err != nil {
+		s.Logger.Error("Error getting Nightfall config file. " +
+			"Ensure you have a Nightfall config file located in the root of your repository at .nightfalldlp/config.json " +
+			"with either a Condition Set UUID or at least one Condition enabled")
+		return nil, err
+	}
+	baseline, err := nightfallconfig.GetBaseline(repoPath, nightfallconfig.BaselineFileName, s.Logger)
+	if err != nil {
+		s.Logger.Error(fmt.Sprintf("Error getting Nightfall baseline file. Recreate %s with the baseline create command", nightfallconfig.BaselineFileName))
+		return nil, err
+	}
+	nightfallAPIKey := os.Getenv(NightfallAPIKeyEnvVar)
+	if nightfallAPIKey == "" && nightfallConfig.UsesAPIEngine() {
+		s.Logger.Warning(fmt.Sprintf("Nightfall API key not found, falling back to the local detector engine. Ensure you have %s exported in your shell to scan with the Nightfall API", NightfallAPIKeyEnvVar))
+	}
+	return &nightfallconfig.Config{
+		NightfallAPIKey:             nightfallAPIKey,
+		NightfallDetectionRuleUUIDs: nightfallConfig.DetectionRuleUUIDs,
+		NightfallDetectionRules:     nightfallConfig.DetectionRules,
+		NightfallMaxNumberRoutines:  nightfallConfig.MaxNumberRoutines,
+		TokenExclusionList:          nightfallConfig.TokenExclusionList,
+		FileInclusionList:           nightfallConfig.FileInclusionList,
+		FileExclusionList:           nightfallConfig.FileExclusionList,
+		DefaultRedactionConfig:      nightfallConfig.DefaultRedactionConfig,
+		AnnotationLevel:             nightfallConfig.AnnotationLevel,
+		Engine:                      nightfallConfig.Engine,
+		EntropyDetection:            nightfallConfig.EntropyDetection,
+		Baseline:                    baseline,
+		FailurePolicy:               nightfallConfig.FailurePolicy,
+		CacheDirectory:              nightfallConfig.CacheDirectory,
+		Engines:                     nightfallConfig.Engines,
+		CommandDetectors:            nightfallConfig.CommandDetectors,
+	}, nil
+}
+
+// GetDiff retrieves the file diff of the requested git range, of each commit of the range
+// when scanning history, or of every file under the scanned path
+func (s *Service) GetDiff() ([]*diffreviewer.FileDiff, error) {
+	if s.Path != "" {
+		return s.getTreeFileDiffs()
+	}
+	if s.History {
+		return s.getHistoryFileDiffs()
+	}
+	s.Logger.Info(fmt.Sprintf("Getting diff of %s", s.diffRange()))
+	content, err := s.GitDiff.GetDiff()
+	if err != nil {
+		s.Logger.Error(fmt.Sprintf("Error getting the raw diff of %s: %v", s.diffRange(), err))
+		return nil, err
+	}
+
+	fileDiffs, err := diffutils.ParseMultiFile(strings.NewReader(content))
+	if err != nil {
+		s.Logger.Error("Error parsing the raw diff")
+		return nil, err
+	}
+	fileDiffs = diffutils.FilterFileDiffs(fileDiffs)
+	return fileDiffs, nil
+}
+
+// diffRange describes the changes diffed by GetDiff for the logs
+func (s *Service) diffRange() string {
+	if gd, ok := s.GitDiff.(*gitdiff.GitDiff); ok && gd.Staged {
+		return "staged changes"
+	}
+	return fmt.Sprintf("%s..%s", s.Base, s.Head)
+}
+
+// WriteComments prints the findings to the terminal as file:line with the severity level
+func (s *Service) WriteComments(comments []*diffreviewer.Comment, level string, failure *diffreviewer.ScanFailure) error {
+	levelString := locallogger.Colorize(level, levelColor(level), s.Color)
+	for _, comment := range comments {
+		if c := comment.Commit; c != nil {
+			fmt.Fprintf(s.Out, historyFindingString, comment.FilePath, comment.LineNumber, levelString, comment.Body, shortSHA(c.SHA), c.Author, c.Date)
+			continue
+		}
+		fmt.Fprintf(s.Out, findingString, comment.FilePath, comment.LineNumber, levelString, comment.Body)
+	}
+	fmt.Fprintf(s.Out, summaryString, len(comments))
+	if failure != nil {
+		fmt.Fprintln(s.Out, failure.Summary())
+	}
+	if failure.FailsRun() {
+		return failure
+	}
+	if len(comments) > 0 && level == nightfallconfig.AnnotationLevelFailure {
+		// returning error to exit with a non-zero status
+		return errSensitiveItemsFound
+	}
+	return nil
+}
+
+func levelColor(level string) string {
+	switch level {
+	case nightfallconfig.AnnotationLevelWarning:
+		return locallogger.ColorYellow
+	case nightfallconfig.AnnotationLevelNotice:
+		return locallogger.ColorCyan
+	default:
+		return locallogger.ColorRed
+	}
+}
@@ -0,0 +1,256 @@
+package local
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	nf "github.com/nightfallai/nightfall-go-sdk"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/gitdiff"
+	locallogger "github.com/nightfallai/nightfall_code_scanner/internal/clients/logger/local_logger"
+	"github.com/nightfallai/nightfall_code_scanner/internal/mocks/clients/gitdiff_mock"
+	"github.com/nightfallai/nightfall_code_scanner/internal/nightfallconfig"
+	"github.com/stretchr/testify/suite"
+)
+
+const expectedDiffResponseStr = `diff --git a/blah.txt b/blah.txt
+new file mode 100644
+index 0000000..e9ea42a
+--- /dev/null
++++ b/blah.txt
+@@ -0,0 +1 @@
++this is a text file`
+
+var expectedFileDiffs = []*diffreviewer.FileDiff{{
+	PathOld: "/dev/null",
+	PathNew: "blah.txt",
+	Hunks: []*diffreviewer.Hunk{{
+		StartLineOld:  0,
+		LineLengthOld: 0,
+		StartLineNew:  1,
+		LineLengthNew: 1,
+		Lines: []*diffreviewer.Line{{
+			Type:     diffreviewer.LineAdded,
+			Content:  "this is a text file",
+			LnumDiff: 1,
+			LnumOld:  0,
+			LnumNew:  1,
+		}},
+	}},
+	Extended: []string{"diff --git a/blah.txt b/blah.txt", "new file mode 100644", "index 0000000..e9ea42a"},
+}}
+
+const testConfigFileName = "nightfall_test_config.json"
+const excludedCreditCardRegex = "4242-4242-4242-[0-9]{4}"
+const excludedApiToken = "xG0Ct4Wsu3OTcJnE1dFLAQfRgL6b8tIv"
+const excludedIPRegex = "^127\\."
+
+var lLogger = locallogger.NewDefaultLocalLogger()
+
+type localTestSuite struct {
+	suite.Suite
+}
+
+func (l *localTestSuite) AfterTest(_, _ string) {
+	err := os.Unsetenv(NightfallAPIKeyEnvVar)
+	l.NoErrorf(err, "Error unsetting var %s", NightfallAPIKeyEnvVar)
+}
+
+func (l *localTestSuite) TestLoadConfig() {
+	workspace, err := os.Getwd()
+	l.NoError(err, "Error getting workspace")
+	repoPath := path.Join(workspace, "../../../../test/data")
+	apiKey := "api-key"
+	_ = os.Setenv(NightfallAPIKeyEnvVar, apiKey)
+	s := &Service{
+		Logger:   lLogger,
+		RepoPath: repoPath,
+		Base:     "main",
+		Head:     "HEAD",
+	}
+
+	expectedNightfallConfig := &nightfallconfig.Config{
+		NightfallAPIKey: apiKey,
+		NightfallDetectionRules: []nf.DetectionRule{
+			{
+				Name: "my detection rule",
+				Detectors: []nf.Detector{
+					{
+						MinNumFindings:    1,
+						MinConfidence:     nf.ConfidencePossible,
+						DetectorType:      nf.DetectorTypeNightfallDetector,
+						DisplayName:       "cc",
+						NightfallDetector: "CREDIT_CARD_NUMBER",
+					},
+					{
+						MinNumFindings:    1,
+						MinConfidence:     nf.ConfidencePossible,
+						DetectorType:      nf.DetectorTypeNightfallDetector,
+						DisplayName:       "phone",
+						NightfallDetector: "PHONE_NUMBER",
+					},
+					{
+						MinNumFindings:    1,
+						MinConfidence:     nf.ConfidenceLikely,
+						DetectorType:      nf.DetectorTypeNightfallDetector,
+						DisplayName:       "ip",
+						NightfallDetector: "IP_ADDRESS",
+					},
+				},
+				LogicalOp: nf.LogicalOpAny,
+			},
+		},
+		NightfallMaxNumberRoutines: 20,
+		TokenExclusionList:         []string{excludedCreditCardRegex, excludedApiToken, excludedIPRegex},
+		FileInclusionList:          []string{"*"},
+		FileExclusionList:          []string{".nightfalldlp/config.json", ".nightfalldlp/baseline.json"},
+		DefaultRedactionConfig: &nf.RedactionConfig{
+			SubstitutionConfig: &nf.SubstitutionConfig{SubstitutionPhrase: "REDACTED"},
+		},
+		AnnotationLevel: "warning",
+		Engine:          "api",
+		FailurePolicy:   "fail-closed",
+	}
+	expectedGitDiff := &gitdiff.GitDiff{
+		WorkDir: repoPath,
+		BaseSHA: "main",
+		Head:    "HEAD",
+		Local:   true,
+	}
+
+	nightfallConfig, err := s.LoadConfig(testConfigFileName)
+	l.NoError(err, "Unexpected error in LoadConfig")
+	l.Equal(expectedNightfallConfig, nightfallConfig, "Incorrect nightfall config")
+	l.Equal(expectedGitDiff, s.GitDiff, "Incorrect git diff")
+}
+
+func (l *localTestSuite) TestLoadConfigMissingApiKey() {
+	workspace, err := os.Getwd()
+	l.NoError(err, "Error getting workspace")
+	s := &Service{
+		Logger:   lLogger,
+		RepoPath: path.Join(workspace, "../../../../test/data"),
+		Base:     "main",
+		Head:     "HEAD",
+	}
+
+	nightfallConfig, err := s.LoadConfig(testConfigFileName)
+	l.NoError(err, "missing api key should fall back to the local detector engine")
+	l.Empty(nightfallConfig.NightfallAPIKey, "unexpected api key")
+}
+
+func (l *localTestSuite) TestGetDiff() {
+	ctrl := gomock.NewController(l.T())
+	defer ctrl.Finish()
+	mockGitDiff := gitdiff_mock.NewGitDiff(ctrl)
+	s := &Service{
+		Logger:  lLogger,
+		GitDiff: mockGitDiff,
+	}
+
+	mockGitDiff.EXPECT().GetDiff().Return(expectedDiffResponseStr, nil)
+
+	fileDiffs, err := s.GetDiff()
+	l.NoError(err, "unexpected error in GetDiff")
+	l.Equal(expectedFileDiffs, fileDiffs, "invalid fileDiff return value")
+}
+
+func (l *localTestSuite) TestWriteComments() {
+	comments := []*diffreviewer.Comment{
+		{Body: "Suspicious content detected (cc)", FilePath: "main.go", LineNumber: 3},
+		{Body: "Suspicious content detected (ip)", FilePath: "config/app.yaml", LineNumber: 12},
+	}
+	tests := []struct {
+		comments []*diffreviewer.Comment
+		level    string
+		failure  *diffreviewer.ScanFailure
+		color    bool
+		wantOut  string
+		wantErr  error
+		desc     string
+	}{
+		{
+			comments: comments,
+			level:    nightfallconfig.AnnotationLevelFailure,
+			wantOut: "main.go:3: failure: Suspicious content detected (cc)\n" +
+				"config/app.yaml:12: failure: Suspicious content detected (ip)\n" +
+				"Nightfall DLP has found 2 potentially sensitive items\n",
+			wantErr: errSensitiveItemsFound,
+			desc:    "failure test",
+		},
+		{
+			comments: comments[:1],
+			level:    nightfallconfig.AnnotationLevelWarning,
+			color:    true,
+			wantOut: "main.go:3: \x1b[0;33mwarning\x1b[0m: Suspicious content detected (cc)\n" +
+				"Nightfall DLP has found 1 potentially sensitive items\n",
+			desc: "colored warning test",
+		},
+		{
+			comments: []*diffreviewer.Comment{{
+				Body:       "Suspicious content detected (cc)",
+				FilePath:   "main.go",
+				LineNumber: 3,
+				Commit: &diffreviewer.Commit{
+					SHA:    "9fceb02d0ae598e95dc970b74767f19372d61af8",
+					Author: "Jane Doe",
+					Date:   "2020-06-01T10:00:00+02:00",
+				},
+			}},
+			level: nightfallconfig.AnnotationLevelNotice,
+			wantOut: "main.go:3: notice: Suspicious content detected (cc) (commit 9fceb02d0ae5 by Jane Doe on 2020-06-01T10:00:00+02:00)\n" +
+				"Nightfall DLP has found 1 potentially sensitive items\n",
+			desc: "history test",
+		},
+		{
+			comments: []*diffreviewer.Comment{},
+			level:    nightfallconfig.AnnotationLevelFailure,
+			wantOut:  "Nightfall DLP has found 0 potentially sensitive items\n",
+			desc:     "no comments test",
+		},
+		{
+			comments: comments[:1],
+			level:    nightfallconfig.AnnotationLevelNotice,
+			failure:  &diffreviewer.ScanFailure{Policy: nightfallconfig.FailurePolicyFailClosed, Files: []string{"a.go", "b.go"}, Err: errors.New("timeout")},
+			wantOut: "main.go:3: notice: Suspicious content detected (cc)\n" +
+				"Nightfall DLP has found 1 potentially sensitive items\n" +
+				"Nightfall DLP could not scan 2 files: a.go, b.go\n",
+			wantErr: errors.New("unable to scan 2 files: timeout"),
+			desc:    "fail closed test",
+		},
+		{
+			comments: []*diffreviewer.Comment{},
+			level:    nightfallconfig.AnnotationLevelFailure,
+			failure:  &diffreviewer.ScanFailure{Policy: nightfallconfig.FailurePolicyFailOpen, Files: []string{"a.go"}, Err: errors.New("timeout")},
+			wantOut: "Nightfall DLP has found 0 potentially sensitive items\n" +
+				"Nightfall DLP could not scan 1 files: a.go\n",
+			desc: "fail open test",
+		},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		s := &Service{
+			Logger: lLogger,
+			Out:    &buf,
+			Color:  tt.color,
+		}
+
+		err := s.WriteComments(tt.comments, tt.level, tt.failure)
+		if tt.wantErr != nil {
+			l.EqualError(err, tt.wantErr.Error(), fmt.Sprintf("invalid error for %s", tt.desc))
+		} else {
+			l.NoError(err, fmt.Sprintf("unexpected error for %s", tt.desc))
+		}
+		l.Equal(tt.wantOut, buf.String(), fmt.Sprintf("invalid output for %s", tt.desc))
+	}
+}
+
+func TestLocalService(t *testing.T) {
+	suite.Run(t, new(localTestSuite))
+}
@@ -0,0 +1,104 @@
+package local
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer/diffutils"
+	locallogger "github.com/nightfallai/nightfall_code_scanner/internal/clients/logger/local_logger"
+)
+
+// binarySniffLength is how much of a file is checked for NUL bytes to detect binary files, as git does
+const binarySniffLength = 8000
+
+// NewPathService creates a local service scanning the whole content of every file under path,
+// independent of any git range. Files ignored by .gitignore are skipped.
+func NewPathService(path string) diffreviewer.DiffReviewer {
+	return &Service{
+		Logger:   locallogger.NewDefaultLocalLogger(),
+		Out:      os.Stdout,
+		Color:    locallogger.UseColor(os.Stdout),
+		RepoPath: path,
+		Path:     path,
+	}
+}
+
+// getTreeFileDiffs returns every tracked or untracked, non ignored file under the path
+// as a diff adding all of its lines, so that the whole content is scanned
+func (s *Service) getTreeFileDiffs() ([]*diffreviewer.FileDiff, error) {
+	s.Logger.Info(fmt.Sprintf("Listing files under %s", s.Path))
+	out, err := runGit(s.Path, "ls-files", "-z", "--cached", "--others", "--exclude-standard", "--full-name")
+	if err != nil {
+		s.Logger.Error(fmt.Sprintf("Error listing the files under %s: %v", s.Path, err))
+		return nil, err
+	}
+
+	fileDiffs := make([]*diffreviewer.FileDiff, 0)
+	for _, name := range strings.Split(out, "\x00") {
+		if name == "" {
+			continue
+		}
+		fileDiff, err := readFileDiff(s.RepoPath, name)
+		if err != nil {
+			s.Logger.Error(fmt.Sprintf("Error reading %s: %v", name, err))
+			return nil, err
+		}
+		if fileDiff != nil {
+			fileDiffs = append(fileDiffs, fileDiff)
+		}
+	}
+	s.Logger.Info(fmt.Sprintf("Scanning %d files", len(fileDiffs)))
+	return diffutils.FilterFileDiffs(fileDiffs), nil
+}
+
+// readFileDiff returns nil for files that are missing from the working tree, are not regular files or are binary
+func readFileDiff(repoPath, name string) (*diffreviewer.FileDiff, error) {
+	filePath := filepath.Join(repoPath, filepath.FromSlash(name))
+	info, err := os.Lstat(filePath)
+	if os.IsNotExist(err) {
+		// deleted from the working tree but still in the index
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, nil
+	}
+	content, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	sniff := content
+	if len(sniff) > binarySniffLength {
+		sniff = sniff[:binarySniffLength]
+	}
+	if bytes.IndexByte(sniff, 0) >= 0 {
+		return nil, nil
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	hunk := &diffreviewer.Hunk{
+		StartLineNew:  1,
+		LineLengthNew: len(lines),
+		Lines:         make([]*diffreviewer.Line, len(lines)),
+	}
+	for i, line := range lines {
+		hunk.Lines[i] = &diffreviewer.Line{
+			Type:     diffreviewer.LineAdded,
+			Content:  strings.TrimSuffix(line, "\r"),
+			LnumDiff: i + 1,
+			LnumNew:  i + 1,
+		}
+	}
+	return &diffreviewer.FileDiff{
+		PathOld: name,
+		PathNew: name,
+		Hunks:   []*diffreviewer.Hunk{hunk},
+	}, nil
+}
@@ -0,0 +1,62 @@
+package local
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathServiceGetDiff(t *testing.T) {
+	repoPath := initTestRepo(t)
+	defer os.RemoveAll(repoPath)
+	files := map[string]string{
+		".gitignore":        "*.log\n",
+		"src/main.go":       "package main\r\n\r\nconst key = \"secret\"\r\n",
+		"src/debug.log":     "ignored\n",
+		"src/image.png":     "\x89PNG\x00\x00",
+		"docs/readme.md":    "# docs\n",
+		"src/empty_file.go": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(repoPath, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+
+	s := NewPathService(filepath.Join(repoPath, "src")).(*Service)
+	s.Logger = lLogger
+	_, err := s.LoadConfig(testConfigFileName)
+	require.NoError(t, err, "unexpected error in LoadConfig")
+
+	fileDiffs, err := s.GetDiff()
+	require.NoError(t, err, "unexpected error in GetDiff")
+	expectedFileDiffs := []*diffreviewer.FileDiff{{
+		PathOld: "src/main.go",
+		PathNew: "src/main.go",
+		Hunks: []*diffreviewer.Hunk{{
+			StartLineNew:  1,
+			LineLengthNew: 3,
+			Lines: []*diffreviewer.Line{
+				{Type: diffreviewer.LineAdded, Content: "package main", LnumDiff: 1, LnumNew: 1},
+				{Type: diffreviewer.LineAdded, Content: `const key = "secret"`, LnumDiff: 3, LnumNew: 3},
+			},
+		}},
+	}}
+	assert.Equal(t, expectedFileDiffs, fileDiffs, "only the text files under the path that are not ignored should be scanned")
+}
+
+func TestPathServiceNotGitRepository(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nightfalldlp-path")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s := NewPathService(dir).(*Service)
+	s.Logger = lLogger
+	_, err = s.LoadConfig(testConfigFileName)
+	assert.Error(t, err, "scanning outside a git working tree should fail")
+}
@@ -0,0 +1,60 @@
+package diffreviewer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/logger"
+	"github.com/nightfallai/nightfall_code_scanner/internal/nightfallconfig"
+)
+
+const scanFailureSummaryString = "Nightfall DLP could not scan %d files: %s"
+
+// ScanFailure describes the files a review could not scan, and how the failure policy of the config handles them
+type ScanFailure struct {
+	// Policy is one of the nightfallconfig failure policies
+	Policy string
+	// Files are the paths of the files that were not scanned
+	Files []string
+	// Err is the last error returned when scanning
+	Err error
+}
+
+func (f *ScanFailure) Error() string {
+	return fmt.Sprintf("unable to scan %d files: %v", len(f.Files), f.Err)
+}
+
+func (f *ScanFailure) Unwrap() error {
+	return f.Err
+}
+
+// Summary lists the files that were not scanned, for the summary of the run
+func (f *ScanFailure) Summary() string {
+	if f == nil {
+		return ""
+	}
+	return fmt.Sprintf(scanFailureSummaryString, len(f.Files), strings.Join(f.Files, ", "))
+}
+
+// FailsRun reports whether the run must fail because of the unscanned files
+func (f *ScanFailure) FailsRun() bool {
+	return f != nil && f.Policy == nightfallconfig.FailurePolicyFailClosed
+}
+
+// Neutral reports whether the run must end with a neutral conclusion because of the unscanned files
+func (f *ScanFailure) Neutral() bool {
+	return f != nil && f.Policy == nightfallconfig.FailurePolicyNeutral
+}
+
+// LogScanFailure logs the files that were not scanned, and returns the failure if it must fail the run
+func LogScanFailure(logger logger.Logger, failure *ScanFailure) error {
+	if failure == nil {
+		return nil
+	}
+	if failure.FailsRun() {
+		logger.Error(failure.Summary())
+		return failure
+	}
+	logger.Warning(failure.Summary())
+	return nil
+}
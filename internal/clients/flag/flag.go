@@ -1,8 +1,10 @@
 package flag
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/spf13/pflag"
 )
@@ -14,32 +16,138 @@ const (
 	debugFlag        = "debug"
 	debugShorthand   = "d"
 	debugDescription = "Enable debug logs"
+
+	baseFlag        = "base"
+	baseDescription = "Scan a local git range starting at this revision, without any CI environment"
+
+	headFlag        = "head"
+	headDescription = "End revision of the local git range"
+	defaultHead     = "HEAD"
+
+	repoPathFlag        = "repo-path"
+	repoPathDescription = "Path to the local git repository to scan"
+	defaultRepoPath     = "."
+
+	sarifFlag        = "sarif"
+	sarifDescription = "Write the findings as a SARIF 2.1.0 log to this path"
+
+	outputFlag        = "output"
+	outputShorthand   = "o"
+	outputDescription = "Write the findings to a report file given as <format>=<path>, format is json, sarif or junit (repeatable)"
+
+	pathFlag        = "path"
+	pathDescription = "Directory scanned as a whole by the scan command (default \".\")"
+	defaultPath     = "."
+
+	preCommitHook   = "pre-commit"
+	hookFlag        = "hook"
+	hookDescription = "Scan the changes seen by a git hook (pre-commit or pre-push)"
+
+	installHookCommand       = "install-hook"
+	baselineCommand          = "baseline"
+	baselineCreateSubcommand = "create"
+	scanCommand              = "scan"
+	historyCommand           = "history"
+	usage                    = "Usage: Nightfall DLP is used to scan content for sensitive information\n\n" +
+		"  nightfalldlp [flags]\n" +
+		"  nightfalldlp scan [--path <dir>] [flags]\n" +
+		"  nightfalldlp history [--base <rev>] [--head <rev>] [flags]\n" +
+		"  nightfalldlp install-hook [pre-commit|pre-push] [flags]\n" +
+		"  nightfalldlp baseline create [flags]\n\n"
 )
 
 // Values contains all values parsed from command line flags
 type Values struct {
-	Debug bool
+	Debug    bool
+	Base     string
+	Head     string
+	RepoPath string
+	Hook     string
+	Sarif    string
+	Outputs  []string
+	// InstallHook is the hook type to install when the install-hook command is given
+	InstallHook string
+	// BaselineCreate is set when the baseline create command is given
+	BaselineCreate bool
+	// Path is the directory to scan when the scan command is given
+	Path string
+	// History is set when the history command is given to scan every commit of the range
+	History bool
+}
+
+// Local returns true if a local git range or a git hook scan was requested
+func (v *Values) Local() bool {
+	return v.Base != "" || v.Hook != ""
 }
 
-// Parse parses flags from command line
-func Parse(args []string) (*Values, bool) {
+// Parse parses flags from command line. done is set when only the usage was requested, invalid
+// flags and unknown commands are returned as an error after printing the usage
+func Parse(args []string) (*Values, bool, error) {
 	fs := pflag.NewFlagSet("all flags", pflag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		fs.PrintDefaults()
+	}
 
 	values := Values{}
 	var help bool
 
 	fs.BoolVar(&help, helpFlag, false, helpDescription)
 	fs.BoolVarP(&values.Debug, debugFlag, debugShorthand, false, debugDescription)
+	fs.StringVar(&values.Base, baseFlag, "", baseDescription)
+	fs.StringVar(&values.Head, headFlag, defaultHead, headDescription)
+	fs.StringVar(&values.RepoPath, repoPathFlag, defaultRepoPath, repoPathDescription)
+	fs.StringVar(&values.Hook, hookFlag, "", hookDescription)
+	fs.StringVar(&values.Sarif, sarifFlag, "", sarifDescription)
+	fs.StringArrayVarP(&values.Outputs, outputFlag, outputShorthand, nil, outputDescription)
+	fs.StringVar(&values.Path, pathFlag, "", pathDescription)
 
 	err := fs.Parse(args)
-	if err != nil || help {
-		if err != nil {
-			fmt.Fprint(os.Stderr, err, "\n")
-		}
-		fmt.Fprint(os.Stderr, "Usage: Nightfall DLP is used to scan content for sensitive information\n\n")
-		fs.PrintDefaults()
-		return nil, true
+	if errors.Is(err, pflag.ErrHelp) {
+		// -h has already printed the usage
+		return nil, true, nil
+	}
+	if err == nil {
+		err = parseCommand(fs.Args(), &values)
 	}
+	if err != nil {
+		fs.Usage()
+		return nil, false, err
+	}
+	if help {
+		fs.Usage()
+		return nil, true, nil
+	}
+
+	return &values, false, nil
+}
 
-	return &values, false
+func parseCommand(args []string, values *Values) error {
+	if len(args) == 0 {
+		if values.Path != "" {
+			return fmt.Errorf("--%s can only be used with the %s command", pathFlag, scanCommand)
+		}
+		return nil
+	}
+	switch {
+	case args[0] == scanCommand && len(args) == 1:
+		if values.Path == "" {
+			values.Path = defaultPath
+		}
+		return nil
+	case args[0] == historyCommand && len(args) == 1:
+		values.History = true
+		return nil
+	case args[0] == installHookCommand && len(args) <= 2:
+		values.InstallHook = preCommitHook
+		if len(args) == 2 {
+			values.InstallHook = args[1]
+		}
+		return nil
+	case args[0] == baselineCommand && len(args) == 2 && args[1] == baselineCreateSubcommand:
+		values.BaselineCreate = true
+		return nil
+	default:
+		return fmt.Errorf("unknown command: %s", strings.Join(args, " "))
+	}
 }
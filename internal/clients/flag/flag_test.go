@@ -15,12 +15,15 @@ func TestParse(t *testing.T) {
 		have       []string
 		wantValues *flag.Values
 		wantDone   bool
+		wantErr    bool
 	}{
 		{
 			desc: "Debug flag",
 			have: []string{"--debug"},
 			wantValues: &flag.Values{
-				Debug: true,
+				Debug:    true,
+				Head:     "HEAD",
+				RepoPath: ".",
 			},
 			wantDone: false,
 		},
@@ -28,7 +31,9 @@ func TestParse(t *testing.T) {
 			desc: "Debug shorthand flag",
 			have: []string{"-d"},
 			wantValues: &flag.Values{
-				Debug: true,
+				Debug:    true,
+				Head:     "HEAD",
+				RepoPath: ".",
 			},
 			wantDone: false,
 		},
@@ -36,27 +41,159 @@ func TestParse(t *testing.T) {
 			desc: "No flags",
 			have: []string{},
 			wantValues: &flag.Values{
-				Debug: false,
+				Debug:    false,
+				Head:     "HEAD",
+				RepoPath: ".",
 			},
 			wantDone: false,
 		},
+		{
+			desc: "Local range flags",
+			have: []string{"--base", "main", "--head", "feature", "--repo-path", "/tmp/repo"},
+			wantValues: &flag.Values{
+				Base:     "main",
+				Head:     "feature",
+				RepoPath: "/tmp/repo",
+			},
+			wantDone: false,
+		},
+		{
+			desc: "Hook flag",
+			have: []string{"--hook", "pre-push"},
+			wantValues: &flag.Values{
+				Head:     "HEAD",
+				RepoPath: ".",
+				Hook:     "pre-push",
+			},
+			wantDone: false,
+		},
+		{
+			desc: "Sarif flag",
+			have: []string{"--sarif", "results.sarif"},
+			wantValues: &flag.Values{
+				Head:     "HEAD",
+				RepoPath: ".",
+				Sarif:    "results.sarif",
+			},
+			wantDone: false,
+		},
+		{
+			desc: "Output flags",
+			have: []string{"--output", "json=findings.json", "-o", "sarif=out/results.sarif"},
+			wantValues: &flag.Values{
+				Head:     "HEAD",
+				RepoPath: ".",
+				Outputs:  []string{"json=findings.json", "sarif=out/results.sarif"},
+			},
+			wantDone: false,
+		},
+		{
+			desc: "Install hook command",
+			have: []string{"install-hook", "pre-push"},
+			wantValues: &flag.Values{
+				Head:        "HEAD",
+				RepoPath:    ".",
+				InstallHook: "pre-push",
+			},
+			wantDone: false,
+		},
+		{
+			desc: "Install hook command default",
+			have: []string{"install-hook"},
+			wantValues: &flag.Values{
+				Head:        "HEAD",
+				RepoPath:    ".",
+				InstallHook: "pre-commit",
+			},
+			wantDone: false,
+		},
+		{
+			desc: "Baseline create command",
+			have: []string{"baseline", "create", "--repo-path", "/tmp/repo"},
+			wantValues: &flag.Values{
+				Head:           "HEAD",
+				RepoPath:       "/tmp/repo",
+				BaselineCreate: true,
+			},
+			wantDone: false,
+		},
+		{
+			desc:       "Baseline without subcommand",
+			have:       []string{"baseline"},
+			wantValues: nil,
+			wantDone:   false,
+			wantErr:    true,
+		},
+		{
+			desc: "Scan command",
+			have: []string{"scan", "--path", "src"},
+			wantValues: &flag.Values{
+				Head:     "HEAD",
+				RepoPath: ".",
+				Path:     "src",
+			},
+			wantDone: false,
+		},
+		{
+			desc: "Scan command default path",
+			have: []string{"scan"},
+			wantValues: &flag.Values{
+				Head:     "HEAD",
+				RepoPath: ".",
+				Path:     ".",
+			},
+			wantDone: false,
+		},
+		{
+			desc: "History command",
+			have: []string{"history", "--base", "v1.0.0"},
+			wantValues: &flag.Values{
+				Base:     "v1.0.0",
+				Head:     "HEAD",
+				RepoPath: ".",
+				History:  true,
+			},
+			wantDone: false,
+		},
+		{
+			desc:       "Path flag without scan command",
+			have:       []string{"--path", "src"},
+			wantValues: nil,
+			wantDone:   false,
+			wantErr:    true,
+		},
+		{
+			desc:       "Unknown command",
+			have:       []string{"uninstall"},
+			wantValues: nil,
+			wantDone:   false,
+			wantErr:    true,
+		},
 		{
 			desc:       "Help flag",
 			have:       []string{"--help"},
 			wantValues: nil,
 			wantDone:   true,
 		},
+		{
+			desc:       "Help shorthand flag",
+			have:       []string{"-h"},
+			wantValues: nil,
+			wantDone:   true,
+		},
 		{
 			desc:       "Invalid flag",
 			have:       []string{"--flagdoesnotexist"},
 			wantValues: nil,
-			wantDone:   true,
+			wantDone:   false,
+			wantErr:    true,
 		},
 	}
 
 	for _, tt := range tests {
-		values, done := flag.Parse(tt.have)
+		values, done, err := flag.Parse(tt.have)
 		assert.Equal(t, tt.wantValues, values, fmt.Sprintf("Values returned are incorrect for test %s", tt.desc))
 		assert.Equal(t, tt.wantDone, done, fmt.Sprintf("Done returned is incorrect for test %s", tt.desc))
+		assert.Equal(t, tt.wantErr, err != nil, fmt.Sprintf("Error returned is incorrect for test %s", tt.desc))
 	}
 }
@@ -16,6 +16,11 @@ type GitDiff struct {
 	BaseBranch string
 	BaseSHA    string
 	Head       string
+	// Local diffs BaseSHA against Head using only revisions already present
+	// in the working copy, without fetching from origin
+	Local bool
+	// Staged diffs the index against HEAD, as a pre-commit hook sees it
+	Staged bool
 }
 
 // GetDiff uses the command line to compute the diff
@@ -27,6 +32,12 @@ func (gd *GitDiff) GetDiff() (string, error) {
 
 	var diffCmd *exec.Cmd
 	switch {
+	case gd.Staged:
+		// pre-commit hook so only scan what is about to be committed
+		diffCmd = exec.Command("git", "diff", "--cached")
+	case gd.Local:
+		// local range so both revisions are resolved by the working copy
+		diffCmd = exec.Command("git", "diff", gd.BaseSHA, gd.Head)
 	case gd.BaseBranch != "":
 		// PR event so get diff between base branch and current commit SHA
 		err = exec.Command("git", "fetch", "origin", gd.BaseBranch, "--depth=1").Run()
@@ -0,0 +1,177 @@
+package localscanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	nf "github.com/nightfallai/nightfall-go-sdk"
+)
+
+// maxCommandStderr is how much of the standard error of a failed detector command is reported
+const maxCommandStderr = 1024
+
+// commandEnvVars are the only environment variables passed to detector commands, so that the
+// tokens and API keys of the scan are never visible to them
+var commandEnvVars = []string{"PATH", "HOME", "TMPDIR", "LANG", "LC_ALL"}
+
+// CommandDetector runs an external detector executable. The payload is written to its standard input as a
+// commandRequest, and the executable writes a commandResponse to its standard output before exiting with status 0.
+type CommandDetector struct {
+	name    string
+	command []string
+	timeout time.Duration
+}
+
+// commandRequest is the JSON document written to the standard input of a detector command
+type commandRequest struct {
+	// Items are the content to scan
+	Items []string `json:"items"`
+}
+
+// commandResponse is the JSON document a detector command writes to its standard output
+type commandResponse struct {
+	// Findings are the findings of every item, in the order of the request
+	Findings [][]*commandFinding `json:"findings"`
+}
+
+type commandFinding struct {
+	// Detector is the name reported for the finding, the name of the detector command if empty
+	Detector string `json:"detector"`
+	// Confidence is one of the Nightfall confidence levels, POSSIBLE if empty
+	Confidence string `json:"confidence"`
+	// CodepointRange locates the finding in the item, the end is exclusive
+	CodepointRange *nf.Range `json:"codepointRange"`
+}
+
+// NewCommandDetector creates a CommandDetector called name running command, killed after timeout if positive
+func NewCommandDetector(name string, command []string, timeout time.Duration) *CommandDetector {
+	return &CommandDetector{
+		name:    name,
+		command: command,
+		timeout: timeout,
+	}
+}
+
+// ScanText runs the detector command once over every payload item of the request
+func (d *CommandDetector) ScanText(ctx context.Context, request *nf.ScanTextRequest) (*nf.ScanTextResponse, error) {
+	if len(d.command) == 0 {
+		return nil, fmt.Errorf("detector %s has no command", d.name)
+	}
+	input, err := json.Marshal(&commandRequest{Items: request.Payload})
+	if err != nil {
+		return nil, err
+	}
+	if d.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.timeout)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, d.command[0], d.command[1:]...)
+	cmd.Env = commandEnv()
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("detector %s: %w", d.name, ctx.Err())
+		}
+		return nil, fmt.Errorf("detector %s failed: %w: %s", d.name, err, truncate(stderr.String(), maxCommandStderr))
+	}
+
+	var resp commandResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("detector %s returned an invalid response: %w", d.name, err)
+	}
+	if len(resp.Findings) != len(request.Payload) {
+		return nil, fmt.Errorf("detector %s returned findings for %d items instead of %d", d.name, len(resp.Findings), len(request.Payload))
+	}
+	var redactionConfig *nf.RedactionConfig
+	if request.Config != nil {
+		redactionConfig = request.Config.DefaultRedactionConfig
+	}
+	findings := make([][]*nf.Finding, len(request.Payload))
+	for i, item := range request.Payload {
+		findings[i] = make([]*nf.Finding, 0, len(resp.Findings[i]))
+		for _, f := range resp.Findings[i] {
+			finding, err := d.toFinding(item, f, redactionConfig)
+			if err != nil {
+				return nil, fmt.Errorf("detector %s returned an invalid finding for item %d: %w", d.name, i, err)
+			}
+			findings[i] = append(findings[i], finding)
+		}
+	}
+	return &nf.ScanTextResponse{Findings: findings}, nil
+}
+
+// toFinding converts the finding of the detector command, its fragment is read from the item
+// so that the finding is always located where the detector reported it
+func (d *CommandDetector) toFinding(item string, f *commandFinding, redactionConfig *nf.RedactionConfig) (*nf.Finding, error) {
+	if f == nil || f.CodepointRange == nil {
+		return nil, errors.New("missing codepointRange")
+	}
+	start, end := f.CodepointRange.Start, f.CodepointRange.End
+	if start < 0 || end <= start || end > int64(utf8.RuneCountInString(item)) {
+		return nil, fmt.Errorf("codepointRange [%d, %d) is outside of the item", start, end)
+	}
+	byteStart, byteEnd := byteOffset(item, start), byteOffset(item, end)
+	detector := f.Detector
+	if detector == "" {
+		detector = d.name
+	}
+	confidence := strings.ToUpper(f.Confidence)
+	if _, ok := confidenceLevels[nf.Confidence(confidence)]; !ok {
+		confidence = string(nf.ConfidencePossible)
+	}
+	fragment := item[byteStart:byteEnd]
+	return &nf.Finding{
+		Finding:         fragment,
+		RedactedFinding: redact(fragment, detector, redactionConfig),
+		Detector:        nf.DetectorMetadata{DisplayName: detector},
+		Confidence:      confidence,
+		Location: &nf.Location{
+			ByteRange:      &nf.Range{Start: int64(byteStart), End: int64(byteEnd)},
+			CodepointRange: &nf.Range{Start: start, End: end},
+		},
+	}, nil
+}
+
+// commandEnv returns the variables of commandEnvVars set in the environment of the scan
+func commandEnv() []string {
+	env := make([]string, 0, len(commandEnvVars))
+	for _, name := range commandEnvVars {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	return env
+}
+
+// byteOffset returns the byte offset of the codepoint at index codepoint of s
+func byteOffset(s string, codepoint int64) int {
+	var i int64
+	for offset := range s {
+		if i == codepoint {
+			return offset
+		}
+		i++
+	}
+	return len(s)
+}
+
+func truncate(s string, max int) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + "..."
+}
@@ -0,0 +1,132 @@
+package localscanner
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	nf "github.com/nightfallai/nightfall-go-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func respondWith(response string) []string {
+	return []string{"sh", "-c", "cat > /dev/null; echo '" + response + "'"}
+}
+
+func TestCommandDetectorScanText(t *testing.T) {
+	tests := []struct {
+		haveCommand  []string
+		haveTimeout  time.Duration
+		haveItems    []string
+		wantFindings [][]*nf.Finding
+		wantErr      string
+		desc         string
+	}{
+		{
+			haveCommand: respondWith(`{"findings": [[], [{"detector": "INTERNAL_KEY", "confidence": "likely", "codepointRange": {"start": 6, "end": 13}}]]}`),
+			haveItems:   []string{"package main", "clé = INT-123"},
+			wantFindings: [][]*nf.Finding{
+				{},
+				{
+					{
+						Finding:         "INT-123",
+						RedactedFinding: "[INTERNAL_KEY]",
+						Detector:        nf.DetectorMetadata{DisplayName: "INTERNAL_KEY"},
+						Confidence:      string(nf.ConfidenceLikely),
+						Location: &nf.Location{
+							ByteRange:      &nf.Range{Start: 7, End: 14},
+							CodepointRange: &nf.Range{Start: 6, End: 13},
+						},
+					},
+				},
+			},
+			desc: "findings located in the items",
+		},
+		{
+			haveCommand: respondWith(`{"findings": [[{"codepointRange": {"start": 0, "end": 3}}]]}`),
+			haveItems:   []string{"abc"},
+			wantFindings: [][]*nf.Finding{
+				{
+					{
+						Finding:         "abc",
+						RedactedFinding: "[in-house]",
+						Detector:        nf.DetectorMetadata{DisplayName: "in-house"},
+						Confidence:      string(nf.ConfidencePossible),
+						Location: &nf.Location{
+							ByteRange:      &nf.Range{Start: 0, End: 3},
+							CodepointRange: &nf.Range{Start: 0, End: 3},
+						},
+					},
+				},
+			},
+			desc: "defaults of the finding",
+		},
+		{
+			haveCommand:  []string{"sh", "-c", `grep -q '{"items":\["a","b"\]}' && echo '{"findings": [[], []]}'`},
+			haveItems:    []string{"a", "b"},
+			wantFindings: [][]*nf.Finding{{}, {}},
+			desc:         "items written to standard input",
+		},
+		{
+			haveCommand: []string{"sh", "-c", "echo 'model not found' >&2; exit 3"},
+			haveItems:   []string{"a"},
+			wantErr:     "detector in-house failed: exit status 3: model not found",
+			desc:        "command failure",
+		},
+		{
+			haveCommand: respondWith(`{"findings": [[]]}`),
+			haveItems:   []string{"a", "b"},
+			wantErr:     "detector in-house returned findings for 1 items instead of 2",
+			desc:        "missing item findings",
+		},
+		{
+			haveCommand: respondWith(`{"findings": [[{"codepointRange": {"start": 2, "end": 9}}]]}`),
+			haveItems:   []string{"abc"},
+			wantErr:     "detector in-house returned an invalid finding for item 0: codepointRange [2, 9) is outside of the item",
+			desc:        "range outside of the item",
+		},
+		{
+			haveCommand: respondWith(`not json`),
+			haveItems:   []string{"abc"},
+			wantErr:     "detector in-house returned an invalid response: invalid character 'o' in literal null (expecting 'u')",
+			desc:        "invalid response",
+		},
+		{
+			haveCommand: []string{"sleep", "5"},
+			haveTimeout: 50 * time.Millisecond,
+			haveItems:   []string{"abc"},
+			wantErr:     "detector in-house: context deadline exceeded",
+			desc:        "timeout",
+		},
+	}
+	for _, tt := range tests {
+		detector := NewCommandDetector("in-house", tt.haveCommand, tt.haveTimeout)
+		request := &nf.ScanTextRequest{
+			Payload: tt.haveItems,
+			Config: &nf.Config{DefaultRedactionConfig: &nf.RedactionConfig{
+				InfoTypeSubstitutionConfig: &nf.InfoTypeSubstitutionConfig{},
+			}},
+		}
+		resp, err := detector.ScanText(context.Background(), request)
+		if tt.wantErr != "" {
+			assert.EqualError(t, err, tt.wantErr, tt.desc)
+			continue
+		}
+		if assert.NoError(t, err, tt.desc) {
+			assert.Equal(t, tt.wantFindings, resp.Findings, tt.desc)
+		}
+	}
+}
+
+func TestCommandDetectorEnvironment(t *testing.T) {
+	os.Setenv("NIGHTFALL_API_KEY", "secret")
+	defer os.Unsetenv("NIGHTFALL_API_KEY")
+	command := []string{"sh", "-c", `cat > /dev/null; [ -z "$NIGHTFALL_API_KEY" ] && [ -n "$PATH" ] && echo '{"findings": [[]]}'`}
+
+	detector := NewCommandDetector("in-house", command, 0)
+	resp, err := detector.ScanText(context.Background(), &nf.ScanTextRequest{Payload: []string{"abc"}})
+	if assert.NoError(t, err, "the secrets of the scan should not be passed to the command") {
+		assert.Equal(t, [][]*nf.Finding{{}}, resp.Findings, "invalid findings")
+	}
+}
@@ -0,0 +1,97 @@
+package localscanner
+
+import (
+	"regexp"
+
+	nf "github.com/nightfallai/nightfall-go-sdk"
+)
+
+// Nightfall detectors approximated by the built-in detectors
+const (
+	NightfallDetectorAPIKey           = "API_KEY"
+	NightfallDetectorCryptographicKey = "CRYPTOGRAPHIC_KEY"
+	NightfallDetectorPasswordInCode   = "PASSWORD_IN_CODE"
+)
+
+// builtinDetector is a regex evaluated in-process in place of a Nightfall detector.
+// If the pattern has a capture group only the first group is reported as the finding.
+type builtinDetector struct {
+	displayName       string
+	nightfallDetector string
+	pattern           *regexp.Regexp
+	confidence        nf.Confidence
+}
+
+var builtinDetectors = []*builtinDetector{
+	{
+		displayName:       "AWS Access Key ID",
+		nightfallDetector: NightfallDetectorAPIKey,
+		pattern:           regexp.MustCompile(`\b((?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16})\b`),
+		confidence:        nf.ConfidenceLikely,
+	},
+	{
+		displayName:       "AWS Secret Access Key",
+		nightfallDetector: NightfallDetectorAPIKey,
+		pattern:           regexp.MustCompile(`(?i)aws_?secret_?(?:access_?)?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})\b`),
+		confidence:        nf.ConfidenceLikely,
+	},
+	{
+		displayName:       "GitHub Token",
+		nightfallDetector: NightfallDetectorAPIKey,
+		pattern:           regexp.MustCompile(`\b((?:ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36}|github_pat_[A-Za-z0-9_]{82})\b`),
+		confidence:        nf.ConfidenceVeryLikely,
+	},
+	{
+		displayName:       "Slack Token",
+		nightfallDetector: NightfallDetectorAPIKey,
+		pattern:           regexp.MustCompile(`\b(xox[abposr]-[0-9A-Za-z-]{10,})\b`),
+		confidence:        nf.ConfidenceVeryLikely,
+	},
+	{
+		displayName:       "Slack Webhook",
+		nightfallDetector: NightfallDetectorAPIKey,
+		pattern:           regexp.MustCompile(`(https://hooks\.slack\.com/services/T[A-Za-z0-9]+/B[A-Za-z0-9]+/[A-Za-z0-9]+)`),
+		confidence:        nf.ConfidenceVeryLikely,
+	},
+	{
+		displayName:       "Google API Key",
+		nightfallDetector: NightfallDetectorAPIKey,
+		pattern:           regexp.MustCompile(`\b(AIza[0-9A-Za-z_-]{35})\b`),
+		confidence:        nf.ConfidenceLikely,
+	},
+	{
+		displayName:       "Stripe API Key",
+		nightfallDetector: NightfallDetectorAPIKey,
+		pattern:           regexp.MustCompile(`\b((?:sk|rk)_live_[0-9A-Za-z]{24,})\b`),
+		confidence:        nf.ConfidenceVeryLikely,
+	},
+	{
+		displayName:       "JSON Web Token",
+		nightfallDetector: NightfallDetectorAPIKey,
+		pattern:           regexp.MustCompile(`\b(eyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,})`),
+		confidence:        nf.ConfidenceLikely,
+	},
+	{
+		displayName:       "Private Key",
+		nightfallDetector: NightfallDetectorCryptographicKey,
+		pattern:           regexp.MustCompile(`(-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----)`),
+		confidence:        nf.ConfidenceVeryLikely,
+	},
+	{
+		displayName:       "Password In Code",
+		nightfallDetector: NightfallDetectorPasswordInCode,
+		pattern:           regexp.MustCompile(`(?i)(?:password|passwd|pwd|secret)["']?\s*(?::=|=|:)\s*["']([^"'\s]{6,})["']`),
+		confidence:        nf.ConfidencePossible,
+	},
+}
+
+// getBuiltinDetectors returns the built-in detectors standing in for a Nightfall detector
+func getBuiltinDetectors(nightfallDetector string) []*builtinDetector {
+	detectors := make([]*builtinDetector, 0)
+	for _, d := range builtinDetectors {
+		if d.nightfallDetector == nightfallDetector {
+			detectors = append(detectors, d)
+		}
+	}
+	return detectors
+}
@@ -0,0 +1,144 @@
+package localscanner
+
+import (
+	"context"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	nf "github.com/nightfallai/nightfall-go-sdk"
+)
+
+// Named charsets of the entropy detector, any other value is used as the set of characters itself
+const (
+	EntropyCharsetBase64 = "base64"
+	EntropyCharsetHex    = "hex"
+)
+
+const (
+	// EntropyDetectorName is the display name of findings of the entropy detector
+	EntropyDetectorName = "High Entropy String"
+
+	base64Chars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/=-_"
+	hexChars    = "0123456789abcdefABCDEF"
+
+	// a string of n characters has at most log2(n) bits per character, so the base64
+	// threshold can only be reached by strings of at least 2^4.5, about 23 characters
+	defaultEntropyMinLength = 20
+	defaultBase64MinLength  = 24
+	// thresholds commonly used for base64 and hex secrets, a random string reaches
+	// close to log2 of the charset size bits per character
+	defaultBase64Threshold = 4.5
+	defaultHexThreshold    = 3.0
+	defaultCustomThreshold = 4.0
+)
+
+// EntropyDetector finds strings of a charset whose Shannon entropy reaches a threshold,
+// catching random tokens that no named detector knows about
+type EntropyDetector struct {
+	charset   map[rune]bool
+	minLength int
+	threshold float64
+}
+
+// NewEntropyDetector creates an EntropyDetector, zero values select the defaults of the charset
+func NewEntropyDetector(charset string, minLength int, threshold float64) *EntropyDetector {
+	chars := charset
+	defaultMinLength := defaultEntropyMinLength
+	defaultThreshold := defaultCustomThreshold
+	switch strings.ToLower(charset) {
+	case "", EntropyCharsetBase64:
+		chars = base64Chars
+		defaultMinLength = defaultBase64MinLength
+		defaultThreshold = defaultBase64Threshold
+	case EntropyCharsetHex:
+		chars = hexChars
+		defaultThreshold = defaultHexThreshold
+	}
+	if minLength <= 0 {
+		minLength = defaultMinLength
+	}
+	if threshold <= 0 {
+		threshold = defaultThreshold
+	}
+	d := &EntropyDetector{
+		charset:   make(map[rune]bool, len(chars)),
+		minLength: minLength,
+		threshold: threshold,
+	}
+	for _, r := range chars {
+		d.charset[r] = true
+	}
+	return d
+}
+
+// Scan returns a finding for every run of charset characters in content that is at least
+// minLength characters long and reaches the entropy threshold
+func (d *EntropyDetector) Scan(content string, redactionConfig *nf.RedactionConfig) []*nf.Finding {
+	findings := make([]*nf.Finding, 0)
+	start, codepoint, startCodepoint := -1, 0, 0
+	for i, r := range content {
+		if d.charset[r] {
+			if start < 0 {
+				start, startCodepoint = i, codepoint
+			}
+		} else if start >= 0 {
+			findings = d.appendFinding(findings, content[start:i], start, startCodepoint, redactionConfig)
+			start = -1
+		}
+		codepoint++
+	}
+	if start >= 0 {
+		findings = d.appendFinding(findings, content[start:], start, startCodepoint, redactionConfig)
+	}
+	return findings
+}
+
+// ScanText scans each payload item of the request, so the entropy detector can be used as a detection engine
+func (d *EntropyDetector) ScanText(ctx context.Context, request *nf.ScanTextRequest) (*nf.ScanTextResponse, error) {
+	var redactionConfig *nf.RedactionConfig
+	if request.Config != nil {
+		redactionConfig = request.Config.DefaultRedactionConfig
+	}
+	findings := make([][]*nf.Finding, len(request.Payload))
+	for i, item := range request.Payload {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		findings[i] = d.Scan(item, redactionConfig)
+	}
+	return &nf.ScanTextResponse{Findings: findings}, nil
+}
+
+func (d *EntropyDetector) appendFinding(findings []*nf.Finding, token string, start, startCodepoint int, redactionConfig *nf.RedactionConfig) []*nf.Finding {
+	length := utf8.RuneCountInString(token)
+	if length < d.minLength || shannonEntropy(token) < d.threshold {
+		return findings
+	}
+	return append(findings, &nf.Finding{
+		Finding:         token,
+		RedactedFinding: redact(token, EntropyDetectorName, redactionConfig),
+		Detector:        nf.DetectorMetadata{DisplayName: EntropyDetectorName},
+		Confidence:      string(nf.ConfidencePossible),
+		Location: &nf.Location{
+			ByteRange:      &nf.Range{Start: int64(start), End: int64(start + len(token))},
+			CodepointRange: &nf.Range{Start: int64(startCodepoint), End: int64(startCodepoint + length)},
+		},
+	})
+}
+
+// shannonEntropy returns the entropy of s in bits per character
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
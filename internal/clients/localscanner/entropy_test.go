@@ -0,0 +1,123 @@
+package localscanner
+
+import (
+	"context"
+	"testing"
+
+	nf "github.com/nightfallai/nightfall-go-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	randomToken = "q8Zt3LmX0vR7pK2sWy9NcB4fHj6D"
+	hexToken    = "9f86d081884c7d659a2feaa0c55ad015"
+)
+
+func TestShannonEntropy(t *testing.T) {
+	assert.Equal(t, 0.0, shannonEntropy("aaaaaaaa"), "single character has no entropy")
+	assert.Equal(t, 1.0, shannonEntropy("abababab"), "two equally likely characters have one bit")
+	assert.Equal(t, 4.0, shannonEntropy(hexChars[:16]), "sixteen distinct characters have four bits")
+}
+
+func TestEntropyDetectorScan(t *testing.T) {
+	tests := []struct {
+		haveDetector *EntropyDetector
+		haveContent  string
+		wantFindings []string
+		desc         string
+	}{
+		{
+			haveDetector: NewEntropyDetector("", 0, 0),
+			haveContent:  "token = '" + randomToken + "' ",
+			wantFindings: []string{randomToken},
+			desc:         "random base64 token",
+		},
+		{
+			haveDetector: NewEntropyDetector("", 0, 0),
+			haveContent:  "const AbstractSingletonProxyFactoryBean = aaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+			wantFindings: []string{},
+			desc:         "identifiers and repeated characters",
+		},
+		{
+			haveDetector: NewEntropyDetector("", 40, 0),
+			haveContent:  randomToken,
+			wantFindings: []string{},
+			desc:         "shorter than min length",
+		},
+		{
+			haveDetector: NewEntropyDetector(EntropyCharsetHex, 0, 0),
+			haveContent:  "sha256:" + hexToken,
+			wantFindings: []string{hexToken},
+			desc:         "hex charset",
+		},
+		{
+			haveDetector: NewEntropyDetector("abc", 6, 1.5),
+			haveContent:  "xx abcabc yy aaaaaa",
+			wantFindings: []string{"abcabc"},
+			desc:         "custom charset",
+		},
+	}
+	for _, tt := range tests {
+		findings := tt.haveDetector.Scan(tt.haveContent, nil)
+		assert.Equal(t, tt.wantFindings, findingValues(findings), tt.desc)
+	}
+}
+
+func TestEntropyDetectorDefaultMinLength(t *testing.T) {
+	tests := []struct {
+		haveDetector *EntropyDetector
+		haveContent  string
+		wantFindings []string
+		desc         string
+	}{
+		{
+			haveDetector: NewEntropyDetector("", 0, 0),
+			haveContent:  randomToken[:defaultBase64MinLength],
+			wantFindings: []string{randomToken[:defaultBase64MinLength]},
+			desc:         "base64 token of exactly the default min length",
+		},
+		{
+			haveDetector: NewEntropyDetector("", 0, 0),
+			haveContent:  randomToken[:defaultBase64MinLength-1],
+			wantFindings: []string{},
+			desc:         "base64 token one character shorter than the default min length",
+		},
+		{
+			haveDetector: NewEntropyDetector(EntropyCharsetHex, 0, 0),
+			haveContent:  hexToken[:defaultEntropyMinLength],
+			wantFindings: []string{hexToken[:defaultEntropyMinLength]},
+			desc:         "hex token of exactly the default min length",
+		},
+	}
+	for _, tt := range tests {
+		findings := tt.haveDetector.Scan(tt.haveContent, nil)
+		assert.Equal(t, tt.wantFindings, findingValues(findings), tt.desc)
+	}
+}
+
+func TestEntropyDetectorScanText(t *testing.T) {
+	request := &nf.ScanTextRequest{Payload: []string{"package main", "token = " + randomToken}}
+	resp, err := NewEntropyDetector("", 0, 0).ScanText(context.Background(), request)
+	assert.NoError(t, err, "unexpected error")
+	if assert.Len(t, resp.Findings, 2, "there should be findings for every payload item") {
+		assert.Equal(t, []string{}, findingValues(resp.Findings[0]), "incorrect findings")
+		assert.Equal(t, []string{randomToken}, findingValues(resp.Findings[1]), "incorrect findings")
+	}
+}
+
+func TestEntropyDetectorFinding(t *testing.T) {
+	redactionConfig := &nf.RedactionConfig{MaskConfig: &nf.MaskConfig{NumCharsToLeaveUnmasked: 2}}
+	findings := NewEntropyDetector("", 0, 0).Scan("é "+randomToken, redactionConfig)
+	if assert.Len(t, findings, 1, "expected a single finding") {
+		assert.Equal(t, &nf.Finding{
+			Finding:         randomToken,
+			RedactedFinding: "q8**************************",
+			Detector:        nf.DetectorMetadata{DisplayName: EntropyDetectorName},
+			Confidence:      string(nf.ConfidencePossible),
+			Location: &nf.Location{
+				ByteRange:      &nf.Range{Start: 3, End: 31},
+				CodepointRange: &nf.Range{Start: 2, End: 30},
+			},
+		}, findings[0], "incorrect finding")
+	}
+}
@@ -0,0 +1,326 @@
+package localscanner
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	nf "github.com/nightfallai/nightfall-go-sdk"
+)
+
+const (
+	// findings of user defined regexes and word lists are exact matches
+	customDetectorConfidence = nf.ConfidenceVeryLikely
+	// fallbackRuleName is reported when no detection rule could be evaluated offline
+	fallbackRuleName = "built-in detectors"
+)
+
+var confidenceLevels = map[nf.Confidence]int{
+	nf.ConfidenceVeryUnlikely: 1,
+	nf.ConfidenceUnlikely:     2,
+	nf.ConfidencePossible:     3,
+	nf.ConfidenceLikely:       4,
+	nf.ConfidenceVeryLikely:   5,
+}
+
+// Scanner evaluates detection rules in-process without calling the Nightfall API.
+// Regex and word list detectors are evaluated as configured, Nightfall detectors
+// are approximated by built-in regexes where one exists and skipped otherwise.
+// Detection rules referenced by UUID only exist in the Nightfall API and are skipped;
+// if no detector can be evaluated every built-in detector is used instead.
+type Scanner struct{}
+
+// NewScanner creates a new local Scanner
+func NewScanner() *Scanner {
+	return &Scanner{}
+}
+
+type matcher struct {
+	displayName string
+	pattern     *regexp.Regexp
+	confidence  nf.Confidence
+}
+
+type compiledDetector struct {
+	detectorUUID    string
+	minNumFindings  int
+	minConfidence   nf.Confidence
+	redactionConfig *nf.RedactionConfig
+	matchers        []*matcher
+}
+
+type compiledRule struct {
+	name      string
+	logicalOp nf.LogicalOp
+	detectors []*compiledDetector
+}
+
+// ScanText scans each payload item with the detection rules of the request
+func (s *Scanner) ScanText(ctx context.Context, request *nf.ScanTextRequest) (*nf.ScanTextResponse, error) {
+	var config nf.Config
+	if request.Config != nil {
+		config = *request.Config
+	}
+	rules, err := compileRules(config.DetectionRules)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		rules = []*compiledRule{builtinRule()}
+	}
+
+	findings := make([][]*nf.Finding, len(request.Payload))
+	for i, item := range request.Payload {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		findings[i] = make([]*nf.Finding, 0)
+		for _, rule := range rules {
+			findings[i] = append(findings[i], rule.scan(item, config.DefaultRedactionConfig)...)
+		}
+	}
+	return &nf.ScanTextResponse{Findings: findings}, nil
+}
+
+// UnsupportedDetectors lists the detectors of the rules that cannot be evaluated offline
+func UnsupportedDetectors(rules []nf.DetectionRule) []string {
+	unsupported := make([]string, 0)
+	for _, rule := range rules {
+		for _, d := range rule.Detectors {
+			if d.DetectorType == nf.DetectorTypeNightfallDetector && len(getBuiltinDetectors(d.NightfallDetector)) == 0 {
+				unsupported = append(unsupported, d.NightfallDetector)
+			}
+		}
+	}
+	return unsupported
+}
+
+func compileRules(rules []nf.DetectionRule) ([]*compiledRule, error) {
+	compiled := make([]*compiledRule, 0, len(rules))
+	for _, rule := range rules {
+		cr := &compiledRule{
+			name:      rule.Name,
+			logicalOp: rule.LogicalOp,
+			detectors: make([]*compiledDetector, 0, len(rule.Detectors)),
+		}
+		for _, d := range rule.Detectors {
+			matchers, err := compileMatchers(d)
+			if err != nil {
+				return nil, fmt.Errorf("detection rule %q: %w", rule.Name, err)
+			}
+			if len(matchers) == 0 {
+				continue
+			}
+			cr.detectors = append(cr.detectors, &compiledDetector{
+				detectorUUID:    d.DetectorUUID,
+				minNumFindings:  d.MinNumFindings,
+				minConfidence:   d.MinConfidence,
+				redactionConfig: d.RedactionConfig,
+				matchers:        matchers,
+			})
+		}
+		if len(cr.detectors) > 0 {
+			compiled = append(compiled, cr)
+		}
+	}
+	return compiled, nil
+}
+
+func compileMatchers(d nf.Detector) ([]*matcher, error) {
+	switch d.DetectorType {
+	case nf.DetectorTypeRegex:
+		if d.Regex == nil || d.Regex.Pattern == "" {
+			return nil, fmt.Errorf("regex detector %q is missing a pattern", d.DisplayName)
+		}
+		pattern := d.Regex.Pattern
+		if !d.Regex.IsCaseSensitive {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex for detector %q: %w", d.DisplayName, err)
+		}
+		return []*matcher{{displayName: d.DisplayName, pattern: re, confidence: customDetectorConfidence}}, nil
+	case nf.DetectorTypeWordList:
+		if d.WordList == nil || len(d.WordList.Values) == 0 {
+			return nil, fmt.Errorf("word list detector %q has no values", d.DisplayName)
+		}
+		re, err := compileWordList(d.WordList)
+		if err != nil {
+			return nil, fmt.Errorf("invalid word list for detector %q: %w", d.DisplayName, err)
+		}
+		return []*matcher{{displayName: d.DisplayName, pattern: re, confidence: customDetectorConfidence}}, nil
+	case nf.DetectorTypeNightfallDetector:
+		builtins := getBuiltinDetectors(d.NightfallDetector)
+		matchers := make([]*matcher, len(builtins))
+		for i, b := range builtins {
+			matchers[i] = &matcher{displayName: b.displayName, pattern: b.pattern, confidence: b.confidence}
+		}
+		return matchers, nil
+	default:
+		return nil, nil
+	}
+}
+
+// compileWordList matches any of the words, only at word boundaries where the word starts or ends with a word character
+func compileWordList(wordList *nf.WordList) (*regexp.Regexp, error) {
+	words := make([]string, 0, len(wordList.Values))
+	for _, w := range wordList.Values {
+		if w == "" {
+			continue
+		}
+		word := regexp.QuoteMeta(w)
+		if first, _ := utf8.DecodeRuneInString(w); isWordChar(first) {
+			word = `\b` + word
+		}
+		if last, _ := utf8.DecodeLastRuneInString(w); isWordChar(last) {
+			word += `\b`
+		}
+		words = append(words, word)
+	}
+	pattern := "(" + strings.Join(words, "|") + ")"
+	if !wordList.IsCaseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+func isWordChar(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func builtinRule() *compiledRule {
+	rule := &compiledRule{
+		name:      fallbackRuleName,
+		logicalOp: nf.LogicalOpAny,
+		detectors: make([]*compiledDetector, len(builtinDetectors)),
+	}
+	for i, b := range builtinDetectors {
+		rule.detectors[i] = &compiledDetector{
+			matchers: []*matcher{{displayName: b.displayName, pattern: b.pattern, confidence: b.confidence}},
+		}
+	}
+	return rule
+}
+
+// scan returns the findings of the rule in item. With the ALL logical operator
+// every detector must reach its minimum number of findings for the rule to match.
+func (r *compiledRule) scan(item string, defaultRedactionConfig *nf.RedactionConfig) []*nf.Finding {
+	findings := make([]*nf.Finding, 0)
+	for _, d := range r.detectors {
+		detectorFindings := d.scan(item, r.name, defaultRedactionConfig)
+		minNumFindings := d.minNumFindings
+		if minNumFindings < 1 {
+			minNumFindings = 1
+		}
+		if len(detectorFindings) < minNumFindings {
+			if r.logicalOp == nf.LogicalOpAll {
+				return []*nf.Finding{}
+			}
+			continue
+		}
+		findings = append(findings, detectorFindings...)
+	}
+	return findings
+}
+
+func (d *compiledDetector) scan(item, ruleName string, defaultRedactionConfig *nf.RedactionConfig) []*nf.Finding {
+	redactionConfig := d.redactionConfig
+	if redactionConfig == nil {
+		redactionConfig = defaultRedactionConfig
+	}
+	findings := make([]*nf.Finding, 0)
+	for _, m := range d.matchers {
+		if !meetsConfidence(m.confidence, d.minConfidence) {
+			continue
+		}
+		for _, loc := range m.pattern.FindAllStringSubmatchIndex(item, -1) {
+			start, end := loc[0], loc[1]
+			// report the first capture group if the pattern has one
+			if len(loc) >= 4 && loc[2] >= 0 {
+				start, end = loc[2], loc[3]
+			}
+			if start == end {
+				continue
+			}
+			fragment := item[start:end]
+			codepointStart := utf8.RuneCountInString(item[:start])
+			finding := &nf.Finding{
+				Finding:         fragment,
+				RedactedFinding: redact(fragment, m.displayName, redactionConfig),
+				Detector: nf.DetectorMetadata{
+					DisplayName:  m.displayName,
+					DetectorUUID: d.detectorUUID,
+				},
+				Confidence: string(m.confidence),
+				Location: &nf.Location{
+					ByteRange: &nf.Range{Start: int64(start), End: int64(end)},
+					CodepointRange: &nf.Range{
+						Start: int64(codepointStart),
+						End:   int64(codepointStart + utf8.RuneCountInString(fragment)),
+					},
+				},
+			}
+			if ruleName != "" && ruleName != fallbackRuleName {
+				finding.MatchedDetectionRules = []string{ruleName}
+			}
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+func meetsConfidence(confidence, minConfidence nf.Confidence) bool {
+	minLevel, ok := confidenceLevels[minConfidence]
+	if !ok {
+		return true
+	}
+	return confidenceLevels[confidence] >= minLevel
+}
+
+// redact applies the mask, substitution or info type substitution redaction configs.
+// Encryption requires the Nightfall API, so no redacted finding is returned for it.
+func redact(fragment, displayName string, config *nf.RedactionConfig) string {
+	switch {
+	case config == nil:
+		return ""
+	case config.SubstitutionConfig != nil:
+		return config.SubstitutionConfig.SubstitutionPhrase
+	case config.InfoTypeSubstitutionConfig != nil:
+		return fmt.Sprintf("[%s]", displayName)
+	case config.MaskConfig != nil:
+		return mask(fragment, config.MaskConfig)
+	default:
+		return ""
+	}
+}
+
+func mask(fragment string, config *nf.MaskConfig) string {
+	maskingChar := config.MaskingChar
+	if maskingChar == "" {
+		maskingChar = "*"
+	}
+	ignore := make(map[string]bool, len(config.CharsToIgnore))
+	for _, c := range config.CharsToIgnore {
+		ignore[c] = true
+	}
+	runes := []rune(fragment)
+	unmasked := config.NumCharsToLeaveUnmasked
+	masked := make([]string, len(runes))
+	for i, r := range runes {
+		// by default characters are masked right to left, leaving the start of the finding visible
+		pos := i
+		if config.MaskLeftToRight {
+			pos = len(runes) - 1 - i
+		}
+		if pos < unmasked || ignore[string(r)] {
+			masked[i] = string(r)
+		} else {
+			masked[i] = maskingChar
+		}
+	}
+	return strings.Join(masked, "")
+}
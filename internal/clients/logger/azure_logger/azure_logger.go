@@ -0,0 +1,56 @@
+package azurelogger
+
+import (
+	"log"
+	"os"
+
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/logger"
+)
+
+// Azure Pipelines logging commands
+// https://learn.microsoft.com/en-us/azure/devops/pipelines/scripts/logging-commands
+const (
+	debugPrefix   = "##[debug]"
+	warningPrefix = "##vso[task.logissue type=warning]"
+	errorPrefix   = "##vso[task.logissue type=error]"
+)
+
+// AzureLogger logger for Azure Pipelines
+type AzureLogger struct {
+	log *log.Logger
+}
+
+// NewDefaultAzureLogger creates an Azure Pipelines logger
+// with the default log.Logger set
+func NewDefaultAzureLogger() logger.Logger {
+	return NewAzureLogger(log.New(os.Stdout, "", 0))
+}
+
+// NewAzureLogger creates a new AzureLogger
+func NewAzureLogger(logger *log.Logger) logger.Logger {
+	return &AzureLogger{
+		log: logger,
+	}
+}
+
+// Debug logs a debug message
+// to view debug logs the pipeline variable
+// System.Debug must be set to true
+func (l *AzureLogger) Debug(msg string) {
+	l.log.Printf("%s%s\n", debugPrefix, msg)
+}
+
+// Info logs an info message
+func (l *AzureLogger) Info(msg string) {
+	l.log.Println(msg)
+}
+
+// Warning logs a warning message
+func (l *AzureLogger) Warning(msg string) {
+	l.log.Printf("%s%s\n", warningPrefix, msg)
+}
+
+// Error logs a error message
+func (l *AzureLogger) Error(msg string) {
+	l.log.Printf("%s%s\n", errorPrefix, msg)
+}
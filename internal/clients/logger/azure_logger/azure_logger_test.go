@@ -0,0 +1,71 @@
+package azurelogger_test
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/logger"
+	azurelogger "github.com/nightfallai/nightfall_code_scanner/internal/clients/logger/azure_logger"
+	"gotest.tools/assert"
+)
+
+const (
+	debugPrefix   = "##[debug]"
+	warningPrefix = "##vso[task.logissue type=warning]"
+	errorPrefix   = "##vso[task.logissue type=error]"
+)
+
+// Test case strings used by all tests
+var tests = []string{"test", "汉字 Hello 123", "*** this has stuff"}
+
+func setupTest() (logger.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	logger := log.New(os.Stdout, "", 0)
+	logger.SetOutput(&buf)
+
+	azLogger := azurelogger.NewAzureLogger(logger)
+	return azLogger, &buf
+}
+
+func TestDebug(t *testing.T) {
+	azLogger, buf := setupTest()
+
+	for _, tt := range tests {
+		buf.Reset()
+		azLogger.Debug(tt)
+		assert.Equal(t, fmt.Sprintf("%s%s\n", debugPrefix, tt), buf.String())
+	}
+}
+
+func TestInfo(t *testing.T) {
+	azLogger, buf := setupTest()
+
+	for _, tt := range tests {
+		buf.Reset()
+		azLogger.Info(tt)
+		assert.Equal(t, fmt.Sprintf("%s\n", tt), buf.String())
+	}
+}
+
+func TestWarning(t *testing.T) {
+	azLogger, buf := setupTest()
+
+	for _, tt := range tests {
+		buf.Reset()
+		azLogger.Warning(tt)
+		assert.Equal(t, fmt.Sprintf("%s%s\n", warningPrefix, tt), buf.String())
+	}
+}
+
+func TestError(t *testing.T) {
+	azLogger, buf := setupTest()
+
+	for _, tt := range tests {
+		buf.Reset()
+		azLogger.Error(tt)
+		assert.Equal(t, fmt.Sprintf("%s%s\n", errorPrefix, tt), buf.String())
+	}
+}
@@ -0,0 +1,53 @@
+package bitbucketlogger
+
+import (
+	"log"
+	"os"
+
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/logger"
+)
+
+const (
+	debugPrefix   = "[DEBUG]"
+	infoPrefix    = "[INFO]"
+	warningPrefix = "[WARNING]"
+	errorPrefix   = "[ERROR]"
+)
+
+// BitbucketLogger logger for Bitbucket Pipelines
+type BitbucketLogger struct {
+	log *log.Logger
+}
+
+// NewDefaultBitbucketLogger creates a Bitbucket Pipelines logger
+// with the default log.Logger set
+func NewDefaultBitbucketLogger() logger.Logger {
+	return NewBitbucketLogger(log.New(os.Stdout, "", 0))
+}
+
+// NewBitbucketLogger creates a new BitbucketLogger
+func NewBitbucketLogger(logger *log.Logger) logger.Logger {
+	return &BitbucketLogger{
+		log: logger,
+	}
+}
+
+// Debug logs a debug message
+func (l *BitbucketLogger) Debug(msg string) {
+	l.log.Printf("%s %s\n", debugPrefix, msg)
+}
+
+// Info logs an info message
+func (l *BitbucketLogger) Info(msg string) {
+	l.log.Printf("%s %s\n", infoPrefix, msg)
+}
+
+// Warning logs a warning message
+func (l *BitbucketLogger) Warning(msg string) {
+	l.log.Printf("%s %s\n", warningPrefix, msg)
+}
+
+// Error logs a error message
+func (l *BitbucketLogger) Error(msg string) {
+	l.log.Printf("%s %s\n", errorPrefix, msg)
+}
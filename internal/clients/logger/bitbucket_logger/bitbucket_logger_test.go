@@ -0,0 +1,72 @@
+package bitbucketlogger_test
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/logger"
+	bitbucketlogger "github.com/nightfallai/nightfall_code_scanner/internal/clients/logger/bitbucket_logger"
+	"gotest.tools/assert"
+)
+
+const (
+	debugPrefix   = "[DEBUG]"
+	infoPrefix    = "[INFO]"
+	warningPrefix = "[WARNING]"
+	errorPrefix   = "[ERROR]"
+)
+
+// Test case strings used by all tests
+var tests = []string{"test", "汉字 Hello 123", "*** this has stuff"}
+
+func setupTest() (logger.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	logger := log.New(os.Stdout, "", 0)
+	logger.SetOutput(&buf)
+
+	bbLogger := bitbucketlogger.NewBitbucketLogger(logger)
+	return bbLogger, &buf
+}
+
+func TestDebug(t *testing.T) {
+	bbLogger, buf := setupTest()
+
+	for _, tt := range tests {
+		buf.Reset()
+		bbLogger.Debug(tt)
+		assert.Equal(t, fmt.Sprintf("%s %s\n", debugPrefix, tt), buf.String())
+	}
+}
+
+func TestInfo(t *testing.T) {
+	bbLogger, buf := setupTest()
+
+	for _, tt := range tests {
+		buf.Reset()
+		bbLogger.Info(tt)
+		assert.Equal(t, fmt.Sprintf("%s %s\n", infoPrefix, tt), buf.String())
+	}
+}
+
+func TestWarning(t *testing.T) {
+	bbLogger, buf := setupTest()
+
+	for _, tt := range tests {
+		buf.Reset()
+		bbLogger.Warning(tt)
+		assert.Equal(t, fmt.Sprintf("%s %s\n", warningPrefix, tt), buf.String())
+	}
+}
+
+func TestError(t *testing.T) {
+	bbLogger, buf := setupTest()
+
+	for _, tt := range tests {
+		buf.Reset()
+		bbLogger.Error(tt)
+		assert.Equal(t, fmt.Sprintf("%s %s\n", errorPrefix, tt), buf.String())
+	}
+}
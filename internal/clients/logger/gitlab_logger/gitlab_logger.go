@@ -0,0 +1,55 @@
+package gitlablogger
+
+import (
+	"log"
+	"os"
+
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/logger"
+)
+
+// GitLab job logs render ANSI escape codes, so each level is colored
+// https://docs.gitlab.com/ee/ci/yaml/script.html#add-color-codes-to-script-output
+const (
+	debugPrefix   = "\x1b[0;36m[DEBUG]\x1b[0m"
+	infoPrefix    = "\x1b[0;32m[INFO]\x1b[0m"
+	warningPrefix = "\x1b[0;33m[WARNING]\x1b[0m"
+	errorPrefix   = "\x1b[0;31m[ERROR]\x1b[0m"
+)
+
+// GitlabLogger logger for GitLab CI
+type GitlabLogger struct {
+	log *log.Logger
+}
+
+// NewDefaultGitlabLogger creates a GitLab CI logger
+// with the default log.Logger set
+func NewDefaultGitlabLogger() logger.Logger {
+	return NewGitlabLogger(log.New(os.Stdout, "", 0))
+}
+
+// NewGitlabLogger creates a new GitlabLogger
+func NewGitlabLogger(logger *log.Logger) logger.Logger {
+	return &GitlabLogger{
+		log: logger,
+	}
+}
+
+// Debug logs a debug message
+func (l *GitlabLogger) Debug(msg string) {
+	l.log.Printf("%s %s\n", debugPrefix, msg)
+}
+
+// Info logs an info message
+func (l *GitlabLogger) Info(msg string) {
+	l.log.Printf("%s %s\n", infoPrefix, msg)
+}
+
+// Warning logs a warning message
+func (l *GitlabLogger) Warning(msg string) {
+	l.log.Printf("%s %s\n", warningPrefix, msg)
+}
+
+// Error logs a error message
+func (l *GitlabLogger) Error(msg string) {
+	l.log.Printf("%s %s\n", errorPrefix, msg)
+}
@@ -0,0 +1,72 @@
+package gitlablogger_test
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/logger"
+	gitlablogger "github.com/nightfallai/nightfall_code_scanner/internal/clients/logger/gitlab_logger"
+	"gotest.tools/assert"
+)
+
+const (
+	debugPrefix   = "\x1b[0;36m[DEBUG]\x1b[0m"
+	infoPrefix    = "\x1b[0;32m[INFO]\x1b[0m"
+	warningPrefix = "\x1b[0;33m[WARNING]\x1b[0m"
+	errorPrefix   = "\x1b[0;31m[ERROR]\x1b[0m"
+)
+
+// Test case strings used by all tests
+var tests = []string{"test", "汉字 Hello 123", "*** this has stuff"}
+
+func setupTest() (logger.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	logger := log.New(os.Stdout, "", 0)
+	logger.SetOutput(&buf)
+
+	glLogger := gitlablogger.NewGitlabLogger(logger)
+	return glLogger, &buf
+}
+
+func TestDebug(t *testing.T) {
+	glLogger, buf := setupTest()
+
+	for _, tt := range tests {
+		buf.Reset()
+		glLogger.Debug(tt)
+		assert.Equal(t, fmt.Sprintf("%s %s\n", debugPrefix, tt), buf.String())
+	}
+}
+
+func TestInfo(t *testing.T) {
+	glLogger, buf := setupTest()
+
+	for _, tt := range tests {
+		buf.Reset()
+		glLogger.Info(tt)
+		assert.Equal(t, fmt.Sprintf("%s %s\n", infoPrefix, tt), buf.String())
+	}
+}
+
+func TestWarning(t *testing.T) {
+	glLogger, buf := setupTest()
+
+	for _, tt := range tests {
+		buf.Reset()
+		glLogger.Warning(tt)
+		assert.Equal(t, fmt.Sprintf("%s %s\n", warningPrefix, tt), buf.String())
+	}
+}
+
+func TestError(t *testing.T) {
+	glLogger, buf := setupTest()
+
+	for _, tt := range tests {
+		buf.Reset()
+		glLogger.Error(tt)
+		assert.Equal(t, fmt.Sprintf("%s %s\n", errorPrefix, tt), buf.String())
+	}
+}
@@ -0,0 +1,85 @@
+package locallogger
+
+import (
+	"log"
+	"os"
+
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/logger"
+)
+
+// ANSI color codes used when writing to a terminal
+const (
+	ColorReset  = "\x1b[0m"
+	ColorRed    = "\x1b[0;31m"
+	ColorYellow = "\x1b[0;33m"
+	ColorGreen  = "\x1b[0;32m"
+	ColorCyan   = "\x1b[0;36m"
+
+	debugPrefix   = "[DEBUG]"
+	infoPrefix    = "[INFO]"
+	warningPrefix = "[WARNING]"
+	errorPrefix   = "[ERROR]"
+
+	noColorEnvVar = "NO_COLOR"
+)
+
+// LocalLogger logger for running nightfalldlp from a terminal
+type LocalLogger struct {
+	log   *log.Logger
+	color bool
+}
+
+// NewDefaultLocalLogger creates a local logger writing to stderr,
+// colored if stderr is a terminal
+func NewDefaultLocalLogger() logger.Logger {
+	return NewLocalLogger(log.New(os.Stderr, "", 0), UseColor(os.Stderr))
+}
+
+// NewLocalLogger creates a new LocalLogger
+func NewLocalLogger(logger *log.Logger, color bool) logger.Logger {
+	return &LocalLogger{
+		log:   logger,
+		color: color,
+	}
+}
+
+// UseColor determines if ANSI colors should be written to f. Colors are
+// disabled when f is not a terminal or NO_COLOR is set https://no-color.org
+func UseColor(f *os.File) bool {
+	if _, ok := os.LookupEnv(noColorEnvVar); ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Colorize wraps s in the given color if color is enabled
+func Colorize(s, color string, enabled bool) string {
+	if !enabled {
+		return s
+	}
+	return color + s + ColorReset
+}
+
+// Debug logs a debug message
+func (l *LocalLogger) Debug(msg string) {
+	l.log.Printf("%s %s\n", Colorize(debugPrefix, ColorCyan, l.color), msg)
+}
+
+// Info logs an info message
+func (l *LocalLogger) Info(msg string) {
+	l.log.Printf("%s %s\n", Colorize(infoPrefix, ColorGreen, l.color), msg)
+}
+
+// Warning logs a warning message
+func (l *LocalLogger) Warning(msg string) {
+	l.log.Printf("%s %s\n", Colorize(warningPrefix, ColorYellow, l.color), msg)
+}
+
+// Error logs a error message
+func (l *LocalLogger) Error(msg string) {
+	l.log.Printf("%s %s\n", Colorize(errorPrefix, ColorRed, l.color), msg)
+}
@@ -0,0 +1,83 @@
+package locallogger_test
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/logger"
+	locallogger "github.com/nightfallai/nightfall_code_scanner/internal/clients/logger/local_logger"
+	"gotest.tools/assert"
+)
+
+const (
+	debugPrefix        = "[DEBUG]"
+	infoPrefix         = "[INFO]"
+	warningPrefix      = "[WARNING]"
+	errorPrefix        = "[ERROR]"
+	coloredErrorPrefix = "\x1b[0;31m[ERROR]\x1b[0m"
+)
+
+// Test case strings used by all tests
+var tests = []string{"test", "汉字 Hello 123", "*** this has stuff"}
+
+func setupTest(color bool) (logger.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	logger := log.New(os.Stdout, "", 0)
+	logger.SetOutput(&buf)
+
+	lLogger := locallogger.NewLocalLogger(logger, color)
+	return lLogger, &buf
+}
+
+func TestDebug(t *testing.T) {
+	lLogger, buf := setupTest(false)
+
+	for _, tt := range tests {
+		buf.Reset()
+		lLogger.Debug(tt)
+		assert.Equal(t, fmt.Sprintf("%s %s\n", debugPrefix, tt), buf.String())
+	}
+}
+
+func TestInfo(t *testing.T) {
+	lLogger, buf := setupTest(false)
+
+	for _, tt := range tests {
+		buf.Reset()
+		lLogger.Info(tt)
+		assert.Equal(t, fmt.Sprintf("%s %s\n", infoPrefix, tt), buf.String())
+	}
+}
+
+func TestWarning(t *testing.T) {
+	lLogger, buf := setupTest(false)
+
+	for _, tt := range tests {
+		buf.Reset()
+		lLogger.Warning(tt)
+		assert.Equal(t, fmt.Sprintf("%s %s\n", warningPrefix, tt), buf.String())
+	}
+}
+
+func TestError(t *testing.T) {
+	lLogger, buf := setupTest(false)
+
+	for _, tt := range tests {
+		buf.Reset()
+		lLogger.Error(tt)
+		assert.Equal(t, fmt.Sprintf("%s %s\n", errorPrefix, tt), buf.String())
+	}
+}
+
+func TestErrorColored(t *testing.T) {
+	lLogger, buf := setupTest(true)
+
+	for _, tt := range tests {
+		buf.Reset()
+		lLogger.Error(tt)
+		assert.Equal(t, fmt.Sprintf("%s %s\n", coloredErrorPrefix, tt), buf.String())
+	}
+}
@@ -0,0 +1,153 @@
+package nightfall
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	nf "github.com/nightfallai/nightfall-go-sdk"
+)
+
+// resultCacheVersion is part of every key so that entries of an incompatible format are never read
+const resultCacheVersion = "2"
+
+// cachedFinding is the part of a finding stored in the cache. It holds what re-reporting the finding
+// needs but never the fragment that was found, nor its context, which are restored from the content
+type cachedFinding struct {
+	RedactedFinding           string              `json:"redactedFinding,omitempty"`
+	Detector                  nf.DetectorMetadata `json:"detector"`
+	Confidence                string              `json:"confidence"`
+	ByteRange                 *nf.Range           `json:"byteRange,omitempty"`
+	CodepointRange            *nf.Range           `json:"codepointRange,omitempty"`
+	MatchedDetectionRuleUUIDs []string            `json:"matchedDetectionRuleUUIDs,omitempty"`
+	MatchedDetectionRules     []string            `json:"matchedDetectionRules,omitempty"`
+	FindingMetadata           *nf.FindingMetadata `json:"findingMetadata,omitempty"`
+}
+
+// resultCache stores the findings of scanned content on disk, keyed by the hash of the content
+// and of the detection config, so that identical content is not sent to the Nightfall API again
+type resultCache struct {
+	dir        string
+	configHash string
+}
+
+// newResultCache creates a cache in dir for content scanned with the config of request
+func newResultCache(dir string, request *nf.ScanTextRequest) (*resultCache, error) {
+	config, err := json.Marshal(request.Config)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	h.Write([]byte(resultCacheVersion))
+	h.Write([]byte{0})
+	h.Write(config)
+	return &resultCache{
+		dir:        dir,
+		configHash: hex.EncodeToString(h.Sum(nil)),
+	}, nil
+}
+
+func (c *resultCache) path(content string) string {
+	h := sha256.New()
+	h.Write([]byte(c.configHash))
+	h.Write([]byte{0})
+	h.Write([]byte(content))
+	key := hex.EncodeToString(h.Sum(nil))
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
+
+// get returns the findings of content if it was already scanned with the same config.
+// An entry whose fragments can not be restored from content is treated as missing.
+func (c *resultCache) get(content string) ([]*nf.Finding, bool) {
+	data, err := ioutil.ReadFile(c.path(content))
+	if err != nil {
+		return nil, false
+	}
+	var cached []*cachedFinding
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, false
+	}
+	findings := make([]*nf.Finding, 0, len(cached))
+	for _, cf := range cached {
+		fragment, ok := contentFragment(content, cf.ByteRange, cf.CodepointRange)
+		if !ok {
+			return nil, false
+		}
+		findings = append(findings, &nf.Finding{
+			Finding:                   fragment,
+			RedactedFinding:           cf.RedactedFinding,
+			Detector:                  cf.Detector,
+			Confidence:                cf.Confidence,
+			Location:                  &nf.Location{ByteRange: cf.ByteRange, CodepointRange: cf.CodepointRange},
+			MatchedDetectionRuleUUIDs: cf.MatchedDetectionRuleUUIDs,
+			MatchedDetectionRules:     cf.MatchedDetectionRules,
+			FindingMetadata:           cf.FindingMetadata,
+		})
+	}
+	return findings, true
+}
+
+// put stores the findings of content without their fragments, findings without a location
+// are skipped as their fragment could not be restored
+func (c *resultCache) put(content string, findings []*nf.Finding) error {
+	cached := make([]*cachedFinding, 0, len(findings))
+	for _, f := range findings {
+		if f.Location == nil || (f.Location.ByteRange == nil && f.Location.CodepointRange == nil) {
+			continue
+		}
+		cached = append(cached, &cachedFinding{
+			RedactedFinding:           f.RedactedFinding,
+			Detector:                  f.Detector,
+			Confidence:                f.Confidence,
+			ByteRange:                 f.Location.ByteRange,
+			CodepointRange:            f.Location.CodepointRange,
+			MatchedDetectionRuleUUIDs: f.MatchedDetectionRuleUUIDs,
+			MatchedDetectionRules:     f.MatchedDetectionRules,
+			FindingMetadata:           f.FindingMetadata,
+		})
+	}
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+	path := c.path(content)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	// written to a temporary file first so that concurrent runs never read a partial entry
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// contentFragment returns the fragment of content at the codepoint range, or at the byte range
+// if there is no codepoint range
+func contentFragment(content string, byteRange, codepointRange *nf.Range) (string, bool) {
+	if codepointRange != nil {
+		runes := []rune(content)
+		if codepointRange.Start < 0 || codepointRange.Start > codepointRange.End || codepointRange.End > int64(len(runes)) {
+			return "", false
+		}
+		return string(runes[codepointRange.Start:codepointRange.End]), true
+	}
+	if byteRange != nil {
+		if byteRange.Start < 0 || byteRange.Start > byteRange.End || byteRange.End > int64(len(content)) {
+			return "", false
+		}
+		return content[byteRange.Start:byteRange.End], true
+	}
+	return "", false
+}
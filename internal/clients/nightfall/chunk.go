@@ -0,0 +1,134 @@
+package nightfall
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	nf "github.com/nightfallai/nightfall-go-sdk"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+)
+
+// chunkOverlap is how many bytes consecutive chunks of a large file share, so that a
+// finding cut by the end of one chunk is found whole at the start of the next one
+const chunkOverlap = 4 * 1024
+
+// splitFileToScan splits content larger than maxSize into overlapping chunks of at most maxSize bytes.
+// Chunks end after a space when possible so that words are not split, and share the file's ContentToLineMap
+// with the offsets needed to remap their findings to the whole file.
+func splitFileToScan(file *fileToScan, maxSize, overlap int) []*fileToScan {
+	content := file.Content
+	if len(content) <= maxSize {
+		return []*fileToScan{file}
+	}
+
+	chunks := make([]*fileToScan, 0, len(content)/(maxSize-overlap)+1)
+	start, codepointStart := 0, 0
+	for {
+		end := chunkEnd(content, start, maxSize, overlap)
+		chunks = append(chunks, &fileToScan{
+			Content:          content[start:end],
+			FilePath:         file.FilePath,
+			ContentToLineMap: file.ContentToLineMap,
+			Commit:           file.Commit,
+			ByteOffset:       start,
+			CodepointOffset:  codepointStart,
+		})
+		if end == len(content) {
+			return chunks
+		}
+		next := chunkStart(content, end-overlap, end)
+		codepointStart += utf8.RuneCountInString(content[start:next])
+		start = next
+	}
+}
+
+// chunkEnd returns the end of the chunk starting at start, after the last space of the chunk if it leaves
+// enough content for the chunks to overlap, or else at the last rune boundary
+func chunkEnd(content string, start, maxSize, overlap int) int {
+	end := start + maxSize
+	if end >= len(content) {
+		return len(content)
+	}
+	if i := strings.LastIndexByte(content[start:end], ' '); i+1 > 2*overlap {
+		return start + i + 1
+	}
+	for !utf8.RuneStart(content[end]) {
+		end--
+	}
+	return end
+}
+
+// chunkStart returns the start of the chunk following the chunk ending at end, after the first space of the
+// overlap if any, or else at the first rune boundary
+func chunkStart(content string, start, end int) int {
+	if i := strings.IndexByte(content[start:end], ' '); i >= 0 {
+		return start + i + 1
+	}
+	for !utf8.RuneStart(content[start]) {
+		start++
+	}
+	return start
+}
+
+// remapFinding returns the finding with its location relative to the whole file instead of the chunk
+func remapFinding(finding *nf.Finding, file *fileToScan) *nf.Finding {
+	if finding.Location == nil || (file.ByteOffset == 0 && file.CodepointOffset == 0) {
+		return finding
+	}
+	remapped := *finding
+	location := *finding.Location
+	location.ByteRange = offsetRange(location.ByteRange, file.ByteOffset)
+	location.CodepointRange = offsetRange(location.CodepointRange, file.CodepointOffset)
+	remapped.Location = &location
+	return &remapped
+}
+
+func offsetRange(r *nf.Range, offset int) *nf.Range {
+	if r == nil {
+		return nil
+	}
+	return &nf.Range{
+		Start: r.Start + int64(offset),
+		End:   r.End + int64(offset),
+	}
+}
+
+// dedupeChunkComments drops the findings of chunked files found twice in the overlap of two chunks.
+// A finding cut by the end of a chunk overlaps the whole finding of the next chunk, and the longest is kept.
+func dedupeChunkComments(comments []*diffreviewer.Comment, chunkedFiles map[string]bool) []*diffreviewer.Comment {
+	if len(chunkedFiles) == 0 {
+		return comments
+	}
+	dedupedComments := make([]*diffreviewer.Comment, 0, len(comments))
+	for _, c := range comments {
+		if !chunkedFiles[fileKey(c.FilePath, c.Commit)] {
+			dedupedComments = append(dedupedComments, c)
+			continue
+		}
+		duplicate := false
+		for i, d := range dedupedComments {
+			if isChunkDuplicate(c, d) {
+				if rangeLength(codepointRange(c)) > rangeLength(codepointRange(d)) {
+					dedupedComments[i] = c
+				}
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			dedupedComments = append(dedupedComments, c)
+		}
+	}
+	return dedupedComments
+}
+
+func isChunkDuplicate(c, other *diffreviewer.Comment) bool {
+	r, otherRange := codepointRange(c), codepointRange(other)
+	return r != nil && otherRange != nil &&
+		c.FilePath == other.FilePath && c.Commit == other.Commit && c.DetectorName == other.DetectorName &&
+		r.Start < otherRange.End && otherRange.Start < r.End
+}
+
+func rangeLength(r *nf.Range) int64 {
+	return r.End - r.Start
+}
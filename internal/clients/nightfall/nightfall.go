@@ -4,9 +4,11 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -16,6 +18,7 @@ import (
 	"github.com/google/uuid"
 	nf "github.com/nightfallai/nightfall-go-sdk"
 	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/localscanner"
 	"github.com/nightfallai/nightfall_code_scanner/internal/clients/logger"
 	"github.com/nightfallai/nightfall_code_scanner/internal/datastructs"
 	"github.com/nightfallai/nightfall_code_scanner/internal/nightfallconfig"
@@ -28,19 +31,23 @@ const (
 	maxItemsForAPIReq = 479
 	// timeout for the total time spent sending scan requests and receiving responses for a diff
 	defaultTimeout = time.Minute * 20
-	// maximum attempts to Nightfall API upon receiving 429 Too Many Requests before failing
+	// maximum attempts to Nightfall API upon receiving 429 Too Many Requests or a server error before failing
 	maxScanAttempts = 5
-	// initial delay before re-attempting scan request
+	// initial delay before re-attempting scan request, doubled after every attempt
 	initialDelay = time.Second
 
 	maxAPIRequestSize = 500 * 1024 // 500KB
 )
 
-// Client uses the Nightfall API to scan text for findings
+var (
+	errFileTooLarge           = errors.New("file is larger than the size limit of a scan request")
+	errIncompleteScanResponse = errors.New("scan response is missing the findings of some items")
+)
+
+// Client uses the Nightfall API, and the other configured detection engines, to scan text for findings
 type Client struct {
-	APIClient interface {
-		ScanText(ctx context.Context, request *nf.ScanTextRequest) (*nf.ScanTextResponse, error)
-	}
+	// Engines scan the content in order, a finding overlapping one of a previous engine is dropped
+	Engines                []*Engine
 	DetectionRuleUUIDs     []uuid.UUID
 	DetectionRules         []nf.DetectionRule
 	MaxNumberRoutines      int
@@ -49,15 +56,14 @@ type Client struct {
 	FileInclusionList      []string
 	FileExclusionList      []string
 	DefaultRedactionConfig *nf.RedactionConfig
+	Baseline               *nightfallconfig.Baseline
+	FailurePolicy          string
 }
 
+// NewClient creates a Client scanning with the engines and command detectors of the config. The local
+// detector engine replaces the Nightfall API when no Nightfall API key is available.
 func NewClient(config nightfallconfig.Config) (*Client, error) {
-	client, err := nf.NewClient(nf.OptionAPIKey(config.NightfallAPIKey))
-	if err != nil {
-		return nil, err
-	}
-	return &Client{
-		APIClient:              client,
+	client := &Client{
 		DetectionRuleUUIDs:     config.NightfallDetectionRuleUUIDs,
 		DetectionRules:         config.NightfallDetectionRules,
 		MaxNumberRoutines:      config.NightfallMaxNumberRoutines,
@@ -66,7 +72,23 @@ func NewClient(config nightfallconfig.Config) (*Client, error) {
 		FileInclusionList:      config.FileInclusionList,
 		FileExclusionList:      config.FileExclusionList,
 		DefaultRedactionConfig: config.DefaultRedactionConfig,
-	}, nil
+		Baseline:               config.Baseline,
+		FailurePolicy:          config.FailurePolicy,
+	}
+	for _, name := range engineNames(config) {
+		engine, err := client.newEngine(name, config)
+		if err != nil {
+			return nil, err
+		}
+		client.Engines = append(client.Engines, engine)
+	}
+	for _, detector := range config.CommandDetectors {
+		client.Engines = append(client.Engines, &Engine{
+			Name:    nightfallconfig.EngineCommand + ":" + detector.Name,
+			Scanner: localscanner.NewCommandDetector(detector.Name, detector.Command, time.Duration(detector.TimeoutSeconds)*time.Second),
+		})
+	}
+	return client, nil
 }
 
 type contentToScan struct {
@@ -79,6 +101,18 @@ type fileToScan struct {
 	Content          string
 	FilePath         string
 	ContentToLineMap *datastructs.RangeMap
+	Commit           *diffreviewer.Commit
+	// ByteOffset and CodepointOffset locate the content in the file when it is a chunk of a large file
+	ByteOffset      int
+	CodepointOffset int
+}
+
+// fileKey identifies a file of a single commit, as a path is scanned once per commit when scanning git history
+func fileKey(filePath string, commit *diffreviewer.Commit) string {
+	if commit == nil {
+		return filePath
+	}
+	return commit.SHA + ":" + filePath
 }
 
 func getCommentMsg(finding *nf.Finding) string {
@@ -191,18 +225,30 @@ func createCommentsFromScanRespForFiles(inputContent []*fileToScan, resp *nf.Sca
 				// Found sensitive info
 				// Create comment if fragment is not in exclusion set
 				correspondingContent := inputContent[j]
-				exists, lineNumber, _ := correspondingContent.ContentToLineMap.Find(int(finding.Location.CodepointRange.Start))
+				finding := remapFinding(finding, correspondingContent)
+				start, end := int(finding.Location.CodepointRange.Start), int(finding.Location.CodepointRange.End)
+				exists, lineStart, lineEnd, lineNumber := correspondingContent.ContentToLineMap.FindRange(start)
 				if !exists {
 					// should not come here
 					continue
 				}
+				// the line range ends with the space joining it to the next line, a finding
+				// spanning several lines is cut at the end of its first line
+				if end > lineEnd {
+					end = lineEnd
+				}
 				findingMsg := getCommentMsg(finding)
 				findingTitle := getCommentTitle(finding)
 				c := diffreviewer.Comment{
-					FilePath:   correspondingContent.FilePath,
-					LineNumber: lineNumber,
-					Body:       findingMsg,
-					Title:      findingTitle,
+					FilePath:     correspondingContent.FilePath,
+					LineNumber:   lineNumber,
+					Body:         findingMsg,
+					Title:        findingTitle,
+					DetectorName: finding.Detector.DisplayName,
+					Finding:      finding,
+					Commit:       correspondingContent.Commit,
+					StartColumn:  start - lineStart + 1,
+					EndColumn:    end - lineStart + 1,
 				}
 				comments = append(comments, &c)
 			}
@@ -222,10 +268,12 @@ func createCommentsFromScanResp(inputContent []*contentToScan, resp *nf.ScanText
 				findingMsg := getCommentMsg(finding)
 				findingTitle := getCommentTitle(finding)
 				c := diffreviewer.Comment{
-					FilePath:   correspondingContent.FilePath,
-					LineNumber: correspondingContent.LineNumber,
-					Body:       findingMsg,
-					Title:      findingTitle,
+					FilePath:     correspondingContent.FilePath,
+					LineNumber:   correspondingContent.LineNumber,
+					Body:         findingMsg,
+					Title:        findingTitle,
+					DetectorName: finding.Detector.DisplayName,
+					Finding:      finding,
 				}
 				comments = append(comments, &c)
 			}
@@ -234,6 +282,17 @@ func createCommentsFromScanResp(inputContent []*contentToScan, resp *nf.ScanText
 	return comments
 }
 
+// filterExcludedFindings drops the findings without a fragment, or with a fragment in the token exclusion list
+func filterExcludedFindings(findings []*nf.Finding, tokenExclusionList []string) []*nf.Finding {
+	filtered := make([]*nf.Finding, 0, len(findings))
+	for _, finding := range findings {
+		if finding.Finding != "" && !isFindingInTokenExclusionList(finding.Finding, tokenExclusionList) {
+			filtered = append(filtered, finding)
+		}
+	}
+	return filtered
+}
+
 func isFindingInTokenExclusionList(fragment string, tokenExclusionList []string) bool {
 	if tokenExclusionList == nil {
 		return false
@@ -268,21 +327,52 @@ func (n *Client) buildScanRequest(items []string) *nf.ScanTextRequest {
 
 func (n *Client) scanFileContent(
 	ctx context.Context,
+	engine *Engine,
 	cts []*fileToScan,
 	requestNum int,
 	logger logger.Logger,
 ) ([]*diffreviewer.Comment, error) {
-	// Pull out content strings for request
-	items := make([]string, len(cts))
+	resp := &nf.ScanTextResponse{Findings: make([][]*nf.Finding, len(cts))}
+	// Pull out content strings for request, skipping the content already in the cache
+	items := make([]string, 0, len(cts))
+	itemIndexes := make([]int, 0, len(cts))
 	for i, item := range cts {
-		items[i] = item.Content
+		if engine.Cache != nil {
+			if findings, ok := engine.Cache.get(item.Content); ok {
+				resp.Findings[i] = findings
+				continue
+			}
+		}
+		items = append(items, item.Content)
+		itemIndexes = append(itemIndexes, i)
+	}
+	if engine.Cache != nil && len(items) < len(cts) {
+		logger.Info(fmt.Sprintf("Reused cached findings for %d of %d items in request #%d", len(cts)-len(items), len(cts), requestNum))
 	}
 
-	// send API request
-	resp, err := n.Scan(ctx, items)
-	if err != nil {
-		logger.Debug(fmt.Sprintf("Error sending request number %d with %d items: %v", requestNum, len(items), err))
-		return nil, err
+	if len(items) > 0 {
+		// send API request
+		scanResp, err := n.Scan(ctx, engine, items)
+		if err != nil {
+			logger.Error(fmt.Sprintf("Error sending request number %d with %d items: %v", requestNum, len(items), err))
+			return nil, err
+		}
+		for j, i := range itemIndexes {
+			if j >= len(scanResp.Findings) {
+				break
+			}
+			// excluded findings are dropped before caching, so they are never written to disk
+			resp.Findings[i] = filterExcludedFindings(scanResp.Findings[j], n.TokenExclusionList)
+			if engine.Cache != nil {
+				if err := engine.Cache.put(items[j], resp.Findings[i]); err != nil {
+					logger.Warning(fmt.Sprintf("Unable to cache the findings of request #%d: %v", requestNum, err))
+				}
+			}
+		}
+		if len(scanResp.Findings) < len(items) {
+			logger.Error(fmt.Sprintf("Request number %d returned findings for %d of %d items", requestNum, len(scanResp.Findings), len(items)))
+			return nil, errIncompleteScanResponse
+		}
 	}
 
 	// Determine findings from response and create comments
@@ -291,15 +381,39 @@ func (n *Client) scanFileContent(
 	return createdComments, nil
 }
 
+// scanResult holds the comments of a scan request, or the error that made it fail
+type scanResult struct {
+	Comments []*diffreviewer.Comment
+	Files    []*fileToScan
+	Err      error
+}
+
 func (n *Client) scanAllFiles(
 	ctx context.Context,
 	logger logger.Logger,
+	engine *Engine,
 	cts []*fileToScan,
-	commentCh chan<- []*diffreviewer.Comment,
+	resultCh chan<- *scanResult,
 ) {
-	defer close(commentCh)
+	defer close(resultCh)
 	blockingCh := make(chan struct{}, n.MaxNumberRoutines)
 	var wg sync.WaitGroup
+	// chunking keeps files below the request size limit, a larger file is reported as unscanned
+	scannable := make([]*fileToScan, 0, len(cts))
+	for _, fts := range cts {
+		if len(fts.Content) > maxAPIRequestSize {
+			logger.Error(fmt.Sprintf("Unable to scan %s, it is larger than the %d bytes of a scan request", fts.FilePath, maxAPIRequestSize))
+			select {
+			case resultCh <- &scanResult{Files: []*fileToScan{fts}, Err: errFileTooLarge}:
+			case <-ctx.Done():
+				return
+			}
+			continue
+		}
+		scannable = append(scannable, fts)
+	}
+	cts = scannable
+
 	requestBatches := make([][]*fileToScan, 0)
 	endIndex := 0
 	for endIndex < len(cts) {
@@ -311,11 +425,6 @@ func (n *Client) scanAllFiles(
 				break
 			}
 			size := len(cts[endIndex].Content)
-			if size > maxAPIRequestSize {
-				// file diff size is greater than supported by API platform, should not come here
-				logger.Error("terminating the scan early as large file scanning is not supported by API platform")
-				return
-			}
 			if (size + currentSize) > maxAPIRequestSize {
 				break
 			}
@@ -331,40 +440,67 @@ func (n *Client) scanAllFiles(
 	}
 
 	numRequestsRequired := len(requestBatches)
-	logger.Info(fmt.Sprintf("Sending %d requests to Nightfall API", numRequestsRequired))
+	logger.Info(fmt.Sprintf("Sending %d requests to the %s engine", numRequestsRequired, engine.Name))
 	for i := 0; i < numRequestsRequired; i++ {
 		// Use max number of items to determine content to send in request
+		select {
+		case blockingCh <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		}
 		wg.Add(1)
-		blockingCh <- struct{}{}
 		go func(loopCount int, cts []*fileToScan) {
 			defer wg.Done()
+			defer func() { <-blockingCh }()
 			if ctx.Err() != nil {
 				return
 			}
 
-			c, err := n.scanFileContent(ctx, cts, loopCount+1, logger)
-			if err != nil {
-				logger.Error(fmt.Sprintf("Unable to scan %d content items", len(cts)))
-			} else {
-				commentCh <- c
+			// scanFileContent logs the failure of the request
+			c, err := n.scanFileContent(ctx, engine, cts, loopCount+1, logger)
+			select {
+			case resultCh <- &scanResult{Comments: c, Files: cts, Err: err}:
+			case <-ctx.Done():
 			}
-			<-blockingCh
 		}(i, requestBatches[i])
 	}
 	wg.Wait()
 }
 
-func (n *Client) Scan(ctx context.Context, items []string) (*nf.ScanTextResponse, error) {
+// Scan sends the items to be scanned by the engine, retrying with exponential backoff while the API is
+// rate limiting requests or failing server side, for at most maxScanAttempts attempts
+func (n *Client) Scan(ctx context.Context, engine *Engine, items []string) (*nf.ScanTextResponse, error) {
 	request := n.buildScanRequest(items)
-	return n.APIClient.ScanText(ctx, request)
+	backoff := n.InitialRetryDelay
+	for attempt := 1; ; attempt++ {
+		resp, err := engine.Scanner.ScanText(ctx, request)
+		if err == nil || !isRetryableError(err) {
+			return resp, err
+		}
+		if attempt == maxScanAttempts {
+			return nil, fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+		select {
+		case <-time.After(retryDelay(err, backoff)):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		backoff *= 2
+	}
 }
 
 // ReviewDiff will take in a diff, chunk the contents of the diff
-// and send the chunks to the Nightfall API to determine if it
-// contains sensitive data
+// and send the chunks to the detection engines to determine if it
+// contains sensitive data. If scan requests fail, the findings of
+// the other requests are returned with a *diffreviewer.ScanFailure
 func (n *Client) ReviewDiff(ctx context.Context, logger logger.Logger, fileDiffs []*diffreviewer.FileDiff) ([]*diffreviewer.Comment, error) {
+	if n.hasEngine(nightfallconfig.EngineLocal) {
+		n.warnLocalEngineLimitations(logger)
+	}
 	fileDiffs = filterFileDiffs(fileDiffs, n.FileInclusionList, n.FileExclusionList, logger)
 	fileToScanList := make([]*fileToScan, 0, len(fileDiffs))
+	chunkedFiles := make(map[string]bool)
 
 	for _, fd := range fileDiffs {
 		file, err := getFileToScan(fd)
@@ -372,36 +508,139 @@ func (n *Client) ReviewDiff(ctx context.Context, logger logger.Logger, fileDiffs
 			return nil, err
 		}
 		if len(file.Content) > maxAPIRequestSize {
-			logger.Warning(fmt.Sprintf("unable to scan file %s as its size exceeds the supported limit of %d Kbs", file.FilePath, maxAPIRequestSize/1024))
+			chunks := splitFileToScan(file, maxAPIRequestSize, chunkOverlap)
+			logger.Info(fmt.Sprintf("Splitting file %s into %d chunks as its size exceeds the supported limit of %d Kbs", file.FilePath, len(chunks), maxAPIRequestSize/1024))
+			chunkedFiles[fileKey(file.FilePath, file.Commit)] = true
+			fileToScanList = append(fileToScanList, chunks...)
 			continue
 		}
 		fileToScanList = append(fileToScanList, file)
 	}
 
-	commentCh := make(chan []*diffreviewer.Comment)
 	newCtx, cancel := context.WithDeadline(ctx, time.Now().Add(defaultTimeout))
 	defer cancel()
 
-	go n.scanAllFiles(newCtx, logger, fileToScanList, commentCh)
-
 	comments := make([]*diffreviewer.Comment, 0)
+	var failedFiles []*fileToScan
+	var scanErr error
+	for _, engine := range n.Engines {
+		result, err := n.scanWithEngine(newCtx, logger, engine, fileToScanList)
+		if err != nil {
+			return nil, err
+		}
+		if result.Err != nil {
+			failedFiles = append(failedFiles, result.Files...)
+			scanErr = result.Err
+		}
+		comments = mergeEngineComments(comments, dedupeChunkComments(result.Comments, chunkedFiles))
+	}
+	comments = filterSuppressedComments(comments, fileDiffs, time.Now(), logger)
+	comments = filterBaselineComments(comments, n.Baseline, logger)
+	if scanErr != nil {
+		return comments, n.newScanFailure(failedFiles, scanErr)
+	}
+	return comments, nil
+}
+
+// scanWithEngine scans the files with the engine. The result holds the comments of the successful
+// requests, and the files of the failed requests with the last error returned.
+func (n *Client) scanWithEngine(ctx context.Context, logger logger.Logger, engine *Engine, files []*fileToScan) (*scanResult, error) {
+	resultCh := make(chan *scanResult)
+	go n.scanAllFiles(ctx, logger, engine, files, resultCh)
+
+	engineResult := &scanResult{Comments: make([]*diffreviewer.Comment, 0)}
 	for {
 		select {
-		case c, chOpen := <-commentCh:
+		case result, chOpen := <-resultCh:
 			if !chOpen {
-				return comments, nil
+				return engineResult, nil
+			}
+			if result.Err != nil {
+				engineResult.Files = append(engineResult.Files, result.Files...)
+				engineResult.Err = result.Err
+				continue
 			}
-			comments = append(comments, c...)
-		case <-newCtx.Done():
-			return nil, newCtx.Err()
+			engineResult.Comments = append(engineResult.Comments, result.Comments...)
+		case <-ctx.Done():
+			return nil, ctx.Err()
 		}
 	}
 }
 
+// newScanFailure lists the distinct paths of the files that could not be scanned
+func (n *Client) newScanFailure(files []*fileToScan, err error) *diffreviewer.ScanFailure {
+	seen := make(map[string]bool, len(files))
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		if !seen[file.FilePath] {
+			seen[file.FilePath] = true
+			paths = append(paths, file.FilePath)
+		}
+	}
+	sort.Strings(paths)
+	policy := n.FailurePolicy
+	if policy == "" {
+		policy = nightfallconfig.FailurePolicyFailClosed
+	}
+	return &diffreviewer.ScanFailure{
+		Policy: policy,
+		Files:  paths,
+		Err:    err,
+	}
+}
+
+// mergeEngineComments adds the findings of an engine that do not overlap a finding of the previous engines
+func mergeEngineComments(comments, engineComments []*diffreviewer.Comment) []*diffreviewer.Comment {
+	previousComments := comments
+	for _, ec := range engineComments {
+		if !overlapsComment(ec, previousComments) {
+			comments = append(comments, ec)
+		}
+	}
+	return comments
+}
+
+func overlapsComment(comment *diffreviewer.Comment, comments []*diffreviewer.Comment) bool {
+	r := codepointRange(comment)
+	if r == nil {
+		return false
+	}
+	for _, c := range comments {
+		other := codepointRange(c)
+		if c.FilePath == comment.FilePath && c.Commit == comment.Commit && other != nil && r.Start < other.End && other.Start < r.End {
+			return true
+		}
+	}
+	return false
+}
+
+func codepointRange(comment *diffreviewer.Comment) *nf.Range {
+	if comment.Finding == nil || comment.Finding.Location == nil {
+		return nil
+	}
+	return comment.Finding.Location.CodepointRange
+}
+
+// warnLocalEngineLimitations logs the parts of the config the local detector engine cannot evaluate
+func (n *Client) warnLocalEngineLimitations(logger logger.Logger) {
+	if n.hasEngine(nightfallconfig.EngineAPI) {
+		logger.Info("Scanning with the local detector engine")
+	} else {
+		logger.Info("Scanning with the local detector engine, content is not sent to the Nightfall API")
+	}
+	if len(n.DetectionRuleUUIDs) > 0 {
+		logger.Warning(fmt.Sprintf("Skipping %d detection rule UUIDs, they can only be evaluated by the Nightfall API", len(n.DetectionRuleUUIDs)))
+	}
+	if unsupported := localscanner.UnsupportedDetectors(n.DetectionRules); len(unsupported) > 0 {
+		logger.Warning(fmt.Sprintf("Skipping Nightfall detectors not supported by the local detector engine: %s", strings.Join(unsupported, ", ")))
+	}
+}
+
 func getFileToScan(fd *diffreviewer.FileDiff) (*fileToScan, error) {
 	fts := &fileToScan{
 		FilePath:         fd.PathNew,
 		ContentToLineMap: datastructs.NewRangeMap(),
+		Commit:           fd.Commit,
 	}
 
 	bufferString := bytes.NewBufferString("")
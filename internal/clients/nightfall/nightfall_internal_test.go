@@ -1,12 +1,18 @@
 package nightfall
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	nf "github.com/nightfallai/nightfall-go-sdk"
 	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
 	githublogger "github.com/nightfallai/nightfall_code_scanner/internal/clients/logger/github_logger"
+	"github.com/nightfallai/nightfall_code_scanner/internal/nightfallconfig"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -216,6 +222,120 @@ func TestCreateCommentsFromScanResp(t *testing.T) {
 	}
 }
 
+func TestCreateCommentsFromScanRespForFilesColumns(t *testing.T) {
+	fts, err := getFileToScan(&diffreviewer.FileDiff{
+		PathNew: filePath,
+		Hunks: []*diffreviewer.Hunk{{
+			Lines: []*diffreviewer.Line{
+				{LnumNew: 4, Content: "first line"},
+				{LnumNew: 5, Content: creditCardNumber2Content},
+				{LnumNew: 6, Content: "key: " + exampleAPIKey},
+				{LnumNew: 7, Content: "last line"},
+			},
+		}},
+	})
+	assert.NoError(t, err, "unexpected error getting file to scan")
+	finding := func(fragment string, start, end int64) *nf.Finding {
+		return &nf.Finding{
+			Finding:  fragment,
+			Location: &nf.Location{CodepointRange: &nf.Range{Start: start, End: end}},
+		}
+	}
+	// the lines are joined by spaces, the second line starts at codepoint 11 and the third at 66
+	resp := &nf.ScanTextResponse{Findings: [][]*nf.Finding{{
+		finding(exampleCreditCardNumber2, 31, 50),
+		finding(exampleAPIKey+" last", 71, 116),
+	}}}
+
+	comments := createCommentsFromScanRespForFiles([]*fileToScan{fts}, resp, nil)
+	assert.Len(t, comments, 2, "invalid number of comments")
+	assert.Equal(t, 5, comments[0].LineNumber, "invalid line of the first finding")
+	assert.Equal(t, 21, comments[0].StartColumn, "invalid start column of the first finding")
+	assert.Equal(t, 40, comments[0].EndColumn, "invalid end column of the first finding")
+	assert.Equal(t, 6, comments[1].LineNumber, "invalid line of the second finding")
+	assert.Equal(t, 6, comments[1].StartColumn, "invalid start column of the second finding")
+	assert.Equal(t, 46, comments[1].EndColumn, "a finding spanning lines should end with its first line")
+}
+
+func TestScanWithEngineFileTooLarge(t *testing.T) {
+	var requests []*nf.ScanTextRequest
+	client := Client{
+		Engines: []*Engine{{Name: nightfallconfig.EngineAPI, Scanner: &mockNightfall{scanFn: func(ctx context.Context, request *nf.ScanTextRequest) (*nf.ScanTextResponse, error) {
+			requests = append(requests, request)
+			return &nf.ScanTextResponse{Findings: make([][]*nf.Finding, len(request.Payload))}, nil
+		}}}},
+		MaxNumberRoutines: 1,
+	}
+	tooLarge := &fileToScan{FilePath: "large.txt", Content: strings.Repeat("a", maxAPIRequestSize+1)}
+	small := &fileToScan{FilePath: "small.txt", Content: "small"}
+
+	result, err := client.scanWithEngine(context.Background(), githublogger.NewDefaultGithubLogger(), client.Engines[0], []*fileToScan{tooLarge, small})
+	assert.NoError(t, err, "unexpected error scanning files")
+	assert.Equal(t, []*fileToScan{tooLarge}, result.Files, "the large file should be reported as unscanned")
+	assert.Equal(t, errFileTooLarge, result.Err, "invalid scan error")
+	assert.Len(t, requests, 1, "the other files should still be scanned")
+	assert.Equal(t, []string{"small"}, requests[0].Payload, "invalid scanned files")
+}
+
+func TestScanAllFilesCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := Client{
+		Engines: []*Engine{{Name: nightfallconfig.EngineAPI, Scanner: &mockNightfall{scanFn: func(ctx context.Context, request *nf.ScanTextRequest) (*nf.ScanTextResponse, error) {
+			cancel()
+			return &nf.ScanTextResponse{Findings: make([][]*nf.Finding, len(request.Payload))}, nil
+		}}}},
+		MaxNumberRoutines: 1,
+	}
+	files := []*fileToScan{{FilePath: "a.txt", Content: "a"}, {FilePath: "b.txt", Content: "b"}}
+
+	// nothing reads the results, as when scanWithEngine gave up on the context
+	done := make(chan struct{})
+	go func() {
+		client.scanAllFiles(ctx, githublogger.NewDefaultGithubLogger(), client.Engines[0], files, make(chan *scanResult))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("scanAllFiles did not return after the context was cancelled")
+	}
+}
+
+func TestScanFileContentShortResponse(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nightfalldlp-cache")
+	assert.NoError(t, err, "Unexpected error creating temp dir")
+	defer os.RemoveAll(dir)
+
+	finding := &nf.Finding{
+		Finding:  exampleAPIKey,
+		Detector: nf.DetectorMetadata{DisplayName: "API_KEY"},
+		Location: &nf.Location{CodepointRange: &nf.Range{Start: 14, End: 54}},
+	}
+	client := Client{
+		Engines: []*Engine{{Name: nightfallconfig.EngineAPI, Scanner: &mockNightfall{scanFn: func(ctx context.Context, request *nf.ScanTextRequest) (*nf.ScanTextResponse, error) {
+			return &nf.ScanTextResponse{Findings: [][]*nf.Finding{{finding}}}, nil
+		}}}},
+		DetectionRules:    []nf.DetectionRule{{Name: "rule"}},
+		MaxNumberRoutines: 1,
+	}
+	client.Engines[0].Cache, err = newResultCache(dir, client.buildScanRequest(nil))
+	assert.NoError(t, err, "Unexpected error creating cache")
+	files := []*fileToScan{
+		{FilePath: "key.go", Content: apiKeyContent},
+		{FilePath: "card.go", Content: creditCardNumberContent},
+	}
+
+	comments, err := client.scanFileContent(context.Background(), client.Engines[0], files, 1, githublogger.NewDefaultGithubLogger())
+	assert.Equal(t, errIncompleteScanResponse, err, "a short response should be an error")
+	assert.Nil(t, comments, "no comments should be returned")
+	findings, ok := client.Engines[0].Cache.get(apiKeyContent)
+	assert.True(t, ok, "the returned findings should be cached")
+	assert.Equal(t, []*nf.Finding{finding}, findings, "Incorrect cached findings")
+	_, ok = client.Engines[0].Cache.get(creditCardNumberContent)
+	assert.False(t, ok, "the items missing from the response should not be cached")
+}
+
 func TestFilterFileDiffs(t *testing.T) {
 	filePaths := []string{"path/secondary_path/file.txt", "a.go", "a/a.go", "test.go", "path/main.go", "path/test.py"}
 	fileDiffs := make([]*diffreviewer.FileDiff, len(filePaths))
@@ -388,9 +508,259 @@ func createContentToScan(content string) *contentToScan {
 
 func createComment(finding *nf.Finding) *diffreviewer.Comment {
 	return &diffreviewer.Comment{
-		Body:       getCommentMsg(finding),
-		FilePath:   filePath,
-		LineNumber: lineNumber,
-		Title:      getCommentTitle(finding),
+		Body:         getCommentMsg(finding),
+		FilePath:     filePath,
+		LineNumber:   lineNumber,
+		Title:        getCommentTitle(finding),
+		DetectorName: finding.Detector.DisplayName,
+		Finding:      finding,
+	}
+}
+
+func TestFilterSuppressedComments(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	apiKeyComment := &diffreviewer.Comment{FilePath: "main.go", LineNumber: 5, DetectorName: "API_KEY"}
+	entropyComment := &diffreviewer.Comment{FilePath: "main.go", LineNumber: 5, DetectorName: "High Entropy String"}
+	otherLineComment := &diffreviewer.Comment{FilePath: "main.go", LineNumber: 6, DetectorName: "API_KEY"}
+	otherFileComment := &diffreviewer.Comment{FilePath: "other.go", LineNumber: 5, DetectorName: "API_KEY"}
+	comments := []*diffreviewer.Comment{apiKeyComment, entropyComment, otherLineComment, otherFileComment}
+
+	tests := []struct {
+		haveSuppression *diffreviewer.Suppression
+		wantComments    []*diffreviewer.Comment
+		desc            string
+	}{
+		{
+			haveSuppression: &diffreviewer.Suppression{LineNumber: 5},
+			wantComments:    []*diffreviewer.Comment{otherLineComment, otherFileComment},
+			desc:            "all detectors",
+		},
+		{
+			haveSuppression: &diffreviewer.Suppression{LineNumber: 5, Detectors: []string{"api_key"}},
+			wantComments:    []*diffreviewer.Comment{entropyComment, otherLineComment, otherFileComment},
+			desc:            "single detector",
+		},
+		{
+			haveSuppression: &diffreviewer.Suppression{LineNumber: 5, Detectors: []string{"HIGH_ENTROPY_STRING"}},
+			wantComments:    []*diffreviewer.Comment{apiKeyComment, otherLineComment, otherFileComment},
+			desc:            "detector name with spaces",
+		},
+		{
+			haveSuppression: &diffreviewer.Suppression{LineNumber: 5, Reason: "fixture", Expires: "2026-03-01"},
+			wantComments:    []*diffreviewer.Comment{otherLineComment, otherFileComment},
+			desc:            "expires today",
+		},
+		{
+			haveSuppression: &diffreviewer.Suppression{LineNumber: 5, Expires: "2026-02-28"},
+			wantComments:    comments,
+			desc:            "expired",
+		},
+		{
+			haveSuppression: &diffreviewer.Suppression{LineNumber: 5, Expires: "soon"},
+			wantComments:    comments,
+			desc:            "invalid expiry",
+		},
+	}
+	for _, tt := range tests {
+		fileDiffs := []*diffreviewer.FileDiff{{
+			PathNew:      "main.go",
+			Suppressions: []*diffreviewer.Suppression{tt.haveSuppression},
+		}}
+		actual := filterSuppressedComments(comments, fileDiffs, now, githublogger.NewDefaultGithubLogger())
+		assert.Equal(t, tt.wantComments, actual, fmt.Sprintf("Incorrect response from filter suppressed comments %s test", tt.desc))
+	}
+}
+
+func TestFilterBaselineComments(t *testing.T) {
+	baselined := &diffreviewer.Comment{
+		FilePath:     "main.go",
+		LineNumber:   12,
+		DetectorName: "API_KEY",
+		Finding:      createFinding(exampleAPIKey, "API_KEY"),
+	}
+	moved := &diffreviewer.Comment{
+		FilePath:     "other.go",
+		LineNumber:   12,
+		DetectorName: "API_KEY",
+		Finding:      createFinding(exampleAPIKey, "API_KEY"),
+	}
+	newFinding := &diffreviewer.Comment{
+		FilePath:     "main.go",
+		LineNumber:   40,
+		DetectorName: "CREDIT_CARD_NUMBER",
+		Finding:      createFinding(exampleCreditCardNumber, "CREDIT_CARD_NUMBER"),
+	}
+	comments := []*diffreviewer.Comment{baselined, moved, newFinding}
+	baseline := nightfallconfig.NewBaseline()
+	baseline.Add(nightfallconfig.NewBaselineFingerprint("main.go", "API_KEY", exampleAPIKey))
+
+	logger := githublogger.NewDefaultGithubLogger()
+	assert.Equal(t, comments, filterBaselineComments(comments, nil, logger), "nil baseline should keep every comment")
+	assert.Equal(t, []*diffreviewer.Comment{moved, newFinding}, filterBaselineComments(comments, baseline, logger), "incorrect baseline filtering")
+}
+
+func TestSplitFileToScan(t *testing.T) {
+	tests := []struct {
+		haveContent string
+		haveMaxSize int
+		haveOverlap int
+		wantChunks  []string
+		wantOffsets [][2]int
+		desc        string
+	}{
+		{
+			haveContent: "small file ",
+			haveMaxSize: 20,
+			haveOverlap: 2,
+			wantChunks:  []string{"small file "},
+			wantOffsets: [][2]int{{0, 0}},
+			desc:        "content under the max size",
+		},
+		{
+			haveContent: "aaaa bbbb cccc dddd eeee ",
+			haveMaxSize: 12,
+			haveOverlap: 5,
+			wantChunks:  []string{"aaaa bbbb cc", "cccc dddd ee", "eeee "},
+			wantOffsets: [][2]int{{0, 0}, {10, 10}, {20, 20}},
+			desc:        "chunks overlapping on word boundaries",
+		},
+		{
+			haveContent: "aaaa bbbb cccc dddd eeee ",
+			haveMaxSize: 12,
+			haveOverlap: 2,
+			wantChunks:  []string{"aaaa bbbb ", "cccc dddd ", "eeee "},
+			wantOffsets: [][2]int{{0, 0}, {10, 10}, {20, 20}},
+			desc:        "chunks ending after a space",
+		},
+		{
+			haveContent: "ééééé ",
+			haveMaxSize: 5,
+			haveOverlap: 1,
+			wantChunks:  []string{"éé", "éé", "é "},
+			wantOffsets: [][2]int{{0, 0}, {4, 2}, {8, 4}},
+			desc:        "chunks ending on rune boundaries",
+		},
+	}
+	for _, tt := range tests {
+		file := &fileToScan{Content: tt.haveContent, FilePath: filePath}
+		chunks := splitFileToScan(file, tt.haveMaxSize, tt.haveOverlap)
+		actualChunks := make([]string, len(chunks))
+		actualOffsets := make([][2]int, len(chunks))
+		for i, chunk := range chunks {
+			actualChunks[i] = chunk.Content
+			actualOffsets[i] = [2]int{chunk.ByteOffset, chunk.CodepointOffset}
+			assert.Equal(t, filePath, chunk.FilePath, fmt.Sprintf("Incorrect file path for %s test", tt.desc))
+		}
+		assert.Equal(t, tt.wantChunks, actualChunks, fmt.Sprintf("Incorrect chunks for %s test", tt.desc))
+		assert.Equal(t, tt.wantOffsets, actualOffsets, fmt.Sprintf("Incorrect offsets for %s test", tt.desc))
+	}
+}
+
+func TestDedupeChunkComments(t *testing.T) {
+	newComment := func(filePath, detector string, start, end int64) *diffreviewer.Comment {
+		return &diffreviewer.Comment{
+			FilePath:     filePath,
+			DetectorName: detector,
+			Finding:      &nf.Finding{Location: &nf.Location{CodepointRange: &nf.Range{Start: start, End: end}}},
+		}
+	}
+	whole := newComment("large.json", "API_KEY", 100, 140)
+	cut := newComment("large.json", "API_KEY", 100, 120)
+	duplicate := newComment("large.json", "API_KEY", 100, 140)
+	otherDetector := newComment("large.json", "PASSWORD_IN_CODE", 100, 140)
+	otherRange := newComment("large.json", "API_KEY", 200, 240)
+	notChunked := newComment("small.json", "API_KEY", 100, 140)
+	notChunkedDuplicate := newComment("small.json", "API_KEY", 100, 140)
+
+	comments := []*diffreviewer.Comment{cut, whole, duplicate, otherDetector, otherRange, notChunked, notChunkedDuplicate}
+	actual := dedupeChunkComments(comments, map[string]bool{"large.json": true})
+	assert.Equal(t, []*diffreviewer.Comment{whole, otherDetector, otherRange, notChunked, notChunkedDuplicate}, actual, "Incorrect deduped comments")
+}
+
+func TestResultCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nightfalldlp-cache")
+	assert.NoError(t, err, "Unexpected error creating temp dir")
+	defer os.RemoveAll(dir)
+
+	client := &Client{DetectionRules: []nf.DetectionRule{{Name: "rule"}}}
+	cache, err := newResultCache(dir, client.buildScanRequest(nil))
+	assert.NoError(t, err, "Unexpected error creating cache")
+	finding := &nf.Finding{
+		Finding:  exampleAPIKey,
+		Detector: nf.DetectorMetadata{DisplayName: "API_KEY"},
+		Location: &nf.Location{CodepointRange: &nf.Range{Start: 14, End: 54}},
+	}
+
+	_, ok := cache.get(apiKeyContent)
+	assert.False(t, ok, "content should not be cached before put")
+	assert.NoError(t, cache.put(apiKeyContent, []*nf.Finding{finding}), "Unexpected error in put")
+	assert.NoError(t, cache.put(creditCardNumberContent, nil), "Unexpected error in put")
+
+	findings, ok := cache.get(apiKeyContent)
+	assert.True(t, ok, "content should be cached after put")
+	assert.Equal(t, []*nf.Finding{finding}, findings, "Incorrect cached findings")
+	findings, ok = cache.get(creditCardNumberContent)
+	assert.True(t, ok, "content without findings should be cached")
+	assert.Empty(t, findings, "Incorrect cached findings")
+
+	client.DetectionRules[0].Name = "other rule"
+	otherCache, err := newResultCache(dir, client.buildScanRequest(nil))
+	assert.NoError(t, err, "Unexpected error creating cache")
+	_, ok = otherCache.get(apiKeyContent)
+	assert.False(t, ok, "content scanned with another config should not be cached")
+}
+
+func TestResultCacheOmitsFragments(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nightfalldlp-cache")
+	assert.NoError(t, err, "Unexpected error creating temp dir")
+	defer os.RemoveAll(dir)
+
+	content := fmt.Sprintf("key %s and card %s", exampleAPIKey, exampleCreditCardNumber)
+	findings := [][]*nf.Finding{{
+		{
+			Finding:         exampleAPIKey,
+			RedactedFinding: "yr**************************************",
+			BeforeContext:   "key ",
+			Detector:        nf.DetectorMetadata{DisplayName: "API_KEY"},
+			Confidence:      string(nf.ConfidenceLikely),
+			Location:        &nf.Location{CodepointRange: &nf.Range{Start: 4, End: 44}, ByteRange: &nf.Range{Start: 4, End: 44}},
+		},
+		{
+			Finding:  exampleCreditCardNumber,
+			Detector: nf.DetectorMetadata{DisplayName: "CREDIT_CARD_NUMBER"},
+			Location: &nf.Location{CodepointRange: &nf.Range{Start: 54, End: 73}},
+		},
+	}}
+	client := Client{
+		Engines: []*Engine{{Name: nightfallconfig.EngineAPI, Scanner: &mockNightfall{scanFn: func(ctx context.Context, request *nf.ScanTextRequest) (*nf.ScanTextResponse, error) {
+			return &nf.ScanTextResponse{Findings: findings}, nil
+		}}}},
+		DetectionRules:     []nf.DetectionRule{{Name: "rule"}},
+		TokenExclusionList: []string{"^4916"},
+		MaxNumberRoutines:  1,
+	}
+	client.Engines[0].Cache, err = newResultCache(dir, client.buildScanRequest(nil))
+	assert.NoError(t, err, "Unexpected error creating cache")
+
+	fts, err := getFileToScan(&diffreviewer.FileDiff{
+		PathNew: filePath,
+		Hunks:   []*diffreviewer.Hunk{{Lines: []*diffreviewer.Line{{LnumNew: 1, Content: content}}}},
+	})
+	assert.NoError(t, err, "unexpected error getting file to scan")
+
+	_, err = client.scanFileContent(context.Background(), client.Engines[0], []*fileToScan{fts}, 1, githublogger.NewDefaultGithubLogger())
+	assert.NoError(t, err, "Unexpected error scanning content")
+	data, err := ioutil.ReadFile(client.Engines[0].Cache.path(fts.Content))
+	assert.NoError(t, err, "Unexpected error reading cache entry")
+	assert.NotContains(t, string(data), exampleAPIKey, "the cache entry should not hold the raw finding")
+	assert.NotContains(t, string(data), exampleCreditCardNumber, "the cache entry should not hold excluded findings")
+	assert.NotContains(t, string(data), "CREDIT_CARD_NUMBER", "excluded findings should not be cached")
+
+	cached, ok := client.Engines[0].Cache.get(fts.Content)
+	assert.True(t, ok, "content should be cached")
+	if assert.Len(t, cached, 1, "incorrect number of cached findings") {
+		assert.Equal(t, exampleAPIKey, cached[0].Finding, "the fragment should be restored from the content")
+		assert.Equal(t, "yr**************************************", cached[0].RedactedFinding, "incorrect redacted finding")
+		assert.Empty(t, cached[0].BeforeContext, "the context should not be cached")
 	}
 }
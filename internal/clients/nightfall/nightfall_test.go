@@ -5,13 +5,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	nf "github.com/nightfallai/nightfall-go-sdk"
 	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/localscanner"
 	githublogger "github.com/nightfallai/nightfall_code_scanner/internal/clients/logger/github_logger"
+	"github.com/nightfallai/nightfall_code_scanner/internal/nightfallconfig"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -77,10 +84,17 @@ var expectedScanResponse = &nf.ScanTextResponse{
 	},
 }
 
+// paddedScanResponse returns the findings of resp for the first items of request, and no findings for the others
+func paddedScanResponse(resp *nf.ScanTextResponse, request *nf.ScanTextRequest) *nf.ScanTextResponse {
+	findings := make([][]*nf.Finding, len(request.Payload))
+	copy(findings, resp.Findings)
+	return &nf.ScanTextResponse{Findings: findings}
+}
+
 func TestReviewDiff(t *testing.T) {
 	mockAPIClient := &mockNightfall{}
 	client := Client{
-		APIClient:         mockAPIClient,
+		Engines:           []*Engine{{Name: nightfallconfig.EngineAPI, Scanner: mockAPIClient}},
 		DetectionRules:    testDetectionRules,
 		MaxNumberRoutines: 1,
 	}
@@ -118,10 +132,14 @@ func TestReviewDiff(t *testing.T) {
 	}
 
 	c := diffreviewer.Comment{
-		FilePath:   filePath,
-		LineNumber: lineNum,
-		Body:       fmt.Sprintf("Suspicious content detected (%q, type %q)", blurredCreditCard, "CREDIT_CARD_NUMBER"),
-		Title:      fmt.Sprintf("Detected CREDIT_CARD_NUMBER"),
+		FilePath:     filePath,
+		LineNumber:   lineNum,
+		Body:         fmt.Sprintf("Suspicious content detected (%q, type %q)", blurredCreditCard, "CREDIT_CARD_NUMBER"),
+		Title:        fmt.Sprintf("Detected CREDIT_CARD_NUMBER"),
+		DetectorName: "CREDIT_CARD_NUMBER",
+		Finding:      expectedScanResponse.Findings[1][0],
+		StartColumn:  31,
+		EndColumn:    41,
 	}
 	expectedComments := []*diffreviewer.Comment{&c, &c}
 
@@ -146,7 +164,7 @@ func TestReviewDiff(t *testing.T) {
 		mockAPIClient.scanFn = func(ctx context.Context, request *nf.ScanTextRequest) (*nf.ScanTextResponse, error) {
 			assert.Equal(t, expectedRequests[callCount], request, "request object did not match")
 			callCount++
-			return expectedScanResponse, nil
+			return paddedScanResponse(expectedScanResponse, request), nil
 		}
 	}
 
@@ -158,7 +176,7 @@ func TestReviewDiff(t *testing.T) {
 func TestReviewDiffDetectionRuleUUID(t *testing.T) {
 	mockAPIClient := &mockNightfall{}
 	client := Client{
-		APIClient:          mockAPIClient,
+		Engines:            []*Engine{{Name: nightfallconfig.EngineAPI, Scanner: mockAPIClient}},
 		DetectionRuleUUIDs: []uuid.UUID{uuid.New()},
 		MaxNumberRoutines:  1,
 	}
@@ -198,10 +216,14 @@ func TestReviewDiffDetectionRuleUUID(t *testing.T) {
 	}
 
 	c := diffreviewer.Comment{
-		FilePath:   filePath,
-		LineNumber: lineNum,
-		Body:       fmt.Sprintf("Suspicious content detected (%q, type %q)", blurredCreditCard, "CREDIT_CARD_NUMBER"),
-		Title:      "Detected CREDIT_CARD_NUMBER",
+		FilePath:     filePath,
+		LineNumber:   lineNum,
+		Body:         fmt.Sprintf("Suspicious content detected (%q, type %q)", blurredCreditCard, "CREDIT_CARD_NUMBER"),
+		Title:        "Detected CREDIT_CARD_NUMBER",
+		DetectorName: "CREDIT_CARD_NUMBER",
+		Finding:      expectedScanResponse.Findings[1][0],
+		StartColumn:  31,
+		EndColumn:    41,
 	}
 	expectedComments := []*diffreviewer.Comment{&c, &c}
 
@@ -226,7 +248,7 @@ func TestReviewDiffDetectionRuleUUID(t *testing.T) {
 		mockAPIClient.scanFn = func(ctx context.Context, request *nf.ScanTextRequest) (*nf.ScanTextResponse, error) {
 			assert.Equal(t, expectedRequests[callCount], request, "request object did not match")
 			callCount++
-			return expectedScanResponse, nil
+			return paddedScanResponse(expectedScanResponse, request), nil
 		}
 	}
 
@@ -238,7 +260,7 @@ func TestReviewDiffDetectionRuleUUID(t *testing.T) {
 func TestReviewDiffHasFindingMetadata(t *testing.T) {
 	mockAPIClient := &mockNightfall{}
 	client := Client{
-		APIClient:         mockAPIClient,
+		Engines:           []*Engine{{Name: nightfallconfig.EngineAPI, Scanner: mockAPIClient}},
 		DetectionRules:    testDetectionRules,
 		MaxNumberRoutines: 1,
 	}
@@ -276,10 +298,13 @@ func TestReviewDiffHasFindingMetadata(t *testing.T) {
 	}
 
 	c := diffreviewer.Comment{
-		FilePath:   filePath,
-		LineNumber: lineNum,
-		Body:       fmt.Sprintf("Suspicious content detected (%q, type %q (%s %s key))", blurredAPIKey, "API_KEY", "Active", "Stripe"),
-		Title:      fmt.Sprintf("Detected API_KEY"),
+		FilePath:     filePath,
+		LineNumber:   lineNum,
+		Body:         fmt.Sprintf("Suspicious content detected (%q, type %q (%s %s key))", blurredAPIKey, "API_KEY", "Active", "Stripe"),
+		Title:        fmt.Sprintf("Detected API_KEY"),
+		DetectorName: "API_KEY",
+		StartColumn:  31,
+		EndColumn:    41,
 	}
 	expectedComments := []*diffreviewer.Comment{&c, &c}
 
@@ -313,6 +338,8 @@ func TestReviewDiffHasFindingMetadata(t *testing.T) {
 		},
 	}
 
+	c.Finding = scanResp.Findings[1][0]
+
 	var callCount int
 	expectedRequests := make([]*nf.ScanTextRequest, numScanReq)
 	// 500kb can only handle 426 files at a time, 500*1024/1200 = 426.66
@@ -322,7 +349,7 @@ func TestReviewDiffHasFindingMetadata(t *testing.T) {
 		mockAPIClient.scanFn = func(ctx context.Context, request *nf.ScanTextRequest) (*nf.ScanTextResponse, error) {
 			assert.Equal(t, expectedRequests[callCount], request, "request object did not match")
 			callCount++
-			return scanResp, nil
+			return paddedScanResponse(scanResp, request), nil
 		}
 	}
 
@@ -358,7 +385,7 @@ func TestScanPaths(t *testing.T) {
 	for _, tt := range tests {
 		mockAPIClient := &mockNightfall{}
 		client := Client{
-			APIClient:         mockAPIClient,
+			Engines:           []*Engine{{Name: nightfallconfig.EngineAPI, Scanner: mockAPIClient}},
 			DetectionRules:    testDetectionRules,
 			InitialRetryDelay: time.Millisecond,
 		}
@@ -371,8 +398,454 @@ func TestScanPaths(t *testing.T) {
 			return expectedScanResponse, nil
 		}
 
-		resp, err := client.Scan(context.Background(), testItems)
+		resp, err := client.Scan(context.Background(), client.Engines[0], testItems)
 		assert.Equal(t, tt.wantErr, err)
 		assert.Equal(t, tt.wantResponse, resp)
 	}
 }
+
+// transportResponseError returns the error of retryAfterTransport for a response of the test server
+func transportResponseError(statusCode int, retryAfter string, now time.Time) error {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.WriteHeader(statusCode)
+		fmt.Fprint(w, `{"code":429,"message":"Too Many Requests"}`)
+	}))
+	defer server.Close()
+	transport := newRetryAfterTransport(nil)
+	transport.now = func() time.Time { return now }
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err == nil {
+		resp.Body.Close()
+	}
+	return err
+}
+
+func TestRetryAfterTransport(t *testing.T) {
+	now := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		haveStatusCode int
+		haveRetryAfter string
+		wantRetryable  bool
+		wantDelay      time.Duration
+		desc           string
+	}{
+		{
+			haveStatusCode: http.StatusTooManyRequests,
+			haveRetryAfter: "2",
+			wantRetryable:  true,
+			wantDelay:      2 * time.Second,
+			desc:           "Retry-After in seconds",
+		},
+		{
+			haveStatusCode: http.StatusServiceUnavailable,
+			haveRetryAfter: now.Add(5 * time.Second).Format(http.TimeFormat),
+			wantRetryable:  true,
+			wantDelay:      5 * time.Second,
+			desc:           "Retry-After date",
+		},
+		{
+			haveStatusCode: http.StatusTooManyRequests,
+			haveRetryAfter: "3600",
+			wantRetryable:  true,
+			wantDelay:      maxRetryAfter,
+			desc:           "Retry-After capped",
+		},
+		{
+			haveStatusCode: http.StatusTooManyRequests,
+			wantRetryable:  true,
+			desc:           "rate limited without Retry-After",
+		},
+		{
+			haveStatusCode: http.StatusBadRequest,
+			desc:           "client error",
+		},
+		{
+			haveStatusCode: http.StatusOK,
+			desc:           "success",
+		},
+	}
+	for _, tt := range tests {
+		err := transportResponseError(tt.haveStatusCode, tt.haveRetryAfter, now)
+		assert.Equal(t, tt.wantRetryable, isRetryableError(err), fmt.Sprintf("invalid retryable error for %s test", tt.desc))
+		if !tt.wantRetryable {
+			assert.NoError(t, err, fmt.Sprintf("the response should be returned for %s test", tt.desc))
+			continue
+		}
+		var retryAfterErr retryAfterError
+		assert.True(t, errors.As(err, &retryAfterErr), fmt.Sprintf("missing Retry-After for %s test", tt.desc))
+		assert.Equal(t, tt.wantDelay, retryAfterErr.RetryAfter(), fmt.Sprintf("invalid Retry-After for %s test", tt.desc))
+		assert.Contains(t, err.Error(), "Too Many Requests", fmt.Sprintf("the response body should be reported for %s test", tt.desc))
+	}
+}
+
+func TestScanRetry(t *testing.T) {
+	rateLimitErr := &nf.Error{Code: http.StatusTooManyRequests, Message: "Too Many Requests"}
+	serverErr := &nf.Error{Code: http.StatusBadGateway, Message: "Bad Gateway"}
+	badRequestErr := &nf.Error{Code: http.StatusBadRequest, Message: "Bad Request"}
+	// the Retry-After date is 20ms ahead of the clock of the transport
+	retryAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	retryAfterErr := transportResponseError(http.StatusTooManyRequests, retryAt.Format(http.TimeFormat), retryAt.Add(-20*time.Millisecond))
+
+	tests := []struct {
+		haveErrs     []error
+		wantResponse *nf.ScanTextResponse
+		wantErr      error
+		wantCalls    int
+		wantMinWait  time.Duration
+		desc         string
+	}{
+		{
+			haveErrs:     []error{rateLimitErr, serverErr},
+			wantResponse: expectedScanResponse,
+			wantCalls:    3,
+			desc:         "success after rate limit and server errors",
+		},
+		{
+			haveErrs:  []error{serverErr, serverErr, serverErr, serverErr, serverErr},
+			wantErr:   serverErr,
+			wantCalls: maxScanAttempts,
+			desc:      "failure after max attempts",
+		},
+		{
+			haveErrs:  []error{badRequestErr},
+			wantErr:   badRequestErr,
+			wantCalls: 1,
+			desc:      "no retry on client error",
+		},
+		{
+			haveErrs:     []error{retryAfterErr},
+			wantResponse: expectedScanResponse,
+			wantCalls:    2,
+			wantMinWait:  20 * time.Millisecond,
+			desc:         "retry after delay",
+		},
+	}
+	for _, tt := range tests {
+		calls := 0
+		client := Client{
+			Engines: []*Engine{{Name: nightfallconfig.EngineAPI, Scanner: &mockNightfall{scanFn: func(ctx context.Context, request *nf.ScanTextRequest) (*nf.ScanTextResponse, error) {
+				calls++
+				if calls <= len(tt.haveErrs) {
+					return nil, tt.haveErrs[calls-1]
+				}
+				return expectedScanResponse, nil
+			}}}},
+			DetectionRules:    testDetectionRules,
+			InitialRetryDelay: time.Millisecond,
+		}
+
+		start := time.Now()
+		resp, err := client.Scan(context.Background(), client.Engines[0], testItems)
+		if tt.wantErr != nil {
+			assert.True(t, errors.Is(err, tt.wantErr), fmt.Sprintf("invalid error for %s test: %v", tt.desc, err))
+		} else {
+			assert.NoError(t, err, fmt.Sprintf("unexpected error for %s test", tt.desc))
+		}
+		assert.Equal(t, tt.wantResponse, resp, fmt.Sprintf("invalid response for %s test", tt.desc))
+		assert.Equal(t, tt.wantCalls, calls, fmt.Sprintf("invalid number of requests for %s test", tt.desc))
+		assert.True(t, time.Since(start) >= tt.wantMinWait, fmt.Sprintf("Retry-After not honored for %s test", tt.desc))
+	}
+}
+
+func TestReviewDiffCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nightfalldlp-cache")
+	assert.NoError(t, err, "unexpected error creating temp dir")
+	defer os.RemoveAll(dir)
+
+	var requests []*nf.ScanTextRequest
+	client := Client{
+		Engines: []*Engine{{Name: nightfallconfig.EngineAPI, Scanner: &mockNightfall{scanFn: func(ctx context.Context, request *nf.ScanTextRequest) (*nf.ScanTextResponse, error) {
+			requests = append(requests, request)
+			findings := make([][]*nf.Finding, len(request.Payload))
+			for i, item := range request.Payload {
+				if strings.Contains(item, exampleCreditCardNumber) {
+					findings[i] = expectedScanResponse.Findings[1]
+				}
+			}
+			return &nf.ScanTextResponse{Findings: findings}, nil
+		}}}},
+		DetectionRules:    testDetectionRules,
+		MaxNumberRoutines: 1,
+	}
+	client.Engines[0].Cache, err = newResultCache(dir, client.buildScanRequest(nil))
+	assert.NoError(t, err, "unexpected error creating cache")
+	newFileDiff := func(path, content string) *diffreviewer.FileDiff {
+		return &diffreviewer.FileDiff{
+			PathNew: path,
+			Hunks:   []*diffreviewer.Hunk{{Lines: []*diffreviewer.Line{{LnumNew: 1, Content: content}}}},
+		}
+	}
+	cardContent := fmt.Sprintf("this has a credit card number %s", exampleCreditCardNumber)
+
+	comments, err := client.ReviewDiff(context.Background(), githublogger.NewDefaultGithubLogger(), []*diffreviewer.FileDiff{newFileDiff("a.go", cardContent)})
+	assert.NoError(t, err, "Received error from ReviewDiff")
+	assert.Len(t, comments, 1, "incorrect number of comments")
+
+	comments, err = client.ReviewDiff(context.Background(), githublogger.NewDefaultGithubLogger(), []*diffreviewer.FileDiff{
+		newFileDiff("b.go", cardContent),
+		newFileDiff("c.go", "package main"),
+	})
+	assert.NoError(t, err, "Received error from ReviewDiff")
+	if assert.Len(t, comments, 1, "incorrect number of comments") {
+		assert.Equal(t, "b.go", comments[0].FilePath, "the cached finding should be reported for the new file")
+	}
+	if assert.Len(t, requests, 2, "incorrect number of requests") {
+		assert.Equal(t, []string{"package main "}, requests[1].Payload, "only content missing from the cache should be sent")
+	}
+}
+
+func TestReviewDiffScanFailure(t *testing.T) {
+	unauthorizedErr := &nf.Error{Code: http.StatusUnauthorized, Message: "Unauthorized"}
+	client := Client{
+		Engines: []*Engine{{Name: nightfallconfig.EngineAPI, Scanner: &mockNightfall{scanFn: func(ctx context.Context, request *nf.ScanTextRequest) (*nf.ScanTextResponse, error) {
+			return nil, unauthorizedErr
+		}}}},
+		DetectionRules:    testDetectionRules,
+		MaxNumberRoutines: 1,
+	}
+	input := []*diffreviewer.FileDiff{{
+		PathNew: "main.go",
+		Hunks:   []*diffreviewer.Hunk{{Lines: []*diffreviewer.Line{{LnumNew: 1, Content: "package main"}}}},
+	}}
+
+	comments, err := client.ReviewDiff(context.Background(), githublogger.NewDefaultGithubLogger(), input)
+	assert.Equal(t, &diffreviewer.ScanFailure{
+		Policy: nightfallconfig.FailurePolicyFailClosed,
+		Files:  []string{"main.go"},
+		Err:    unauthorizedErr,
+	}, err, "a failed request should be returned as a scan failure")
+	assert.Empty(t, comments, "no findings should be returned")
+}
+
+func TestNewClientEngine(t *testing.T) {
+	tests := []struct {
+		haveConfig  nightfallconfig.Config
+		wantEngines []string
+		desc        string
+	}{
+		{
+			haveConfig:  nightfallconfig.Config{NightfallAPIKey: "api-key", Engine: nightfallconfig.EngineAPI},
+			wantEngines: []string{nightfallconfig.EngineAPI},
+			desc:        "api engine with api key",
+		},
+		{
+			haveConfig:  nightfallconfig.Config{NightfallAPIKey: "api-key", Engine: nightfallconfig.EngineLocal},
+			wantEngines: []string{nightfallconfig.EngineLocal},
+			desc:        "local engine configured",
+		},
+		{
+			haveConfig:  nightfallconfig.Config{Engine: nightfallconfig.EngineAPI},
+			wantEngines: []string{nightfallconfig.EngineLocal},
+			desc:        "missing api key falls back to local engine",
+		},
+		{
+			haveConfig: nightfallconfig.Config{
+				NightfallAPIKey:  "api-key",
+				Engine:           nightfallconfig.EngineAPI,
+				EntropyDetection: &nightfallconfig.EntropyConfig{},
+			},
+			wantEngines: []string{nightfallconfig.EngineAPI, nightfallconfig.EngineEntropy},
+			desc:        "entropy detection adds the entropy engine",
+		},
+		{
+			haveConfig: nightfallconfig.Config{
+				NightfallAPIKey: "api-key",
+				Engine:          nightfallconfig.EngineAPI,
+				Engines:         []string{nightfallconfig.EngineLocal, nightfallconfig.EngineAPI, nightfallconfig.EngineEntropy},
+			},
+			wantEngines: []string{nightfallconfig.EngineLocal, nightfallconfig.EngineAPI, nightfallconfig.EngineEntropy},
+			desc:        "engines listed",
+		},
+		{
+			haveConfig:  nightfallconfig.Config{Engines: []string{nightfallconfig.EngineAPI, nightfallconfig.EngineLocal}},
+			wantEngines: []string{nightfallconfig.EngineLocal},
+			desc:        "missing api key with the local engine listed",
+		},
+	}
+	for _, tt := range tests {
+		client, err := NewClient(tt.haveConfig)
+		assert.NoError(t, err, tt.desc)
+		actualEngines := make([]string, len(client.Engines))
+		for i, engine := range client.Engines {
+			actualEngines[i] = engine.Name
+			switch engine.Name {
+			case nightfallconfig.EngineLocal:
+				assert.IsType(t, &localscanner.Scanner{}, engine.Scanner, tt.desc)
+			case nightfallconfig.EngineEntropy:
+				assert.IsType(t, &localscanner.EntropyDetector{}, engine.Scanner, tt.desc)
+			default:
+				assert.IsType(t, &nf.Client{}, engine.Scanner, tt.desc)
+			}
+		}
+		assert.Equal(t, tt.wantEngines, actualEngines, tt.desc)
+	}
+}
+
+func TestReviewDiffLocalEngine(t *testing.T) {
+	client, err := NewClient(nightfallconfig.Config{
+		NightfallDetectionRules:    testDetectionRules,
+		NightfallMaxNumberRoutines: 1,
+		Engine:                     nightfallconfig.EngineLocal,
+	})
+	assert.NoError(t, err, "unexpected error creating client")
+
+	githubToken := "ghp_" + strings.Repeat("a1B2", 9)
+	input := []*diffreviewer.FileDiff{{
+		PathNew: "config/settings.go",
+		Hunks: []*diffreviewer.Hunk{{
+			Lines: []*diffreviewer.Line{
+				{LnumNew: 3, Content: "package settings"},
+				{LnumNew: 4, Content: fmt.Sprintf("const token = %q", githubToken)},
+			},
+		}},
+	}}
+
+	comments, err := client.ReviewDiff(context.Background(), githublogger.NewDefaultGithubLogger(), input)
+	assert.NoError(t, err, "Received error from ReviewDiff")
+	if assert.Len(t, comments, 1, "incorrect number of comments") {
+		assert.Equal(t, "config/settings.go", comments[0].FilePath, "incorrect file path")
+		assert.Equal(t, 4, comments[0].LineNumber, "incorrect line number")
+		assert.Equal(t, "GitHub Token", comments[0].DetectorName, "incorrect detector")
+		assert.Equal(t, githubToken, comments[0].Finding.Finding, "incorrect finding")
+	}
+}
+
+func TestReviewDiffLargeFile(t *testing.T) {
+	client, err := NewClient(nightfallconfig.Config{
+		NightfallDetectionRules:    testDetectionRules,
+		NightfallMaxNumberRoutines: 2,
+		Engine:                     nightfallconfig.EngineLocal,
+	})
+	assert.NoError(t, err, "unexpected error creating client")
+
+	githubToken := "ghp_" + strings.Repeat("a1B2", 9)
+	numLines := 2 * maxAPIRequestSize / 50
+	lines := make([]*diffreviewer.Line, numLines)
+	for i := range lines {
+		lines[i] = &diffreviewer.Line{LnumNew: i + 1, Content: fmt.Sprintf("%-48d", i)}
+	}
+	secretLines := []int{1, numLines / 2, numLines}
+	for _, lineNumber := range secretLines {
+		lines[lineNumber-1].Content = fmt.Sprintf("token: %s", githubToken)
+	}
+	input := []*diffreviewer.FileDiff{{
+		PathNew: "fixtures/large.txt",
+		Hunks:   []*diffreviewer.Hunk{{Lines: lines}},
+	}}
+
+	comments, err := client.ReviewDiff(context.Background(), githublogger.NewDefaultGithubLogger(), input)
+	assert.NoError(t, err, "Received error from ReviewDiff")
+	actualLines := make([]int, len(comments))
+	for i, c := range comments {
+		actualLines[i] = c.LineNumber
+		assert.Equal(t, githubToken, c.Finding.Finding, "incorrect finding")
+	}
+	assert.ElementsMatch(t, secretLines, actualLines, "every finding of the large file should be reported once")
+}
+
+func TestReviewDiffHistory(t *testing.T) {
+	client, err := NewClient(nightfallconfig.Config{
+		NightfallDetectionRules:    testDetectionRules,
+		NightfallMaxNumberRoutines: 1,
+		Engine:                     nightfallconfig.EngineLocal,
+	})
+	assert.NoError(t, err, "unexpected error creating client")
+
+	githubToken := "ghp_" + strings.Repeat("a1B2", 9)
+	addCommit := &diffreviewer.Commit{SHA: "9fceb02d0ae598e95dc970b74767f19372d61af8", Author: "Jane Doe", Date: "2020-06-01T10:00:00+02:00"}
+	moveCommit := &diffreviewer.Commit{SHA: "b1a8e9e4d7b0c1f6a2e3d4c5b6a7980123456789", Author: "John Doe", Date: "2020-07-01T10:00:00+02:00"}
+	input := []*diffreviewer.FileDiff{
+		{
+			PathNew: "config/settings.go",
+			Commit:  addCommit,
+			Hunks: []*diffreviewer.Hunk{{
+				Lines: []*diffreviewer.Line{{LnumNew: 4, Content: fmt.Sprintf("const token = %q", githubToken)}},
+			}},
+		},
+		{
+			PathNew: "config/settings.go",
+			Commit:  moveCommit,
+			Hunks: []*diffreviewer.Hunk{{
+				Lines: []*diffreviewer.Line{{LnumNew: 9, Content: fmt.Sprintf("const token = %q // nightfall:ignore", githubToken)}},
+			}},
+			Suppressions: []*diffreviewer.Suppression{{LineNumber: 9, MarkerLineNumber: 9}},
+		},
+	}
+
+	comments, err := client.ReviewDiff(context.Background(), githublogger.NewDefaultGithubLogger(), input)
+	assert.NoError(t, err, "Received error from ReviewDiff")
+	if assert.Len(t, comments, 1, "suppressions should only apply to the commit they were found in") {
+		assert.Equal(t, 4, comments[0].LineNumber, "incorrect line number")
+		assert.Equal(t, addCommit, comments[0].Commit, "the finding should be attributed to its commit")
+	}
+}
+
+func TestReviewDiffEntropy(t *testing.T) {
+	entropyToken := "q8Zt3LmX0vR7pK2sWy9NcB4fHj6D"
+	apiFinding := &nf.Finding{
+		Finding:  "Zx81mQ4rT0bW6yN2cK9vH3jL5pD7",
+		Detector: nf.DetectorMetadata{DisplayName: "API_KEY"},
+		Location: &nf.Location{CodepointRange: &nf.Range{Start: 16, End: 44}},
+	}
+	client := Client{
+		Engines: []*Engine{{Name: nightfallconfig.EngineAPI, Scanner: &mockNightfall{scanFn: func(ctx context.Context, request *nf.ScanTextRequest) (*nf.ScanTextResponse, error) {
+			return &nf.ScanTextResponse{Findings: [][]*nf.Finding{{apiFinding}}}, nil
+		}}}},
+		MaxNumberRoutines: 1,
+	}
+	client.Engines = append(client.Engines, &Engine{Name: nightfallconfig.EngineEntropy, Scanner: localscanner.NewEntropyDetector("", 0, 0)})
+	input := []*diffreviewer.FileDiff{{
+		PathNew: "config/settings.go",
+		Hunks: []*diffreviewer.Hunk{{
+			Lines: []*diffreviewer.Line{
+				{LnumNew: 7, Content: "aws_access_key: " + apiFinding.Finding},
+				{LnumNew: 8, Content: "internal_token: " + entropyToken},
+			},
+		}},
+	}}
+
+	comments, err := client.ReviewDiff(context.Background(), githublogger.NewDefaultGithubLogger(), input)
+	assert.NoError(t, err, "Received error from ReviewDiff")
+	if assert.Len(t, comments, 2, "overlapping entropy finding should be dropped") {
+		assert.Equal(t, "API_KEY", comments[0].DetectorName, "incorrect detector")
+		assert.Equal(t, 7, comments[0].LineNumber, "incorrect line number")
+		assert.Equal(t, localscanner.EntropyDetectorName, comments[1].DetectorName, "incorrect detector")
+		assert.Equal(t, entropyToken, comments[1].Finding.Finding, "incorrect finding")
+		assert.Equal(t, 8, comments[1].LineNumber, "incorrect line number")
+	}
+}
+
+func TestReviewDiffCommandDetector(t *testing.T) {
+	response := `{"findings": [[{"detector": "INTERNAL_KEY", "codepointRange": {"start": 26, "end": 33}}]]}`
+	client, err := NewClient(nightfallconfig.Config{
+		NightfallDetectionRules:    testDetectionRules,
+		NightfallMaxNumberRoutines: 1,
+		Engine:                     nightfallconfig.EngineLocal,
+		CommandDetectors: []*nightfallconfig.CommandDetector{
+			{Name: "in-house", Command: []string{"sh", "-c", "cat > /dev/null; echo '" + response + "'"}},
+		},
+	})
+	assert.NoError(t, err, "unexpected error creating client")
+	assert.Equal(t, "command:in-house", client.Engines[len(client.Engines)-1].Name, "incorrect engine name")
+	input := []*diffreviewer.FileDiff{{
+		PathNew: "config/settings.go",
+		Hunks: []*diffreviewer.Hunk{{
+			Lines: []*diffreviewer.Line{
+				{LnumNew: 3, Content: "package main"},
+				{LnumNew: 4, Content: `const key = "INT-123"`},
+			},
+		}},
+	}}
+
+	comments, err := client.ReviewDiff(context.Background(), githublogger.NewDefaultGithubLogger(), input)
+	assert.NoError(t, err, "Received error from ReviewDiff")
+	if assert.Len(t, comments, 1, "incorrect number of comments") {
+		assert.Equal(t, "config/settings.go", comments[0].FilePath, "incorrect file path")
+		assert.Equal(t, 4, comments[0].LineNumber, "incorrect line number")
+		assert.Equal(t, "INTERNAL_KEY", comments[0].DetectorName, "incorrect detector")
+		assert.Equal(t, "INT-123", comments[0].Finding.Finding, "incorrect finding")
+	}
+}
@@ -0,0 +1,130 @@
+package nightfall
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	nf "github.com/nightfallai/nightfall-go-sdk"
+)
+
+const (
+	// maxRetryAfter caps the Retry-After delay so a misbehaving response cannot stall the scan
+	maxRetryAfter = time.Minute
+	// maxRetryableResponseBody is how much of the body of a rate limited or failed response is reported
+	maxRetryableResponseBody = 1024
+)
+
+// retryAfterError is implemented by errors carrying the delay requested by the Retry-After header of the response
+type retryAfterError interface {
+	RetryAfter() time.Duration
+}
+
+// retryableResponseError is returned by retryAfterTransport for the responses of rate limited or failed requests
+type retryableResponseError struct {
+	Status     string
+	StatusCode int
+	Body       string
+	retryAfter time.Duration
+}
+
+func (e *retryableResponseError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("nightfall API responded with %s", e.Status)
+	}
+	return fmt.Sprintf("nightfall API responded with %s: %s", e.Status, e.Body)
+}
+
+// RetryAfter is the delay requested by the Retry-After header of the response, 0 if there was none
+func (e *retryableResponseError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+// retryAfterTransport turns the responses of rate limited or failed requests into a retryableResponseError
+// so that the Retry-After header, which the SDK does not return, can be honored when retrying
+type retryAfterTransport struct {
+	base http.RoundTripper
+	now  func() time.Time
+}
+
+func newRetryAfterTransport(base http.RoundTripper) *retryAfterTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &retryAfterTransport{base: base, now: time.Now}
+}
+
+// RoundTrip sends the request with the base transport
+func (t *retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || !isRetryableStatus(resp.StatusCode) {
+		return resp, err
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxRetryableResponseBody))
+	return nil, &retryableResponseError{
+		Status:     resp.Status,
+		StatusCode: resp.StatusCode,
+		Body:       strings.TrimSpace(string(body)),
+		retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), t.now()),
+	}
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date, 0 if invalid
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	var delay time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(value); err == nil {
+		delay = date.Sub(now)
+	}
+	if delay < 0 {
+		return 0
+	}
+	if delay > maxRetryAfter {
+		return maxRetryAfter
+	}
+	return delay
+}
+
+func isRetryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// isRetryableError reports whether a failed scan request may succeed when sent again,
+// which is the case when the API is rate limiting requests or failed server side
+func isRetryableError(err error) bool {
+	var responseErr *retryableResponseError
+	if errors.As(err, &responseErr) {
+		return true
+	}
+	var apiErr *nf.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return isRetryableStatus(apiErr.Code)
+}
+
+// retryDelay returns how long to wait before the next attempt. The Retry-After delay is used when given,
+// else the backoff delay with jitter so concurrent requests do not all retry at the same time.
+func retryDelay(err error, backoff time.Duration) time.Duration {
+	var retryAfterErr retryAfterError
+	if errors.As(err, &retryAfterErr) && retryAfterErr.RetryAfter() > 0 {
+		return retryAfterErr.RetryAfter()
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(backoff-half)+1))
+}
@@ -0,0 +1,93 @@
+package nightfall
+
+import (
+	"context"
+	"net/http"
+
+	nf "github.com/nightfallai/nightfall-go-sdk"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/localscanner"
+	"github.com/nightfallai/nightfall_code_scanner/internal/nightfallconfig"
+)
+
+// Scanner is a detection engine. Like the Nightfall API, it returns the findings of every
+// payload item of the request in the same order, located by byte and codepoint ranges.
+type Scanner interface {
+	ScanText(ctx context.Context, request *nf.ScanTextRequest) (*nf.ScanTextResponse, error)
+}
+
+// Engine is a named Scanner used by the Client
+type Engine struct {
+	Name    string
+	Scanner Scanner
+	// Cache holds the findings of content already scanned by the engine, nil if disabled
+	Cache *resultCache
+}
+
+// engineNames lists the engines of the config in order of precedence. The local detector engine
+// replaces the Nightfall API when no API key is available, and the entropy engine is added
+// whenever entropy detection is configured.
+func engineNames(config nightfallconfig.Config) []string {
+	names := config.Engines
+	if len(names) == 0 {
+		names = []string{config.Engine}
+	}
+	if config.EntropyDetection != nil {
+		names = append(names[:len(names):len(names)], nightfallconfig.EngineEntropy)
+	}
+	resolved := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if name == "" {
+			name = nightfallconfig.EngineAPI
+		}
+		if name == nightfallconfig.EngineAPI && config.NightfallAPIKey == "" {
+			name = nightfallconfig.EngineLocal
+		}
+		if !seen[name] {
+			seen[name] = true
+			resolved = append(resolved, name)
+		}
+	}
+	return resolved
+}
+
+// newEngine creates the engine called name with the config
+func (n *Client) newEngine(name string, config nightfallconfig.Config) (*Engine, error) {
+	engine := &Engine{Name: name}
+	switch name {
+	case nightfallconfig.EngineLocal:
+		engine.Scanner = localscanner.NewScanner()
+	case nightfallconfig.EngineEntropy:
+		var entropy nightfallconfig.EntropyConfig
+		if config.EntropyDetection != nil {
+			entropy = *config.EntropyDetection
+		}
+		engine.Scanner = localscanner.NewEntropyDetector(entropy.Charset, entropy.MinLength, entropy.Threshold)
+	default:
+		apiClient, err := nf.NewClient(
+			nf.OptionAPIKey(config.NightfallAPIKey),
+			nf.OptionHTTPClient(&http.Client{Transport: newRetryAfterTransport(nil)}),
+		)
+		if err != nil {
+			return nil, err
+		}
+		engine.Scanner = apiClient
+		if config.CacheDirectory != "" {
+			engine.Cache, err = newResultCache(config.CacheDirectory, n.buildScanRequest(nil))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return engine, nil
+}
+
+// hasEngine reports whether the client scans with the engine called name
+func (n *Client) hasEngine(name string) bool {
+	for _, engine := range n.Engines {
+		if engine.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package nightfall
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/logger"
+	"github.com/nightfallai/nightfall_code_scanner/internal/nightfallconfig"
+)
+
+// suppressionDateLayout is the format of the expires= date of inline suppressions
+const suppressionDateLayout = "2006-01-02"
+
+// filterSuppressedComments drops the comments on lines suppressed by an active nightfall:ignore marker
+func filterSuppressedComments(comments []*diffreviewer.Comment, fileDiffs []*diffreviewer.FileDiff, now time.Time, logger logger.Logger) []*diffreviewer.Comment {
+	suppressions := make(map[string][]*diffreviewer.Suppression)
+	for _, fd := range fileDiffs {
+		for _, s := range fd.Suppressions {
+			if isSuppressionActive(s, fd.PathNew, now, logger) {
+				key := fileKey(fd.PathNew, fd.Commit)
+				suppressions[key] = append(suppressions[key], s)
+			}
+		}
+	}
+	if len(suppressions) == 0 {
+		return comments
+	}
+
+	filteredComments := make([]*diffreviewer.Comment, 0, len(comments))
+	for _, c := range comments {
+		s := findSuppression(c, suppressions[fileKey(c.FilePath, c.Commit)])
+		if s == nil {
+			filteredComments = append(filteredComments, c)
+			continue
+		}
+		msg := fmt.Sprintf("Suppressed %s finding at %s:%d by nightfall:ignore on line %d", c.DetectorName, c.FilePath, c.LineNumber, s.MarkerLineNumber)
+		if s.Reason != "" {
+			msg += fmt.Sprintf(", reason: %s", s.Reason)
+		}
+		if s.Expires != "" {
+			msg += fmt.Sprintf(", expires: %s", s.Expires)
+		}
+		logger.Info(msg)
+	}
+	return filteredComments
+}
+
+// isSuppressionActive reports whether the suppression has not expired, the expiry date itself is included
+func isSuppressionActive(s *diffreviewer.Suppression, filePath string, now time.Time, logger logger.Logger) bool {
+	if s.Expires == "" {
+		return true
+	}
+	expires, err := time.Parse(suppressionDateLayout, s.Expires)
+	if err != nil {
+		logger.Warning(fmt.Sprintf("Ignoring nightfall:ignore at %s:%d with invalid expiry %q, expected YYYY-MM-DD", filePath, s.MarkerLineNumber, s.Expires))
+		return false
+	}
+	if !now.Before(expires.AddDate(0, 0, 1)) {
+		logger.Warning(fmt.Sprintf("Ignoring nightfall:ignore at %s:%d that expired on %s", filePath, s.MarkerLineNumber, s.Expires))
+		return false
+	}
+	return true
+}
+
+func findSuppression(c *diffreviewer.Comment, suppressions []*diffreviewer.Suppression) *diffreviewer.Suppression {
+	for _, s := range suppressions {
+		if s.LineNumber == c.LineNumber && suppressesDetector(s, c.DetectorName) {
+			return s
+		}
+	}
+	return nil
+}
+
+// suppressesDetector matches detector names case insensitively, with underscores standing in for spaces
+func suppressesDetector(s *diffreviewer.Suppression, detectorName string) bool {
+	if len(s.Detectors) == 0 {
+		return true
+	}
+	name := normalizeDetectorName(detectorName)
+	for _, d := range s.Detectors {
+		if normalizeDetectorName(d) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeDetectorName(name string) string {
+	return strings.ToUpper(strings.Replace(strings.TrimSpace(name), " ", "_", -1))
+}
+
+// filterBaselineComments drops the comments of findings recorded in the baseline
+func filterBaselineComments(comments []*diffreviewer.Comment, baseline *nightfallconfig.Baseline, logger logger.Logger) []*diffreviewer.Comment {
+	if baseline.Len() == 0 {
+		return comments
+	}
+	filteredComments := make([]*diffreviewer.Comment, 0, len(comments))
+	for _, c := range comments {
+		if c.Finding != nil && baseline.Contains(nightfallconfig.NewBaselineFingerprint(c.FilePath, c.DetectorName, c.Finding.Finding)) {
+			continue
+		}
+		filteredComments = append(filteredComments, c)
+	}
+	if skipped := len(comments) - len(filteredComments); skipped > 0 {
+		logger.Info(fmt.Sprintf("Skipped %d findings recorded in the baseline", skipped))
+	}
+	return filteredComments
+}
@@ -0,0 +1,104 @@
+package reporter
+
+import (
+	"encoding/json"
+
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+)
+
+// JSONReport is the top level object of a JSON findings report
+type JSONReport struct {
+	Tool            string         `json:"tool"`
+	AnnotationLevel string         `json:"annotationLevel"`
+	FindingCount    int            `json:"findingCount"`
+	Findings        []*JSONFinding `json:"findings"`
+}
+
+// JSONFinding is a single finding with its location and the detector that produced it
+type JSONFinding struct {
+	FilePath   string `json:"filePath"`
+	LineNumber int    `json:"lineNumber"`
+	// Message describes the finding with its redacted fragment, if any
+	Message string `json:"message"`
+	// Detector is the display name of the detector
+	Detector     string `json:"detector"`
+	DetectorUUID string `json:"detectorUUID,omitempty"`
+	Confidence   string `json:"confidence,omitempty"`
+	// RedactedFinding is only set if a redaction config is used, the raw
+	// sensitive fragment is never written to the report
+	RedactedFinding string `json:"redactedFinding,omitempty"`
+	// ColumnRange locates the finding on its line
+	ColumnRange               *JSONRange `json:"columnRange,omitempty"`
+	MatchedDetectionRuleUUIDs []string   `json:"matchedDetectionRuleUUIDs,omitempty"`
+	MatchedDetectionRules     []string   `json:"matchedDetectionRules,omitempty"`
+	// Commit, Author and Date are only set when scanning git history
+	Commit string `json:"commit,omitempty"`
+	Author string `json:"author,omitempty"`
+	Date   string `json:"date,omitempty"`
+}
+
+// JSONRange is a half open range of 1-based codepoint columns
+type JSONRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// JSONReporter writes the findings as a JSON report
+type JSONReporter struct {
+	Path string
+}
+
+// NewJSONReporter creates a reporter writing a JSON report to path
+func NewJSONReporter(path string) Reporter {
+	return &JSONReporter{Path: path}
+}
+
+// Report writes the findings to the JSON report
+func (r *JSONReporter) Report(comments []*diffreviewer.Comment, level string) error {
+	f, err := createReportFile(r.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(CreateJSONReport(comments, level))
+}
+
+// CreateJSONReport converts the findings to a JSON report
+func CreateJSONReport(comments []*diffreviewer.Comment, level string) *JSONReport {
+	findings := make([]*JSONFinding, len(comments))
+	for i, comment := range comments {
+		finding := &JSONFinding{
+			FilePath:   comment.FilePath,
+			LineNumber: comment.LineNumber,
+			Message:    findingMessage(comment),
+			Detector:   comment.DetectorName,
+		}
+		if nfFinding := comment.Finding; nfFinding != nil {
+			finding.DetectorUUID = nfFinding.Detector.DetectorUUID
+			finding.Confidence = nfFinding.Confidence
+			finding.RedactedFinding = nfFinding.RedactedFinding
+			finding.MatchedDetectionRuleUUIDs = nfFinding.MatchedDetectionRuleUUIDs
+			finding.MatchedDetectionRules = nfFinding.MatchedDetectionRules
+		}
+		if comment.StartColumn > 0 {
+			finding.ColumnRange = &JSONRange{
+				Start: comment.StartColumn,
+				End:   comment.EndColumn,
+			}
+		}
+		if commit := comment.Commit; commit != nil {
+			finding.Commit = commit.SHA
+			finding.Author = commit.Author
+			finding.Date = commit.Date
+		}
+		findings[i] = finding
+	}
+	return &JSONReport{
+		Tool:            toolName,
+		AnnotationLevel: level,
+		FindingCount:    len(findings),
+		Findings:        findings,
+	}
+}
@@ -0,0 +1,133 @@
+package reporter
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	nf "github.com/nightfallai/nightfall-go-sdk"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+	"github.com/nightfallai/nightfall_code_scanner/internal/nightfallconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const rawCreditCard = "4242-4242-4242-4242"
+
+var creditCardFinding = &nf.Finding{
+	Finding:         rawCreditCard,
+	RedactedFinding: "4242-****-****-****",
+	Detector: nf.DetectorMetadata{
+		DisplayName:  "Credit Card Number",
+		DetectorUUID: "74c1815e-c0c3-4df5-8b1e-6cf98864a454",
+	},
+	Confidence: string(nf.ConfidenceVeryLikely),
+	Location: &nf.Location{CodepointRange: &nf.Range{
+		Start: 30,
+		End:   49,
+	}},
+	MatchedDetectionRuleUUIDs: []string{"c3a6a7a2-5d1e-4f0b-8a5e-0f1c2b3d4e5f"},
+	MatchedDetectionRules:     []string{"my detection rule"},
+}
+
+func TestCreateJSONReport(t *testing.T) {
+	comments := []*diffreviewer.Comment{
+		{
+			Title:        "Detected Credit Card Number",
+			Body:         `Suspicious content detected ("4242-****-****-****", type "Credit Card Number")`,
+			FilePath:     "src/payments.go",
+			LineNumber:   12,
+			DetectorName: "Credit Card Number",
+			Finding:      creditCardFinding,
+			StartColumn:  5,
+			EndColumn:    24,
+		},
+		{
+			Body:         "testComment",
+			FilePath:     "README.md",
+			LineNumber:   1,
+			DetectorName: "API_KEY",
+			Commit: &diffreviewer.Commit{
+				SHA:    "9fceb02d0ae598e95dc970b74767f19372d61af8",
+				Author: "Jane Doe",
+				Date:   "2020-06-01T10:00:00+02:00",
+			},
+		},
+	}
+
+	report := CreateJSONReport(comments, nightfallconfig.AnnotationLevelFailure)
+	assert.Equal(t, &JSONReport{
+		Tool:            "nightfalldlp",
+		AnnotationLevel: "failure",
+		FindingCount:    2,
+		Findings: []*JSONFinding{
+			{
+				FilePath:                  "src/payments.go",
+				LineNumber:                12,
+				Message:                   `Suspicious content detected ("4242-****-****-****", type "Credit Card Number")`,
+				Detector:                  "Credit Card Number",
+				DetectorUUID:              "74c1815e-c0c3-4df5-8b1e-6cf98864a454",
+				Confidence:                string(nf.ConfidenceVeryLikely),
+				RedactedFinding:           "4242-****-****-****",
+				ColumnRange:               &JSONRange{Start: 5, End: 24},
+				MatchedDetectionRuleUUIDs: []string{"c3a6a7a2-5d1e-4f0b-8a5e-0f1c2b3d4e5f"},
+				MatchedDetectionRules:     []string{"my detection rule"},
+			},
+			{
+				FilePath:   "README.md",
+				LineNumber: 1,
+				Message:    `Suspicious content detected (type "API_KEY")`,
+				Detector:   "API_KEY",
+				Commit:     "9fceb02d0ae598e95dc970b74767f19372d61af8",
+				Author:     "Jane Doe",
+				Date:       "2020-06-01T10:00:00+02:00",
+			},
+		},
+	}, report, "invalid json report")
+}
+
+func TestJSONReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nightfalldlp-json")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "findings.json")
+	comments := []*diffreviewer.Comment{{
+		Body:         "testComment",
+		FilePath:     "src/payments.go",
+		LineNumber:   12,
+		DetectorName: "Credit Card Number",
+		Finding:      creditCardFinding,
+	}}
+
+	r, err := ParseOutput("json=" + path)
+	require.NoError(t, err, "unexpected error parsing output")
+	err = r.Report(comments, nightfallconfig.AnnotationLevelWarning)
+	require.NoError(t, err, "unexpected error writing json report")
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err, "error reading json report")
+	assert.False(t, strings.Contains(string(content), rawCreditCard), "raw finding must not be written to the report")
+	var report JSONReport
+	require.NoError(t, json.Unmarshal(content, &report), "json report is not valid json")
+	assert.Equal(t, CreateJSONReport(comments, nightfallconfig.AnnotationLevelWarning), &report, "invalid json report")
+}
+
+func TestCreateJSONReportWithoutRedaction(t *testing.T) {
+	finding := *creditCardFinding
+	finding.RedactedFinding = ""
+	comments := []*diffreviewer.Comment{{
+		Body:         `Suspicious content detected ("` + rawCreditCard + `", type "Credit Card Number")`,
+		FilePath:     "src/payments.go",
+		LineNumber:   12,
+		DetectorName: "Credit Card Number",
+		Finding:      &finding,
+	}}
+
+	content, err := json.Marshal(CreateJSONReport(comments, nightfallconfig.AnnotationLevelFailure))
+	require.NoError(t, err, "unexpected error encoding json report")
+	assert.False(t, strings.Contains(string(content), rawCreditCard), "raw finding must not be written to the report")
+	assert.Contains(t, string(content), `Suspicious content detected (type \"Credit Card Number\")`, "invalid message")
+}
@@ -0,0 +1,127 @@
+package reporter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+)
+
+const (
+	// junitPassingSuite holds a single passing testcase when nothing was found,
+	// so CI systems do not report the results as missing
+	junitPassingSuite    = "nightfalldlp"
+	junitPassingTestcase = "no sensitive items found"
+)
+
+// JUnitTestSuites is the root element of a JUnit XML report
+type JUnitTestSuites struct {
+	XMLName  xml.Name          `xml:"testsuites"`
+	Name     string            `xml:"name,attr"`
+	Tests    int               `xml:"tests,attr"`
+	Failures int               `xml:"failures,attr"`
+	Suites   []*JUnitTestSuite `xml:"testsuite"`
+}
+
+// JUnitTestSuite holds the findings of a single file
+type JUnitTestSuite struct {
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	TestCases []*JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitTestCase is a single finding
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Line      int           `xml:"line,attr,omitempty"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+}
+
+// JUnitFailure describes why a testcase failed
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnitReporter writes the findings as a JUnit XML report
+type JUnitReporter struct {
+	Path string
+}
+
+// NewJUnitReporter creates a reporter writing a JUnit XML report to path
+func NewJUnitReporter(path string) Reporter {
+	return &JUnitReporter{Path: path}
+}
+
+// Report writes the findings to the JUnit XML report
+func (r *JUnitReporter) Report(comments []*diffreviewer.Comment, level string) error {
+	f, err := createReportFile(r.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.WriteString(xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(f)
+	encoder.Indent("", "  ")
+	return encoder.Encode(CreateJUnitReport(comments, level))
+}
+
+// CreateJUnitReport converts the findings to a JUnit report with one testsuite per
+// file and one failing testcase per finding
+func CreateJUnitReport(comments []*diffreviewer.Comment, level string) *JUnitTestSuites {
+	report := &JUnitTestSuites{
+		Name:     toolName,
+		Tests:    len(comments),
+		Failures: len(comments),
+		Suites:   make([]*JUnitTestSuite, 0),
+	}
+	if len(comments) == 0 {
+		report.Tests = 1
+		report.Suites = append(report.Suites, &JUnitTestSuite{
+			Name:  junitPassingSuite,
+			Tests: 1,
+			TestCases: []*JUnitTestCase{{
+				Name:      junitPassingTestcase,
+				ClassName: junitPassingSuite,
+			}},
+		})
+		return report
+	}
+
+	suites := make(map[string]*JUnitTestSuite)
+	for _, comment := range comments {
+		filePath := strings.TrimPrefix(comment.FilePath, "/")
+		suite, ok := suites[filePath]
+		if !ok {
+			suite = &JUnitTestSuite{Name: filePath}
+			suites[filePath] = suite
+			report.Suites = append(report.Suites, suite)
+		}
+		detector := comment.DetectorName
+		if detector == "" {
+			detector = defaultRuleName
+		}
+		message := findingMessage(comment)
+		suite.Tests++
+		suite.Failures++
+		suite.TestCases = append(suite.TestCases, &JUnitTestCase{
+			Name:      fmt.Sprintf("%s on line %d", detector, comment.LineNumber),
+			ClassName: filePath,
+			File:      filePath,
+			Line:      comment.LineNumber,
+			Failure: &JUnitFailure{
+				Message: message,
+				Type:    level,
+				Text:    fmt.Sprintf("%s at %s on line %d", message, filePath, comment.LineNumber),
+			},
+		})
+	}
+	return report
+}
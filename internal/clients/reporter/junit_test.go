@@ -0,0 +1,106 @@
+package reporter
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	nf "github.com/nightfallai/nightfall-go-sdk"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+	"github.com/nightfallai/nightfall_code_scanner/internal/nightfallconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateJUnitReport(t *testing.T) {
+	report := CreateJUnitReport(testComments, nightfallconfig.AnnotationLevelWarning)
+
+	assert.Equal(t, len(testComments), report.Tests, "invalid number of tests")
+	assert.Equal(t, len(testComments), report.Failures, "invalid number of failures")
+	require.Len(t, report.Suites, len(testComments), "findings should be grouped by file")
+	suite := report.Suites[1]
+	assert.Equal(t, "config/app.yaml", suite.Name, "file paths should be relative")
+	assert.Equal(t, 1, suite.Tests, "invalid number of tests in suite")
+	assert.Equal(t, 1, suite.Failures, "invalid number of failures in suite")
+	assert.Equal(t, &JUnitTestCase{
+		Name:      "API_KEY on line 3",
+		ClassName: "config/app.yaml",
+		File:      "config/app.yaml",
+		Line:      3,
+		Failure: &JUnitFailure{
+			Message: testComments[1].Body,
+			Type:    "warning",
+			Text:    testComments[1].Body + " at config/app.yaml on line 3",
+		},
+	}, suite.TestCases[0], "invalid testcase")
+	assert.Equal(t, "sensitive-content on line 1", report.Suites[3].TestCases[0].Name, "invalid testcase name without detector")
+}
+
+func TestCreateJUnitReportSameFile(t *testing.T) {
+	comments := []*diffreviewer.Comment{
+		{Body: "first", FilePath: "a.txt", LineNumber: 1, DetectorName: "cc"},
+		{Body: "second", FilePath: "b.txt", LineNumber: 2, DetectorName: "cc"},
+		{Body: "third", FilePath: "a.txt", LineNumber: 5, DetectorName: "ip"},
+	}
+
+	report := CreateJUnitReport(comments, nightfallconfig.AnnotationLevelFailure)
+	require.Len(t, report.Suites, 2, "invalid number of suites")
+	assert.Equal(t, "a.txt", report.Suites[0].Name)
+	assert.Equal(t, 2, report.Suites[0].Failures)
+	assert.Equal(t, "ip on line 5", report.Suites[0].TestCases[1].Name)
+	assert.Equal(t, "b.txt", report.Suites[1].Name)
+}
+
+func TestJUnitReportWithoutRedaction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nightfalldlp-junit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "nightfall.xml")
+	comments := []*diffreviewer.Comment{{
+		Body:         `Suspicious content detected ("4242-4242-4242-4242", type "Credit Card Number")`,
+		FilePath:     "src/payments.go",
+		LineNumber:   12,
+		DetectorName: "Credit Card Number",
+		Finding:      &nf.Finding{Finding: "4242-4242-4242-4242"},
+	}}
+
+	err = NewJUnitReporter(path).Report(comments, nightfallconfig.AnnotationLevelFailure)
+	require.NoError(t, err, "unexpected error writing junit report")
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err, "error reading junit report")
+	assert.NotContains(t, string(content), "4242-4242-4242-4242", "the raw finding should not be in the junit report")
+	assert.Contains(t, string(content), "Suspicious content detected (type &#34;Credit Card Number&#34;) at src/payments.go on line 12", "invalid failure text")
+}
+
+func TestCreateJUnitReportNoFindings(t *testing.T) {
+	report := CreateJUnitReport([]*diffreviewer.Comment{}, nightfallconfig.AnnotationLevelFailure)
+
+	assert.Equal(t, 1, report.Tests, "invalid number of tests")
+	assert.Equal(t, 0, report.Failures, "invalid number of failures")
+	require.Len(t, report.Suites, 1, "invalid number of suites")
+	require.Len(t, report.Suites[0].TestCases, 1, "invalid number of testcases")
+	assert.Nil(t, report.Suites[0].TestCases[0].Failure, "testcase should pass without findings")
+}
+
+func TestJUnitReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nightfalldlp-junit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test-results", "nightfall.xml")
+
+	err = NewJUnitReporter(path).Report(testComments, nightfallconfig.AnnotationLevelFailure)
+	require.NoError(t, err, "unexpected error writing junit report")
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err, "error reading junit report")
+	assert.True(t, strings.HasPrefix(string(content), xml.Header), "missing xml header")
+	var report JUnitTestSuites
+	require.NoError(t, xml.Unmarshal(content, &report), "junit report is not valid xml")
+	assert.Equal(t, len(testComments), report.Failures, "invalid number of failures")
+	assert.Equal(t, "failure", report.Suites[0].TestCases[0].Failure.Type, "invalid failure type")
+	assert.Equal(t, testComments[0].Body, report.Suites[0].TestCases[0].Failure.Message, "invalid failure message")
+}
@@ -0,0 +1,74 @@
+package reporter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+)
+
+// Supported report formats
+const (
+	FormatJSON  = "json"
+	FormatSarif = "sarif"
+	FormatJUnit = "junit"
+)
+
+const (
+	toolName           = "nightfalldlp"
+	toolInformationURI = "https://github.com/nightfallai/nightfall_code_scanner"
+)
+
+// Reporter writes the Nightfall DLP findings to a report file for other tools to consume
+type Reporter interface {
+	// Report writes the findings at the given annotation level
+	Report(comments []*diffreviewer.Comment, level string) error
+}
+
+// NewReporter creates a reporter writing the given format to path
+func NewReporter(format, path string) (Reporter, error) {
+	if path == "" {
+		return nil, fmt.Errorf("missing path for %s report", format)
+	}
+	switch format {
+	case FormatJSON:
+		return NewJSONReporter(path), nil
+	case FormatSarif:
+		return NewSarifReporter(path), nil
+	case FormatJUnit:
+		return NewJUnitReporter(path), nil
+	default:
+		return nil, fmt.Errorf("unsupported report format %q, expected %s, %s or %s", format, FormatJSON, FormatSarif, FormatJUnit)
+	}
+}
+
+// ParseOutput creates a reporter from an output option of the form <format>=<path>
+func ParseOutput(output string) (Reporter, error) {
+	parts := strings.SplitN(output, "=", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid output %q, expected <format>=<path>", output)
+	}
+	return NewReporter(parts[0], parts[1])
+}
+
+// createReportFile creates the file at path, along with any missing parent directories
+func createReportFile(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	return os.Create(path)
+}
+
+// findingMessage describes the finding for reports without the comment body, which
+// holds the raw sensitive fragment when no redaction config is set
+func findingMessage(comment *diffreviewer.Comment) string {
+	if comment.Finding != nil && comment.Finding.RedactedFinding != "" {
+		return fmt.Sprintf("Suspicious content detected (%q, type %q)", comment.Finding.RedactedFinding, comment.DetectorName)
+	}
+	if comment.DetectorName != "" {
+		return fmt.Sprintf("Suspicious content detected (type %q)", comment.DetectorName)
+	}
+	return "Suspicious content detected"
+}
@@ -0,0 +1,32 @@
+package reporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOutput(t *testing.T) {
+	tests := []struct {
+		have    string
+		want    Reporter
+		wantErr string
+	}{
+		{have: "json=out/findings.json", want: &JSONReporter{Path: "out/findings.json"}},
+		{have: "sarif=a=b.sarif", want: &SarifReporter{Path: "a=b.sarif"}},
+		{have: "findings.json", wantErr: `invalid output "findings.json", expected <format>=<path>`},
+		{have: "json=", wantErr: "missing path for json report"},
+		{have: "junit=results/nightfall.xml", want: &JUnitReporter{Path: "results/nightfall.xml"}},
+		{have: "xml=findings.xml", wantErr: `unsupported report format "xml", expected json, sarif or junit`},
+	}
+
+	for _, tt := range tests {
+		r, err := ParseOutput(tt.have)
+		if tt.wantErr != "" {
+			assert.EqualError(t, err, tt.wantErr, "invalid error for %s", tt.have)
+			continue
+		}
+		assert.NoError(t, err, "unexpected error for %s", tt.have)
+		assert.Equal(t, tt.want, r, "invalid reporter for %s", tt.have)
+	}
+}
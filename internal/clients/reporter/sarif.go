@@ -0,0 +1,210 @@
+package reporter
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+	"github.com/nightfallai/nightfall_code_scanner/internal/nightfallconfig"
+)
+
+// SARIF 2.1.0 https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+const (
+	sarifVersion    = "2.1.0"
+	sarifSchema     = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifSrcRoot    = "%SRCROOT%"
+	sarifRulePrefix = "nightfall/"
+	// defaultRuleName is used for findings without a detector display name
+	defaultRuleName = "sensitive-content"
+
+	sarifLevelError   = "error"
+	sarifLevelWarning = "warning"
+	sarifLevelNote    = "note"
+
+	// sarifColumnKind matches the columns of the findings, counted in Unicode code points
+	sarifColumnKind = "unicodeCodePoints"
+)
+
+var nonRuleIDChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// SarifLog is the top level object of a SARIF file
+type SarifLog struct {
+	Version string      `json:"version"`
+	Schema  string      `json:"$schema"`
+	Runs    []*SarifRun `json:"runs"`
+}
+
+// SarifRun is a single invocation of the scanner
+type SarifRun struct {
+	Tool       *SarifTool     `json:"tool"`
+	ColumnKind string         `json:"columnKind,omitempty"`
+	Results    []*SarifResult `json:"results"`
+}
+
+// SarifTool describes the scanner and the rules it reports
+type SarifTool struct {
+	Driver *SarifDriver `json:"driver"`
+}
+
+// SarifDriver is the component of the tool that produced the results
+type SarifDriver struct {
+	Name           string       `json:"name"`
+	InformationURI string       `json:"informationUri"`
+	Rules          []*SarifRule `json:"rules"`
+}
+
+// SarifRule is a reporting descriptor derived from a detector
+type SarifRule struct {
+	ID                   string                  `json:"id"`
+	Name                 string                  `json:"name"`
+	ShortDescription     *SarifMessage           `json:"shortDescription"`
+	DefaultConfiguration *SarifRuleConfiguration `json:"defaultConfiguration"`
+}
+
+// SarifRuleConfiguration holds the default level of a rule
+type SarifRuleConfiguration struct {
+	Level string `json:"level"`
+}
+
+// SarifMessage is a plain text message
+type SarifMessage struct {
+	Text string `json:"text"`
+}
+
+// SarifResult is a single finding
+type SarifResult struct {
+	RuleID    string           `json:"ruleId"`
+	RuleIndex int              `json:"ruleIndex"`
+	Level     string           `json:"level"`
+	Message   *SarifMessage    `json:"message"`
+	Locations []*SarifLocation `json:"locations"`
+}
+
+// SarifLocation is the location of a finding
+type SarifLocation struct {
+	PhysicalLocation *SarifPhysicalLocation `json:"physicalLocation"`
+}
+
+// SarifPhysicalLocation is a region of a file
+type SarifPhysicalLocation struct {
+	ArtifactLocation *SarifArtifactLocation `json:"artifactLocation"`
+	Region           *SarifRegion           `json:"region"`
+}
+
+// SarifArtifactLocation is a file path relative to the repository root
+type SarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId"`
+}
+
+// SarifRegion is a line range within a file, narrowed to the columns of the finding when they are known.
+// Columns are 1-based and the end column is exclusive
+type SarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+// SarifReporter writes the findings as a SARIF 2.1.0 log
+type SarifReporter struct {
+	Path string
+}
+
+// NewSarifReporter creates a reporter writing a SARIF log to path
+func NewSarifReporter(path string) Reporter {
+	return &SarifReporter{Path: path}
+}
+
+// Report writes the findings to the SARIF log
+func (r *SarifReporter) Report(comments []*diffreviewer.Comment, level string) error {
+	f, err := createReportFile(r.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(CreateSarifLog(comments, level))
+}
+
+// CreateSarifLog converts the findings to a SARIF log with one rule per detector
+func CreateSarifLog(comments []*diffreviewer.Comment, level string) *SarifLog {
+	sarifLevel := getSarifLevel(level)
+	rules := make([]*SarifRule, 0)
+	ruleIndexes := make(map[string]int)
+	results := make([]*SarifResult, 0, len(comments))
+	for _, comment := range comments {
+		ruleName := comment.DetectorName
+		if ruleName == "" {
+			ruleName = defaultRuleName
+		}
+		ruleID := getRuleID(ruleName)
+		ruleIndex, ok := ruleIndexes[ruleID]
+		if !ok {
+			ruleIndex = len(rules)
+			ruleIndexes[ruleID] = ruleIndex
+			rules = append(rules, &SarifRule{
+				ID:                   ruleID,
+				Name:                 ruleName,
+				ShortDescription:     &SarifMessage{Text: "Detected " + ruleName},
+				DefaultConfiguration: &SarifRuleConfiguration{Level: sarifLevel},
+			})
+		}
+		region := &SarifRegion{StartLine: comment.LineNumber}
+		if comment.StartColumn > 0 {
+			region.StartColumn = comment.StartColumn
+			region.EndColumn = comment.EndColumn
+		}
+		results = append(results, &SarifResult{
+			RuleID:    ruleID,
+			RuleIndex: ruleIndex,
+			Level:     sarifLevel,
+			Message:   &SarifMessage{Text: findingMessage(comment)},
+			Locations: []*SarifLocation{{
+				PhysicalLocation: &SarifPhysicalLocation{
+					ArtifactLocation: &SarifArtifactLocation{
+						URI:       strings.TrimPrefix(comment.FilePath, "/"),
+						URIBaseID: sarifSrcRoot,
+					},
+					Region: region,
+				},
+			}},
+		})
+	}
+	return &SarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []*SarifRun{{
+			Tool: &SarifTool{
+				Driver: &SarifDriver{
+					Name:           toolName,
+					InformationURI: toolInformationURI,
+					Rules:          rules,
+				},
+			},
+			ColumnKind: sarifColumnKind,
+			Results:    results,
+		}},
+	}
+}
+
+// getRuleID converts a detector display name into a stable rule id, e.g. "Credit Card" -> "nightfall/credit-card"
+func getRuleID(name string) string {
+	id := strings.Trim(nonRuleIDChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if id == "" {
+		id = defaultRuleName
+	}
+	return sarifRulePrefix + id
+}
+
+func getSarifLevel(level string) string {
+	switch level {
+	case nightfallconfig.AnnotationLevelWarning:
+		return sarifLevelWarning
+	case nightfallconfig.AnnotationLevelNotice:
+		return sarifLevelNote
+	default:
+		return sarifLevelError
+	}
+}
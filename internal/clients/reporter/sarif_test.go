@@ -0,0 +1,135 @@
+package reporter
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	nf "github.com/nightfallai/nightfall-go-sdk"
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/diffreviewer"
+	"github.com/nightfallai/nightfall_code_scanner/internal/nightfallconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testComments = []*diffreviewer.Comment{
+	{
+		Title:        "Detected Credit Card Number",
+		Body:         `Suspicious content detected ("4242-****", type "Credit Card Number")`,
+		FilePath:     "src/payments.go",
+		LineNumber:   12,
+		DetectorName: "Credit Card Number",
+		Finding:      &nf.Finding{Finding: "4242-4242-4242-4242", RedactedFinding: "4242-****"},
+		StartColumn:  9,
+		EndColumn:    28,
+	},
+	{
+		Title:        "Detected API_KEY",
+		Body:         `Suspicious content detected ("sk_live_****", type "API_KEY")`,
+		FilePath:     "/config/app.yaml",
+		LineNumber:   3,
+		DetectorName: "API_KEY",
+		Finding:      &nf.Finding{Finding: "sk_live_abcdef", RedactedFinding: "sk_live_****"},
+	},
+	{
+		Title:        "Detected Credit Card Number",
+		Body:         `Suspicious content detected ("5555-****", type "Credit Card Number")`,
+		FilePath:     "src/payments_test.go",
+		LineNumber:   40,
+		DetectorName: "Credit Card Number",
+		Finding:      &nf.Finding{Finding: "5555-5555-5555-4444", RedactedFinding: "5555-****"},
+	},
+	{
+		Body:       `Suspicious content detected ("****", type "")`,
+		FilePath:   "README.md",
+		LineNumber: 1,
+		Finding:    &nf.Finding{Finding: "hunter2", RedactedFinding: "****"},
+	},
+}
+
+func TestCreateSarifLog(t *testing.T) {
+	log := CreateSarifLog(testComments, nightfallconfig.AnnotationLevelWarning)
+
+	assert.Equal(t, "2.1.0", log.Version, "invalid sarif version")
+	require.Len(t, log.Runs, 1, "invalid number of runs")
+	run := log.Runs[0]
+	assert.Equal(t, []*SarifRule{
+		{
+			ID:                   "nightfall/credit-card-number",
+			Name:                 "Credit Card Number",
+			ShortDescription:     &SarifMessage{Text: "Detected Credit Card Number"},
+			DefaultConfiguration: &SarifRuleConfiguration{Level: "warning"},
+		},
+		{
+			ID:                   "nightfall/api-key",
+			Name:                 "API_KEY",
+			ShortDescription:     &SarifMessage{Text: "Detected API_KEY"},
+			DefaultConfiguration: &SarifRuleConfiguration{Level: "warning"},
+		},
+		{
+			ID:                   "nightfall/sensitive-content",
+			Name:                 "sensitive-content",
+			ShortDescription:     &SarifMessage{Text: "Detected sensitive-content"},
+			DefaultConfiguration: &SarifRuleConfiguration{Level: "warning"},
+		},
+	}, run.Tool.Driver.Rules, "invalid rules")
+
+	require.Len(t, run.Results, len(testComments), "invalid number of results")
+	wantRuleIndexes := []int{0, 1, 0, 2}
+	for i, result := range run.Results {
+		assert.Equal(t, wantRuleIndexes[i], result.RuleIndex, "invalid rule index for result %d", i)
+		assert.Equal(t, run.Tool.Driver.Rules[result.RuleIndex].ID, result.RuleID, "invalid rule id for result %d", i)
+		assert.Equal(t, "warning", result.Level, "invalid level for result %d", i)
+		assert.Equal(t, testComments[i].Body, result.Message.Text, "invalid message for result %d", i)
+		assert.Equal(t, testComments[i].LineNumber, result.Locations[0].PhysicalLocation.Region.StartLine, "invalid line for result %d", i)
+	}
+	assert.Equal(t, "config/app.yaml", run.Results[1].Locations[0].PhysicalLocation.ArtifactLocation.URI, "file paths should be relative")
+	assert.Equal(t, "unicodeCodePoints", run.ColumnKind, "invalid column kind")
+	assert.Equal(t, &SarifRegion{StartLine: 12, StartColumn: 9, EndColumn: 28}, run.Results[0].Locations[0].PhysicalLocation.Region, "invalid region with columns")
+	assert.Equal(t, &SarifRegion{StartLine: 3}, run.Results[1].Locations[0].PhysicalLocation.Region, "invalid region without columns")
+}
+
+func TestCreateSarifLogWithoutRedaction(t *testing.T) {
+	comments := []*diffreviewer.Comment{{
+		Body:         `Suspicious content detected ("4242-4242-4242-4242", type "Credit Card Number")`,
+		FilePath:     "src/payments.go",
+		LineNumber:   12,
+		DetectorName: "Credit Card Number",
+		Finding:      &nf.Finding{Finding: "4242-4242-4242-4242"},
+	}}
+
+	log := CreateSarifLog(comments, nightfallconfig.AnnotationLevelFailure)
+	content, err := json.Marshal(log)
+	require.NoError(t, err, "unexpected error encoding sarif log")
+	assert.NotContains(t, string(content), "4242-4242-4242-4242", "the raw finding should not be in the sarif log")
+	assert.Equal(t, `Suspicious content detected (type "Credit Card Number")`, log.Runs[0].Results[0].Message.Text, "invalid message")
+}
+
+func TestGetSarifLevel(t *testing.T) {
+	assert.Equal(t, "error", getSarifLevel(nightfallconfig.AnnotationLevelFailure))
+	assert.Equal(t, "warning", getSarifLevel(nightfallconfig.AnnotationLevelWarning))
+	assert.Equal(t, "note", getSarifLevel(nightfallconfig.AnnotationLevelNotice))
+}
+
+func TestSarifReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nightfalldlp-sarif")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "reports", "nightfall.sarif")
+
+	err = NewSarifReporter(path).Report(testComments, nightfallconfig.AnnotationLevelFailure)
+	require.NoError(t, err, "unexpected error writing sarif report")
+
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err, "error reading sarif report")
+	var raw map[string]interface{}
+	require.NoError(t, json.Unmarshal(content, &raw), "sarif report is not valid json")
+	assert.Equal(t, "2.1.0", raw["version"])
+	assert.Equal(t, "https://json.schemastore.org/sarif-2.1.0.json", raw["$schema"])
+
+	var log SarifLog
+	require.NoError(t, json.Unmarshal(content, &log))
+	assert.Equal(t, CreateSarifLog(testComments, nightfallconfig.AnnotationLevelFailure), &log, "invalid sarif report")
+}
@@ -26,6 +26,15 @@ func (r *RangeMap) Find(key int) (exists bool, value int, canBeInsertedAt int) {
 	return false, 0, i
 }
 
+// FindRange returns the range holding key along with its value
+func (r *RangeMap) FindRange(key int) (exists bool, left int, right int, value int) {
+	exists, value, i := r.Find(key)
+	if !exists {
+		return false, 0, 0, 0
+	}
+	return true, r.rangeValues[i].left, r.rangeValues[i].right, value
+}
+
 func (r *RangeMap) AddRange(left int, right int, value int) error {
 	//check if range does not overlap
 	leftExists, _, toBeInsertedAtLeft := r.Find(left)
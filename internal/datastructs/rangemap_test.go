@@ -30,3 +30,16 @@ func TestRangeMap(t *testing.T) {
 	exists, _, _ = rangeMap.Find(200)
 	assert.False(t, exists)
 }
+
+func TestRangeMapFindRange(t *testing.T) {
+	rangeMap := datastructs.NewRangeMap()
+	assert.NoError(t, rangeMap.AddRange(0, 10, 1))
+	assert.NoError(t, rangeMap.AddRange(11, 30, 2))
+	exists, left, right, value := rangeMap.FindRange(15)
+	assert.True(t, exists)
+	assert.Equal(t, 11, left)
+	assert.Equal(t, 30, right)
+	assert.Equal(t, 2, value)
+	exists, _, _, _ = rangeMap.FindRange(31)
+	assert.False(t, exists)
+}
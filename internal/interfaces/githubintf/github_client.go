@@ -4,6 +4,8 @@ package githubintf
 
 type GithubClient interface {
 	ChecksService() GithubChecks
+	IssuesService() GithubIssues
 	PullRequestsService() GithubPullRequests
 	RepositoriesService() GithubRepositories
+	StatusesService() GithubStatuses
 }
@@ -0,0 +1,15 @@
+package githubintf
+
+import (
+	"context"
+
+	"github.com/google/go-github/v33/github"
+)
+
+//go:generate go run github.com/golang/mock/mockgen -destination=../../mocks/clients/githubissues_mock/githubissues_mock.go -source=../githubintf/github_issues.go -package=githubissues_mock -mock_names=GithubIssues=GithubIssues
+
+type GithubIssues interface {
+	CreateComment(ctx context.Context, owner string, repo string, number int, comment *github.IssueComment) (*github.IssueComment, *github.Response, error)
+	ListComments(ctx context.Context, owner string, repo string, number int, opts *github.IssueListCommentsOptions) ([]*github.IssueComment, *github.Response, error)
+	EditComment(ctx context.Context, owner string, repo string, commentID int64, comment *github.IssueComment) (*github.IssueComment, *github.Response, error)
+}
@@ -0,0 +1,13 @@
+package githubintf
+
+import (
+	"context"
+
+	"github.com/google/go-github/v33/github"
+)
+
+//go:generate go run github.com/golang/mock/mockgen -destination=../../mocks/clients/githubstatuses_mock/githubstatuses_mock.go -source=../githubintf/github_statuses.go -package=githubstatuses_mock -mock_names=GithubStatuses=GithubStatuses
+
+type GithubStatuses interface {
+	CreateStatus(ctx context.Context, owner, repo, ref string, status *github.RepoStatus) (*github.RepoStatus, *github.Response, error)
+}
@@ -66,17 +66,17 @@ func (mr *DiffReviewerMockRecorder) GetDiff() *gomock.Call {
 }
 
 // WriteComments mocks base method
-func (m *DiffReviewer) WriteComments(comments []*diffreviewer.Comment, level string) error {
+func (m *DiffReviewer) WriteComments(comments []*diffreviewer.Comment, level string, failure *diffreviewer.ScanFailure) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "WriteComments", comments, level)
+	ret := m.ctrl.Call(m, "WriteComments", comments, level, failure)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // WriteComments indicates an expected call of WriteComments
-func (mr *DiffReviewerMockRecorder) WriteComments(comments, level interface{}) *gomock.Call {
+func (mr *DiffReviewerMockRecorder) WriteComments(comments, level, failure interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteComments", reflect.TypeOf((*DiffReviewer)(nil).WriteComments), comments, level)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteComments", reflect.TypeOf((*DiffReviewer)(nil).WriteComments), comments, level, failure)
 }
 
 // GetLogger mocks base method
@@ -47,6 +47,20 @@ func (mr *GithubClientMockRecorder) ChecksService() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChecksService", reflect.TypeOf((*GithubClient)(nil).ChecksService))
 }
 
+// IssuesService mocks base method
+func (m *GithubClient) IssuesService() githubintf.GithubIssues {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IssuesService")
+	ret0, _ := ret[0].(githubintf.GithubIssues)
+	return ret0
+}
+
+// IssuesService indicates an expected call of IssuesService
+func (mr *GithubClientMockRecorder) IssuesService() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IssuesService", reflect.TypeOf((*GithubClient)(nil).IssuesService))
+}
+
 // PullRequestsService mocks base method
 func (m *GithubClient) PullRequestsService() githubintf.GithubPullRequests {
 	m.ctrl.T.Helper()
@@ -74,3 +88,17 @@ func (mr *GithubClientMockRecorder) RepositoriesService() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RepositoriesService", reflect.TypeOf((*GithubClient)(nil).RepositoriesService))
 }
+
+// StatusesService mocks base method
+func (m *GithubClient) StatusesService() githubintf.GithubStatuses {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StatusesService")
+	ret0, _ := ret[0].(githubintf.GithubStatuses)
+	return ret0
+}
+
+// StatusesService indicates an expected call of StatusesService
+func (mr *GithubClientMockRecorder) StatusesService() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StatusesService", reflect.TypeOf((*GithubClient)(nil).StatusesService))
+}
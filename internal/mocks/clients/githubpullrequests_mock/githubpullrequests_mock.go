@@ -65,3 +65,35 @@ func (mr *GithubPullRequestsMockRecorder) ListComments(ctx, owner, repo, number,
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListComments", reflect.TypeOf((*GithubPullRequests)(nil).ListComments), ctx, owner, repo, number, opts)
 }
+
+// EditComment mocks base method
+func (m *GithubPullRequests) EditComment(ctx context.Context, owner, repo string, commentID int64, comment *github.PullRequestComment) (*github.PullRequestComment, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EditComment", ctx, owner, repo, commentID, comment)
+	ret0, _ := ret[0].(*github.PullRequestComment)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// EditComment indicates an expected call of EditComment
+func (mr *GithubPullRequestsMockRecorder) EditComment(ctx, owner, repo, commentID, comment interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EditComment", reflect.TypeOf((*GithubPullRequests)(nil).EditComment), ctx, owner, repo, commentID, comment)
+}
+
+// CreateReview mocks base method
+func (m *GithubPullRequests) CreateReview(ctx context.Context, owner, repo string, number int, review *github.PullRequestReviewRequest) (*github.PullRequestReview, *github.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReview", ctx, owner, repo, number, review)
+	ret0, _ := ret[0].(*github.PullRequestReview)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateReview indicates an expected call of CreateReview
+func (mr *GithubPullRequestsMockRecorder) CreateReview(ctx, owner, repo, number, review interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReview", reflect.TypeOf((*GithubPullRequests)(nil).CreateReview), ctx, owner, repo, number, review)
+}
@@ -0,0 +1,127 @@
+package nightfallconfig
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nightfallai/nightfall_code_scanner/internal/clients/logger"
+)
+
+// BaselineFileName is the path of the baseline file relative to the workspace
+const BaselineFileName = ".nightfalldlp/baseline.json"
+
+const baselineVersion = 1
+
+// Baseline records fingerprints of accepted findings, which are not reported again
+type Baseline struct {
+	Version  int                    `json:"version"`
+	Findings []*BaselineFingerprint `json:"findings"`
+
+	fingerprints map[BaselineFingerprint]bool
+}
+
+// BaselineFingerprint identifies a finding independently of the line it is on.
+// The raw finding is never stored, only a hash of it salted with the file path and detector.
+type BaselineFingerprint struct {
+	FilePath string `json:"filePath"`
+	Detector string `json:"detector"`
+	Hash     string `json:"hash"`
+}
+
+// NewBaselineFingerprint creates the fingerprint of a finding of detector in the file at filePath
+func NewBaselineFingerprint(filePath, detector, finding string) BaselineFingerprint {
+	filePath = strings.TrimPrefix(filePath, "/")
+	sum := sha256.Sum256([]byte(strings.Join([]string{filePath, detector, finding}, "\x00")))
+	return BaselineFingerprint{
+		FilePath: filePath,
+		Detector: detector,
+		Hash:     hex.EncodeToString(sum[:]),
+	}
+}
+
+// NewBaseline creates an empty Baseline
+func NewBaseline() *Baseline {
+	return &Baseline{
+		Version:      baselineVersion,
+		Findings:     make([]*BaselineFingerprint, 0),
+		fingerprints: make(map[BaselineFingerprint]bool),
+	}
+}
+
+// Add records the fingerprint in the baseline, fingerprints already recorded are ignored
+func (b *Baseline) Add(fingerprint BaselineFingerprint) {
+	if b.fingerprints[fingerprint] {
+		return
+	}
+	b.fingerprints[fingerprint] = true
+	b.Findings = append(b.Findings, &fingerprint)
+}
+
+// Contains reports whether the fingerprint is recorded in the baseline
+func (b *Baseline) Contains(fingerprint BaselineFingerprint) bool {
+	return b != nil && b.fingerprints[fingerprint]
+}
+
+// Len returns the number of fingerprints recorded in the baseline
+func (b *Baseline) Len() int {
+	if b == nil {
+		return 0
+	}
+	return len(b.Findings)
+}
+
+// Write writes the baseline to path sorted by file, so that diffs of the file stay readable
+func (b *Baseline) Write(filePath string) error {
+	sort.SliceStable(b.Findings, func(i, j int) bool {
+		fi, fj := b.Findings[i], b.Findings[j]
+		if fi.FilePath != fj.FilePath {
+			return fi.FilePath < fj.FilePath
+		}
+		if fi.Detector != fj.Detector {
+			return fi.Detector < fj.Detector
+		}
+		return fi.Hash < fj.Hash
+	})
+	content, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filePath, append(content, '\n'), 0644)
+}
+
+// GetBaseline loads the baseline file of the workspace, returns nil if there is none
+func GetBaseline(workspacePath, fileName string, logger logger.Logger) (*Baseline, error) {
+	content, err := ioutil.ReadFile(path.Join(workspacePath, fileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	baseline := NewBaseline()
+	var baselineFile Baseline
+	if err := json.Unmarshal(content, &baselineFile); err != nil {
+		return nil, fmt.Errorf("invalid baseline file %s: %w", fileName, err)
+	}
+	if baselineFile.Version != baselineVersion {
+		return nil, fmt.Errorf("unsupported baseline file version %d, expected %d", baselineFile.Version, baselineVersion)
+	}
+	for _, f := range baselineFile.Findings {
+		if f != nil {
+			baseline.Add(*f)
+		}
+	}
+	logger.Info(fmt.Sprintf("Loaded %d findings from baseline %s", baseline.Len(), fileName))
+	return baseline, nil
+}
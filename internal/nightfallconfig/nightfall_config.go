@@ -7,6 +7,8 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"path/filepath"
+	"strconv"
 
 	"github.com/google/uuid"
 	nf "github.com/nightfallai/nightfall-go-sdk"
@@ -22,6 +24,37 @@ const (
 	defaultConditionsInfoMessage = "Using default Detection Rule with detectors API_KEY and CRYPTOGRAPHIC_KEY"
 )
 
+// EngineAPI scans content with the Nightfall API
+const EngineAPI = "api"
+
+// EngineLocal scans content in-process with the local detector engine, without calling the Nightfall API
+const EngineLocal = "local"
+
+// EngineEntropy scans content with the Shannon entropy detector, it can only be listed with other engines
+const EngineEntropy = "entropy"
+
+// EngineCommand prefixes the name of the engine of every command detector
+const EngineCommand = "command"
+
+// AllowCommandDetectorsEnvVar must be set to true by the owner of the pipeline for the command detectors
+// of the config to run, as the config can be edited by the pull requests being scanned
+const AllowCommandDetectorsEnvVar = "NIGHTFALL_ALLOW_COMMAND_DETECTORS"
+
+var engines = map[string]struct{}{EngineAPI: {}, EngineLocal: {}}
+var composableEngines = map[string]struct{}{EngineAPI: {}, EngineLocal: {}, EngineEntropy: {}}
+
+// FailurePolicyFailClosed fails the run when part of the diff could not be scanned
+const FailurePolicyFailClosed = "fail-closed"
+
+// FailurePolicyFailOpen only warns when part of the diff could not be scanned
+const FailurePolicyFailOpen = "fail-open"
+
+// FailurePolicyNeutral warns and sets a neutral conclusion where the code host supports one
+// when part of the diff could not be scanned
+const FailurePolicyNeutral = "neutral"
+
+var failurePolicies = map[string]struct{}{FailurePolicyFailClosed: {}, FailurePolicyFailOpen: {}, FailurePolicyNeutral: {}}
+
 // AnnotationLevelFailure describes the failure severity to render comments on code
 var AnnotationLevelFailure = "failure"
 
@@ -69,6 +102,8 @@ var defaultNightfallConfig = &ConfigFile{
 		},
 	},
 	AnnotationLevel: AnnotationLevelFailure,
+	Engine:          EngineAPI,
+	FailurePolicy:   FailurePolicyFailClosed,
 }
 
 // ConfigFile is the struct of the JSON nightfall config file
@@ -81,6 +116,29 @@ type ConfigFile struct {
 	FileExclusionList      []string            `json:"fileExclusionList"`
 	DefaultRedactionConfig *nf.RedactionConfig `json:"defaultRedactionConfig"`
 	AnnotationLevel        string              `json:"annotationLevel"`
+	Engine                 string              `json:"engine"`
+	Engines                []string            `json:"engines"`
+	CommandDetectors       []*CommandDetector  `json:"commandDetectors"`
+	EntropyDetection       *EntropyConfig      `json:"entropyDetection"`
+	FailurePolicy          string              `json:"failurePolicy"`
+	CacheDirectory         string              `json:"cacheDirectory"`
+}
+
+// EntropyConfig enables the Shannon entropy detector, zero values select the defaults of the charset
+type EntropyConfig struct {
+	Charset   string  `json:"charset"`
+	MinLength int     `json:"minLength"`
+	Threshold float64 `json:"threshold"`
+}
+
+// CommandDetector declares an external detector executable, scanning content received on its standard input
+type CommandDetector struct {
+	// Name is reported as the detector of findings that do not name one
+	Name string `json:"name"`
+	// Command is the executable and its arguments
+	Command []string `json:"command"`
+	// TimeoutSeconds is how long a request may run before the command is killed, no limit if zero
+	TimeoutSeconds int `json:"timeoutSeconds"`
 }
 
 // Config general config struct
@@ -94,6 +152,16 @@ type Config struct {
 	FileExclusionList           []string
 	DefaultRedactionConfig      *nf.RedactionConfig
 	AnnotationLevel             string
+	Engine                      string
+	EntropyDetection            *EntropyConfig
+	Baseline                    *Baseline
+	FailurePolicy               string
+	// CacheDirectory is where the findings of scanned content are cached, caching is disabled if empty
+	CacheDirectory string
+	// Engines are the detection engines whose findings are merged, in order of precedence. Engine is used if empty
+	Engines []string
+	// CommandDetectors are external detector executables, each scanning content as an engine after Engines
+	CommandDetectors []*CommandDetector
 }
 
 // GetNightfallConfigFile loads nightfall config from file, returns default if missing/invalid
@@ -124,7 +192,12 @@ func GetNightfallConfigFile(workspacePath, fileName string, logger logger.Logger
 	} else if nightfallConfig.MaxNumberRoutines > MaxConcurrentRoutinesCap {
 		nightfallConfig.MaxNumberRoutines = MaxConcurrentRoutinesCap
 	}
-	nightfallConfig.FileExclusionList = append(nightfallConfig.FileExclusionList, nightfallConfigFilename)
+	nightfallConfig.FileExclusionList = append(nightfallConfig.FileExclusionList, nightfallConfigFilename, BaselineFileName)
+	if dir := nightfallConfig.CacheDirectory; dir != "" && !filepath.IsAbs(dir) {
+		// a cache inside the repository is resolved from its root and never scanned itself
+		nightfallConfig.FileExclusionList = append(nightfallConfig.FileExclusionList, filepath.ToSlash(filepath.Clean(dir))+"/*")
+		nightfallConfig.CacheDirectory = filepath.Join(workspacePath, dir)
+	}
 	// must be one of notice, warning, or failure
 	if _, ok := annotationLevels[nightfallConfig.AnnotationLevel]; !ok {
 		if nightfallConfig.AnnotationLevel != "" {
@@ -132,5 +205,89 @@ func GetNightfallConfigFile(workspacePath, fileName string, logger logger.Logger
 		}
 		nightfallConfig.AnnotationLevel = AnnotationLevelFailure
 	}
+	// must be one of api or local
+	if _, ok := engines[nightfallConfig.Engine]; !ok {
+		if nightfallConfig.Engine != "" {
+			logger.Warning(fmt.Sprintf("Unknown engine: %s. Defaulting to api", nightfallConfig.Engine))
+		}
+		nightfallConfig.Engine = EngineAPI
+	}
+	// each must be one of api, local or entropy
+	if len(nightfallConfig.Engines) > 0 {
+		nightfallConfig.Engines = validEngines(nightfallConfig.Engines, logger)
+	}
+	nightfallConfig.CommandDetectors = validCommandDetectors(nightfallConfig.CommandDetectors, logger)
+	if len(nightfallConfig.CommandDetectors) > 0 && !commandDetectorsAllowed() {
+		logger.Warning(fmt.Sprintf("Skipping the command detectors of the config, set %s to true in the pipeline to run them", AllowCommandDetectorsEnvVar))
+		nightfallConfig.CommandDetectors = nil
+	}
+	// must be one of fail-closed, fail-open or neutral
+	if _, ok := failurePolicies[nightfallConfig.FailurePolicy]; !ok {
+		if nightfallConfig.FailurePolicy != "" {
+			logger.Warning(fmt.Sprintf("Unknown failure policy: %s. Defaulting to fail-closed", nightfallConfig.FailurePolicy))
+		}
+		nightfallConfig.FailurePolicy = FailurePolicyFailClosed
+	}
 	return &nightfallConfig, nil
 }
+
+// validEngines drops the unknown and repeated engines of the list
+func validEngines(engineList []string, logger logger.Logger) []string {
+	valid := make([]string, 0, len(engineList))
+	seen := make(map[string]bool, len(engineList))
+	for _, engine := range engineList {
+		if _, ok := composableEngines[engine]; !ok {
+			logger.Warning(fmt.Sprintf("Unknown engine: %s. Skipping", engine))
+			continue
+		}
+		if !seen[engine] {
+			seen[engine] = true
+			valid = append(valid, engine)
+		}
+	}
+	if len(valid) == 0 {
+		return nil
+	}
+	return valid
+}
+
+// commandDetectorsAllowed reports whether the owner of the pipeline allowed command detectors to run
+func commandDetectorsAllowed() bool {
+	allowed, _ := strconv.ParseBool(os.Getenv(AllowCommandDetectorsEnvVar))
+	return allowed
+}
+
+// validCommandDetectors drops the command detectors without a command, and names the others after their executable if unnamed
+func validCommandDetectors(detectors []*CommandDetector, logger logger.Logger) []*CommandDetector {
+	if len(detectors) == 0 {
+		return nil
+	}
+	valid := make([]*CommandDetector, 0, len(detectors))
+	for _, detector := range detectors {
+		if detector == nil || len(detector.Command) == 0 || detector.Command[0] == "" {
+			logger.Warning("Skipping command detector without a command")
+			continue
+		}
+		if detector.Name == "" {
+			detector.Name = filepath.Base(detector.Command[0])
+		}
+		valid = append(valid, detector)
+	}
+	if len(valid) == 0 {
+		return nil
+	}
+	return valid
+}
+
+// UsesAPIEngine reports whether the config scans content with the Nightfall API
+func (c *ConfigFile) UsesAPIEngine() bool {
+	if len(c.Engines) == 0 {
+		return c.Engine != EngineLocal
+	}
+	for _, engine := range c.Engines {
+		if engine == EngineAPI {
+			return true
+		}
+	}
+	return false
+}
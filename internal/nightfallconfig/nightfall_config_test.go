@@ -1,10 +1,13 @@
 package nightfallconfig
 
 import (
+	"fmt"
+	"io/ioutil"
 	"os"
 	"path"
 	"testing"
 
+	"github.com/google/uuid"
 	nf "github.com/nightfallai/nightfall-go-sdk"
 	githublogger "github.com/nightfallai/nightfall_code_scanner/internal/clients/logger/github_logger"
 	"github.com/stretchr/testify/assert"
@@ -54,11 +57,13 @@ func TestGetNightfallConfig(t *testing.T) {
 		MaxNumberRoutines:  20,
 		TokenExclusionList: []string{excludedCreditCardRegex, excludedApiToken, excludedIPRegex},
 		FileInclusionList:  []string{"*"},
-		FileExclusionList:  []string{".nightfalldlp/config.json"},
+		FileExclusionList:  []string{".nightfalldlp/config.json", ".nightfalldlp/baseline.json"},
 		DefaultRedactionConfig: &nf.RedactionConfig{
 			SubstitutionConfig: &nf.SubstitutionConfig{SubstitutionPhrase: "REDACTED"},
 		},
 		AnnotationLevel: "warning",
+		Engine:          "api",
+		FailurePolicy:   "fail-closed",
 	}
 	actualConfig, err := GetNightfallConfigFile(workspacePath, testFileName, nil)
 	assert.NoError(t, err, "Unexpected error in test GetNightfallConfig")
@@ -107,6 +112,8 @@ func TestGetNightfallConfigMissingConfigFile(t *testing.T) {
 			},
 		},
 		AnnotationLevel: "failure",
+		Engine:          "api",
+		FailurePolicy:   "fail-closed",
 	}
 	actualConfig, err := GetNightfallConfigFile(workspacePath, testMissingFileName, githublogger.NewDefaultGithubLogger())
 	assert.NoError(t, err, "Unexpected error in test GetNightfallConfigMissingConfigFile")
@@ -148,10 +155,156 @@ func TestGetNightfallConfigWrongAnnotationLevel(t *testing.T) {
 			},
 		},
 		MaxNumberRoutines: 20,
-		FileExclusionList: []string{".nightfalldlp/config.json"},
+		FileExclusionList: []string{".nightfalldlp/config.json", ".nightfalldlp/baseline.json"},
 		AnnotationLevel:   "failure",
+		Engine:            "api",
+		FailurePolicy:     "fail-closed",
 	}
 	actualConfig, err := GetNightfallConfigFile(workspacePath, testAnnotationFileName, githublogger.NewDefaultGithubLogger())
 	assert.NoError(t, err, "Unexpected error in test GetNightfallConfig")
 	assert.Equal(t, expectedConfig, actualConfig, "Incorrect nightfall config")
 }
+
+func TestGetNightfallConfigFailurePolicy(t *testing.T) {
+	tests := []struct {
+		haveFailurePolicy string
+		wantFailurePolicy string
+		desc              string
+	}{
+		{
+			haveFailurePolicy: "neutral",
+			wantFailurePolicy: FailurePolicyNeutral,
+			desc:              "neutral",
+		},
+		{
+			haveFailurePolicy: "fail-open",
+			wantFailurePolicy: FailurePolicyFailOpen,
+			desc:              "fail open",
+		},
+		{
+			haveFailurePolicy: "ignore",
+			wantFailurePolicy: FailurePolicyFailClosed,
+			desc:              "unknown policy",
+		},
+	}
+	for _, tt := range tests {
+		workspacePath, err := ioutil.TempDir("", "nightfalldlp-config")
+		assert.NoError(t, err, "Unexpected error creating temp dir")
+		content := fmt.Sprintf(`{"detectionRuleUUIDs": ["%s"], "failurePolicy": %q}`, uuid.New(), tt.haveFailurePolicy)
+		err = ioutil.WriteFile(path.Join(workspacePath, testFileName), []byte(content), 0644)
+		assert.NoError(t, err, "Unexpected error writing config file")
+
+		actualConfig, err := GetNightfallConfigFile(workspacePath, testFileName, githublogger.NewDefaultGithubLogger())
+		os.RemoveAll(workspacePath)
+		assert.NoError(t, err, fmt.Sprintf("Unexpected error in %s test", tt.desc))
+		assert.Equal(t, tt.wantFailurePolicy, actualConfig.FailurePolicy, fmt.Sprintf("Incorrect failure policy in %s test", tt.desc))
+	}
+}
+
+func TestGetNightfallConfigCacheDirectory(t *testing.T) {
+	workspacePath, err := ioutil.TempDir("", "nightfalldlp-config")
+	assert.NoError(t, err, "Unexpected error creating temp dir")
+	defer os.RemoveAll(workspacePath)
+	tests := []struct {
+		haveCacheDirectory string
+		wantCacheDirectory string
+		wantExclusion      string
+		desc               string
+	}{
+		{
+			haveCacheDirectory: "./.cache/nightfall/",
+			wantCacheDirectory: path.Join(workspacePath, ".cache/nightfall"),
+			wantExclusion:      ".cache/nightfall/*",
+			desc:               "relative directory",
+		},
+		{
+			haveCacheDirectory: "/tmp/nightfall-cache",
+			wantCacheDirectory: "/tmp/nightfall-cache",
+			desc:               "absolute directory",
+		},
+	}
+	for _, tt := range tests {
+		content := fmt.Sprintf(`{"detectionRuleUUIDs": ["%s"], "cacheDirectory": %q}`, uuid.New(), tt.haveCacheDirectory)
+		err = ioutil.WriteFile(path.Join(workspacePath, testFileName), []byte(content), 0644)
+		assert.NoError(t, err, "Unexpected error writing config file")
+
+		actualConfig, err := GetNightfallConfigFile(workspacePath, testFileName, githublogger.NewDefaultGithubLogger())
+		assert.NoError(t, err, fmt.Sprintf("Unexpected error in %s test", tt.desc))
+		assert.Equal(t, tt.wantCacheDirectory, actualConfig.CacheDirectory, fmt.Sprintf("Incorrect cache directory in %s test", tt.desc))
+		wantExclusionList := []string{".nightfalldlp/config.json", ".nightfalldlp/baseline.json"}
+		if tt.wantExclusion != "" {
+			wantExclusionList = append(wantExclusionList, tt.wantExclusion)
+		}
+		assert.Equal(t, wantExclusionList, actualConfig.FileExclusionList, fmt.Sprintf("Incorrect file exclusion list in %s test", tt.desc))
+	}
+}
+
+func TestGetNightfallConfigEngines(t *testing.T) {
+	workspacePath, err := ioutil.TempDir("", "nightfalldlp-config")
+	assert.NoError(t, err, "Unexpected error creating temp dir")
+	defer os.RemoveAll(workspacePath)
+	tests := []struct {
+		haveEngines       string
+		wantEngines       []string
+		wantUsesAPIEngine bool
+		desc              string
+	}{
+		{
+			haveEngines:       `["local", "entropy"]`,
+			wantEngines:       []string{EngineLocal, EngineEntropy},
+			wantUsesAPIEngine: false,
+			desc:              "local engines",
+		},
+		{
+			haveEngines:       `["api", "yara", "entropy", "api"]`,
+			wantEngines:       []string{EngineAPI, EngineEntropy},
+			wantUsesAPIEngine: true,
+			desc:              "unknown and repeated engines",
+		},
+		{
+			haveEngines:       `["yara"]`,
+			wantEngines:       nil,
+			wantUsesAPIEngine: true,
+			desc:              "no valid engine",
+		},
+	}
+	for _, tt := range tests {
+		content := fmt.Sprintf(`{"detectionRuleUUIDs": ["%s"], "engines": %s}`, uuid.New(), tt.haveEngines)
+		err = ioutil.WriteFile(path.Join(workspacePath, testFileName), []byte(content), 0644)
+		assert.NoError(t, err, "Unexpected error writing config file")
+
+		actualConfig, err := GetNightfallConfigFile(workspacePath, testFileName, githublogger.NewDefaultGithubLogger())
+		assert.NoError(t, err, fmt.Sprintf("Unexpected error in %s test", tt.desc))
+		assert.Equal(t, tt.wantEngines, actualConfig.Engines, fmt.Sprintf("Incorrect engines in %s test", tt.desc))
+		assert.Equal(t, tt.wantUsesAPIEngine, actualConfig.UsesAPIEngine(), fmt.Sprintf("Incorrect use of the api engine in %s test", tt.desc))
+	}
+}
+
+func TestGetNightfallConfigCommandDetectors(t *testing.T) {
+	workspacePath, err := ioutil.TempDir("", "nightfalldlp-config")
+	assert.NoError(t, err, "Unexpected error creating temp dir")
+	defer os.RemoveAll(workspacePath)
+	content := fmt.Sprintf(`{
+		"detectionRuleUUIDs": ["%s"],
+		"commandDetectors": [
+			{"name": "in-house", "command": ["python3", "detectors/scan.py"], "timeoutSeconds": 30},
+			{"command": ["/opt/detectors/bin/tokens", "--json"]},
+			{"name": "empty", "command": []}
+		]
+	}`, uuid.New())
+	err = ioutil.WriteFile(path.Join(workspacePath, testFileName), []byte(content), 0644)
+	assert.NoError(t, err, "Unexpected error writing config file")
+
+	actualConfig, err := GetNightfallConfigFile(workspacePath, testFileName, githublogger.NewDefaultGithubLogger())
+	assert.NoError(t, err, "Unexpected error in command detectors test")
+	assert.Empty(t, actualConfig.CommandDetectors, "command detectors should not run unless allowed by the pipeline")
+
+	os.Setenv(AllowCommandDetectorsEnvVar, "true")
+	defer os.Unsetenv(AllowCommandDetectorsEnvVar)
+	actualConfig, err = GetNightfallConfigFile(workspacePath, testFileName, githublogger.NewDefaultGithubLogger())
+	assert.NoError(t, err, "Unexpected error in command detectors test")
+	assert.Equal(t, []*CommandDetector{
+		{Name: "in-house", Command: []string{"python3", "detectors/scan.py"}, TimeoutSeconds: 30},
+		{Name: "tokens", Command: []string{"/opt/detectors/bin/tokens", "--json"}},
+	}, actualConfig.CommandDetectors, "Incorrect command detectors")
+}